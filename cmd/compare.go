@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+	"github.com/fdddf/xcstrings-translator/internal/translator"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// compareCmd translates a sample of the catalog with several providers side by side.
+var compareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compare providers on a sample of the catalog",
+	Long: `Send the same sample of strings to several configured providers at once and report
+their translations side by side with QA findings, latency and estimated cost.
+
+Providers are set up in their config file sections as for translating, and prices
+come from the pricing section. For example, to compare DeepL and OpenAI on up to 30
+settings strings in Japanese and German:
+
+  xcstrings-translator compare -t ja,de --providers deepl,openai --keys "settings.*" --sample 30
+
+With --pick the chosen translations are written to the output file: "auto" picks
+the candidate with the fewest QA warnings, then the cheapest and the fastest, and
+"interactive" asks for every string.`,
+	RunE: runCompare,
+}
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+
+	compareCmd.Flags().StringSlice("providers", []string{}, "Providers to compare, e.g. deepl,openai (required)")
+	compareCmd.Flags().Int("sample", 20, "Number of keys to compare, spread over the catalog (0 = all matching keys)")
+	compareCmd.Flags().StringSlice("keys", []string{}, "Glob patterns for keys to compare, e.g. \"settings.*\" (default: all keys)")
+	compareCmd.Flags().String("format", "table", "Report format (table, html, json)")
+	compareCmd.Flags().String("report", "", "Write the report to this file instead of standard output")
+	compareCmd.Flags().String("pick", "", "Write a winner per string to the output file (auto, interactive)")
+}
+
+func runCompare(cmd *cobra.Command, args []string) error {
+	opts, err := loadTranslateOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	names, _ := cmd.Flags().GetStringSlice("providers")
+	sample, _ := cmd.Flags().GetInt("sample")
+	keys, _ := cmd.Flags().GetStringSlice("keys")
+	format, _ := cmd.Flags().GetString("format")
+	reportFile, _ := cmd.Flags().GetString("report")
+	pick, _ := cmd.Flags().GetString("pick")
+
+	var providers []string
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(providerNames, name) {
+			return fmt.Errorf("unknown provider %q (want one of %s)", name, strings.Join(providerNames, ", "))
+		}
+		if !slices.Contains(providers, name) {
+			providers = append(providers, name)
+		}
+	}
+	if len(providers) < 2 {
+		return errors.New("--providers needs at least two providers to compare")
+	}
+	switch format {
+	case "table", "html", "json":
+	default:
+		return fmt.Errorf("unknown report format %q (want table, html or json)", format)
+	}
+	switch pick {
+	case "", "auto", "interactive":
+	default:
+		return fmt.Errorf("unknown pick mode %q (want auto or interactive)", pick)
+	}
+
+	xcstrings, err := model.LoadXCStrings(opts.InputFile)
+	if err != nil {
+		return fmt.Errorf("error loading xcstrings file: %w", err)
+	}
+	if opts.SourceLanguage != "" {
+		xcstrings.SourceLanguage = opts.SourceLanguage
+	}
+
+	requests, err := translator.ComparisonRequests(xcstrings, translator.CompareOptions{
+		Languages:   opts.TargetLanguages,
+		KeyPatterns: keys,
+		Sample:      sample,
+	})
+	if err != nil {
+		return err
+	}
+	if len(requests) == 0 {
+		fmt.Println("No strings matched. Nothing to compare.")
+		return nil
+	}
+
+	protected, err := opts.protectedTerms()
+	if err != nil {
+		return err
+	}
+
+	var services []*translator.TranslationService
+	for _, name := range providers {
+		service := translator.NewTranslationService(configuredProvider(name, opts), opts.Concurrency, 300*time.Second)
+		service.Name = name
+		service.Retry = loadRetryPolicy(cmd, name)
+		service.Limiter = translator.NewRateLimiter(configRateLimit(name))
+		service.Validator = opts.Validator
+		service.Glossary = opts.Glossary
+		service.Protected = protected
+		services = append(services, service)
+	}
+
+	fmt.Printf("Comparing %s on %d strings...\n", strings.Join(providers, ", "), len(requests))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	comparison := translator.Compare(ctx, requests, services, loadPriceTable())
+
+	switch pick {
+	case "auto":
+		comparison.PickBest()
+	case "interactive":
+		comparison.PickBest()
+		pickInteractively(os.Stdin, comparison)
+	}
+
+	out := io.Writer(os.Stdout)
+	if reportFile != "" {
+		file, err := os.Create(reportFile)
+		if err != nil {
+			return fmt.Errorf("failed to create report: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := translator.WriteComparison(out, comparison, format); err != nil {
+		return err
+	}
+	if reportFile != "" {
+		fmt.Printf("Comparison saved to: %s\n", reportFile)
+	}
+
+	if pick == "" {
+		return nil
+	}
+	winners := comparison.Winners()
+	if len(winners) == 0 {
+		fmt.Println("No translation picked. The catalog is unchanged.")
+		return nil
+	}
+	translator.ApplyTranslations(xcstrings, winners, opts.OutputState)
+	if err := model.SaveXCStrings(opts.OutputFile, xcstrings); err != nil {
+		return fmt.Errorf("error saving output file: %w", err)
+	}
+	fmt.Printf("Wrote %d picked translations to: %s\n", len(winners), opts.OutputFile)
+	return nil
+}
+
+// pickInteractively asks for the winner of every row, offering the one
+// already picked as the default.
+func pickInteractively(in io.Reader, comparison *translator.Comparison) {
+	reader := bufio.NewReader(in)
+	for i := range comparison.Rows {
+		row := &comparison.Rows[i]
+		fmt.Printf("\n%s [%s]: %s\n", row.Key, row.Language, row.Source)
+		if row.Path != "" {
+			fmt.Printf("  variation: %s\n", row.Path)
+		}
+		if row.Comment != "" {
+			fmt.Printf("  comment: %s\n", row.Comment)
+		}
+		suggested := 0
+		for j, candidate := range row.Candidates {
+			if candidate.Provider == row.Winner {
+				suggested = j + 1
+			}
+			if candidate.Error != "" {
+				fmt.Printf("  -  %s: failed: %s\n", candidate.Provider, candidate.Error)
+				continue
+			}
+			fmt.Printf("  %d. %s: %s\n", j+1, candidate.Provider, candidate.Text)
+			for _, warning := range candidate.Warnings {
+				fmt.Printf("       QA %s\n", warning)
+			}
+		}
+		if suggested == 0 {
+			continue
+		}
+
+		for {
+			fmt.Printf("Pick 1-%d, s to skip [%d]: ", len(row.Candidates), suggested)
+			line, err := reader.ReadString('\n')
+			answer := strings.TrimSpace(line)
+			if answer == "" {
+				if err != nil {
+					// Out of input: keep the suggestions from here on.
+					fmt.Println()
+					return
+				}
+				break
+			}
+			if answer == "s" {
+				row.Winner = ""
+				break
+			}
+			n, convErr := strconv.Atoi(answer)
+			if convErr == nil && n >= 1 && n <= len(row.Candidates) && row.Candidates[n-1].Error == "" {
+				row.Winner = row.Candidates[n-1].Provider
+				break
+			}
+			fmt.Println("Not a translated candidate.")
+		}
+	}
+}
+
+// loadPriceTable reads the pricing section: a price per provider and,
+// under models, per model.
+func loadPriceTable() translator.PriceTable {
+	table := translator.PriceTable{}
+	for _, name := range providerNames {
+		section := "pricing." + name
+		if !viper.IsSet(section) {
+			continue
+		}
+		table[name] = translator.Price{
+			PerMillionCharacters:   viper.GetFloat64(section + ".per_million_characters"),
+			PerMillionInputTokens:  viper.GetFloat64(section + ".per_million_input_tokens"),
+			PerMillionOutputTokens: viper.GetFloat64(section + ".per_million_output_tokens"),
+		}
+		// Model names often contain dots, which viper would read as nesting,
+		// so the models are read as a plain map.
+		for modelName, value := range viper.GetStringMap(section + ".models") {
+			fields, _ := value.(map[string]any)
+			table[name+"/"+strings.ToLower(modelName)] = translator.Price{
+				PerMillionCharacters:   configFloat(fields["per_million_characters"]),
+				PerMillionInputTokens:  configFloat(fields["per_million_input_tokens"]),
+				PerMillionOutputTokens: configFloat(fields["per_million_output_tokens"]),
+			}
+		}
+	}
+	return table
+}
+
+// configFloat converts a number read from the config file.
+func configFloat(value any) float64 {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	}
+	return 0
+}
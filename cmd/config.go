@@ -52,6 +52,43 @@ global:
     - "ko"
   concurrency: ` + fmt.Sprintf("%d", cfg.Global.Concurrency) + `
   verbose: ` + fmt.Sprintf("%t", cfg.Global.Verbose) + `
+  # Extra states to translate again: needs_review, stale, translated, or all
+  retranslate: []
+  # State written on machine translations: needs_review, translated or new
+  output_state: "` + cfg.Global.OutputState + `"
+  # Save successful translations when some fail, and report the failures.
+  continue_on_error: ` + fmt.Sprintf("%t", cfg.Global.ContinueOnError) + `
+  max_errors: ` + fmt.Sprintf("%d", cfg.Global.MaxErrors) + `
+  failure_report: "` + cfg.Global.FailureReport + `"
+  # JSON file for the characters, tokens, time and cost of a run per language and provider.
+  usage_report: "` + cfg.Global.UsageReport + `"
+  # JSON lines file for the queued, started, retrying, succeeded and failed events of every string.
+  event_log: "` + cfg.Global.EventLog + `"
+  # Journal of finished translations for --resume; empty means next to the output, "off" disables it.
+  checkpoint: "` + cfg.Global.Checkpoint + `"
+  # Cache of earlier translations reused across runs; empty means the user cache directory, "off" disables it.
+  translation_memory: "` + cfg.Global.TranslationMemory + `"
+  # Project glossary (YAML or JSON) of terms with mandated translations.
+  glossary_file: "` + cfg.Global.GlossaryFile + `"
+  # Terms kept verbatim, e.g. ["Pro", "iCloud"]; <catalog>.do-not-translate.txt adds more per catalog.
+  do_not_translate: []
+  # Providers tried in order when the main one fails, e.g. ["google", "openai"],
+  # for these error classes (empty means quota and unsupported).
+  fallback: []
+  fallback_on: []
+  # Stop sending once a run would spend more than this (0 = unlimited); the cost
+  # is priced from the pricing section.
+  budget:
+    max_cost: ` + fmt.Sprintf("%g", cfg.Global.Budget.MaxCost) + `
+    max_characters: ` + fmt.Sprintf("%d", cfg.Global.Budget.MaxCharacters) + `
+    max_tokens: ` + fmt.Sprintf("%d", cfg.Global.Budget.MaxTokens) + `
+  # Retries for transient failures (429, 5xx, network errors); Retry-After is honoured.
+  # Each provider section below may define its own retry block to override this.
+  retry:
+    max_attempts: ` + fmt.Sprintf("%d", cfg.Global.Retry.MaxAttempts) + `
+    initial_backoff: "` + cfg.Global.Retry.InitialBackoff.String() + `"
+    max_backoff: "` + cfg.Global.Retry.MaxBackoff.String() + `"
+    jitter: ` + fmt.Sprintf("%.1f", cfg.Global.Retry.Jitter) + `
 
 # Google Translate API configuration
 google:
@@ -69,6 +106,9 @@ deepl:
 baidu:
   app_id: "your-baidu-app-id-here"
   app_secret: "your-baidu-app-secret-here"
+  # The standard tier allows 1 query per second; raise this on higher tiers.
+  rate_limit:
+    requests_per_second: ` + fmt.Sprintf("%g", cfg.Baidu.RateLimit.RequestsPerSecond) + `
 
 # OpenAI compatible API configuration
 openai:
@@ -77,6 +117,41 @@ openai:
   model: "` + cfg.OpenAI.Model + `"
   temperature: ` + fmt.Sprintf("%.1f", cfg.OpenAI.Temperature) + `
   max_tokens: ` + fmt.Sprintf("%d", cfg.OpenAI.MaxTokens) + `
+  # Match your account's limits; 0 means unlimited.
+  rate_limit:
+    requests_per_second: 0
+    tokens_per_minute: 0
+
+# Checks on every translation before it is written: error rejects it,
+# warning only reports it, off skips the rule.
+qa:
+  rules:
+    placeholders: "` + cfg.QA.Rules["placeholders"] + `"
+    whitespace: "` + cfg.QA.Rules["whitespace"] + `"
+    punctuation: "` + cfg.QA.Rules["punctuation"] + `"
+    length: "` + cfg.QA.Rules["length"] + `"
+    untranslated: "` + cfg.QA.Rules["untranslated"] + `"
+    glossary: "` + cfg.QA.Rules["glossary"] + `"
+    protected: "` + cfg.QA.Rules["protected"] + `"
+  min_length_ratio: ` + fmt.Sprintf("%g", cfg.QA.MinLengthRatio) + `
+  max_length_ratio: ` + fmt.Sprintf("%g", cfg.QA.MaxLengthRatio) + `
+  min_length_source: ` + fmt.Sprintf("%d", cfg.QA.MinLengthSource) + `
+
+# What each provider charges, for the cost estimates of --dry-run and compare.
+# Example rates in one currency; check your own contract. Models listed
+# under an OpenAI compatible provider override its price.
+pricing:
+  google:
+    per_million_characters: 20
+  deepl:
+    per_million_characters: 25
+  openai:
+    per_million_input_tokens: 0.5
+    per_million_output_tokens: 1.5
+    models:
+      gpt-4o-mini:
+        per_million_input_tokens: 0.15
+        per_million_output_tokens: 0.6
 `
 
 	// Write the config file
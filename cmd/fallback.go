@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+	"github.com/fdddf/xcstrings-translator/internal/translator"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// providerNames lists the providers a fallback chain may name.
+var providerNames = []string{"google", "deepl", "baidu", "openai"}
+
+// loadFallbacks reads global.fallback and global.fallback_on; --fallback and
+// --fallback-on override them.
+func loadFallbacks(cmd *cobra.Command, primary string) ([]fallbackOptions, []string, error) {
+	names := viper.GetStringSlice("global.fallback")
+	if cmd.Flags().Changed("fallback") {
+		names, _ = cmd.Flags().GetStringSlice("fallback")
+	}
+	on := viper.GetStringSlice("global.fallback_on")
+	if cmd.Flags().Changed("fallback-on") {
+		on, _ = cmd.Flags().GetStringSlice("fallback-on")
+	}
+
+	seen := []string{primary}
+	var fallbacks []fallbackOptions
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(providerNames, name) {
+			return nil, nil, fmt.Errorf("unknown fallback provider %q (want one of %s)", name, strings.Join(providerNames, ", "))
+		}
+		if slices.Contains(seen, name) {
+			return nil, nil, fmt.Errorf("provider %s appears twice in the fallback chain", name)
+		}
+		seen = append(seen, name)
+		fallbacks = append(fallbacks, fallbackOptions{
+			Provider:  name,
+			Retry:     loadRetryPolicy(cmd, name),
+			RateLimit: configRateLimit(name),
+			Batch:     configBatchLimits(name),
+		})
+	}
+
+	if len(on) == 0 {
+		on = translator.DefaultFallbackOn
+	}
+	for _, class := range on {
+		if !slices.Contains(translator.ErrorClasses, class) {
+			return nil, nil, fmt.Errorf("unknown error class %q in fallback_on (want one of %s)", class, strings.Join(translator.ErrorClasses, ", "))
+		}
+	}
+	return fallbacks, on, nil
+}
+
+// configuredProvider builds a fallback provider from its config section.
+func configuredProvider(name string, opts translateOptions) model.TranslationProvider {
+	switch name {
+	case "google":
+		provider := translator.NewGoogleTranslator(viper.GetString("google.api_key"))
+		provider.Glossary = viper.GetString("google.glossary")
+		if provider.Glossary == "" && opts.Glossary != nil {
+			provider.Glossary = opts.Glossary.Google
+		}
+		return provider
+	case "deepl":
+		provider := translator.NewDeepLTranslator(viper.GetString("deepl.api_key"), viper.GetBool("deepl.is_free"))
+		if opts.Glossary != nil {
+			provider.Glossaries = opts.Glossary.DeepL
+		}
+		return provider
+	case "baidu":
+		return translator.NewBaiduTranslator(viper.GetString("baidu.app_id"), viper.GetString("baidu.app_secret"))
+	default:
+		return translator.NewOpenAITranslator(viper.GetString("openai.api_key"), viper.GetString("openai.api_base_url"),
+			viper.GetString("openai.model"), viper.GetFloat64("openai.temperature"), viper.GetInt("openai.max_tokens"))
+	}
+}
+
+// addFallbacks chains a service for every fallback provider behind service.
+// They share its QA rules, translation memory, budget and events.
+func addFallbacks(service *translator.TranslationService, opts translateOptions, memory *translator.TranslationMemory) {
+	last := service
+	for _, fallback := range opts.Fallbacks {
+		provider := configuredProvider(fallback.Provider, opts)
+		next := translator.NewTranslationService(provider, opts.Concurrency, service.Timeout)
+		next.Name = fallback.Provider
+		next.Retry = fallback.Retry
+		next.Limiter = translator.NewRateLimiter(fallback.RateLimit)
+		next.BatchLimits = fallback.Batch
+		next.Validator = service.Validator
+		next.Memory = memory.Scope(fallback.Provider, provider)
+		next.FallbackOn = service.FallbackOn
+		next.Budget = service.Budget
+		next.Events = service.Events
+		last.Fallback = next
+		last = next
+	}
+}
+
+// printProviderSummary counts the successful translations per provider.
+func printProviderSummary(responses []model.TranslationResponse) {
+	counts := map[string]int{}
+	for _, resp := range responses {
+		if resp.Error == nil && resp.Provider != "" {
+			counts[resp.Provider]++
+		}
+	}
+	if len(counts) == 0 {
+		return
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s %d", name, counts[name])
+	}
+	fmt.Printf("Translations by provider: %s\n", strings.Join(parts, ", "))
+}
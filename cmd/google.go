@@ -1,11 +1,9 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"time"
 
-	tm "github.com/fdddf/xcstrings-translator/internal/model"
 	"github.com/fdddf/xcstrings-translator/internal/translator"
 
 	"github.com/spf13/cobra"
@@ -37,35 +35,10 @@ func init() {
 }
 
 func runGoogleTranslate(cmd *cobra.Command, args []string) {
-	// Get configuration values with fallbacks
-	inputFile := viper.GetString("global.input_file")
-	if cmd.Flags().Changed("input") {
-		inputFile, _ = cmd.Flags().GetString("input")
-	}
-
-	outputFile := viper.GetString("global.output_file")
-	if cmd.Flags().Changed("output") {
-		outputFile, _ = cmd.Flags().GetString("output")
-	}
-
-	sourceLang := viper.GetString("global.source_language")
-	if cmd.Flags().Changed("source-language") {
-		sourceLang, _ = cmd.Flags().GetString("source-language")
-	}
-
-	targetLangs := viper.GetStringSlice("global.target_languages")
-	if cmd.Flags().Changed("target-languages") {
-		targetLangs, _ = cmd.Flags().GetStringSlice("target-languages")
-	}
-
-	concurrency := viper.GetInt("global.concurrency")
-	if cmd.Flags().Changed("concurrency") {
-		concurrency, _ = cmd.Flags().GetInt("concurrency")
-	}
-
-	verbose := viper.GetBool("global.verbose")
-	if cmd.Flags().Changed("verbose") {
-		verbose, _ = cmd.Flags().GetBool("verbose")
+	opts, err := loadTranslateOptions(cmd)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
 	}
 
 	// Get Google specific config
@@ -83,112 +56,20 @@ func runGoogleTranslate(cmd *cobra.Command, args []string) {
 	if cmd.Flags().Changed("glossary") {
 		glossary, _ = cmd.Flags().GetString("glossary")
 	}
+	if glossary == "" && opts.Glossary != nil {
+		glossary = opts.Glossary.Google
+	}
 
-	if verbose {
+	if opts.Verbose {
 		fmt.Printf("Starting Google Translate with:\n")
-		fmt.Printf("  Input file: %s\n", inputFile)
-		fmt.Printf("  Output file: %s\n", outputFile)
-		fmt.Printf("  Source language: %s\n", sourceLang)
-		fmt.Printf("  Target languages: %v\n", targetLangs)
-		fmt.Printf("  Concurrency: %d\n", concurrency)
+		opts.printOptions()
 		fmt.Printf("  Model: %s\n", model)
 		fmt.Printf("  Glossary: %s\n", glossary)
 	}
 
-	// Load xcstrings file
-	if verbose {
-		fmt.Println("Loading xcstrings file...")
-	}
-	xcstrings, err := tm.LoadXCStrings(inputFile)
-	if err != nil {
-		fmt.Printf("Error loading xcstrings file: %v\n", err)
-		return
-	}
-
-	// Override source language if specified
-	if sourceLang != "" {
-		xcstrings.SourceLanguage = sourceLang
-	}
-
 	// Create translator
 	provider := translator.NewGoogleTranslator(apiKey)
+	provider.Glossary = glossary
 
-	// Create translation service
-	service := translator.NewTranslationService(
-		provider,
-		concurrency,
-		300*time.Second, // 5 minute timeout
-	)
-
-	// Run translation
-	if verbose {
-		fmt.Println("Starting translation...")
-	}
-	ctx := context.Background()
-	var responses []tm.TranslationResponse
-	for _, target := range targetLangs {
-		reqs := translator.CreateTranslationRequestsForLanguage(xcstrings, target)
-		if len(reqs) == 0 {
-			continue
-		}
-
-		if verbose {
-			fmt.Printf("Translating to %s (%d strings)...\n", target, len(reqs))
-		}
-
-		progress := translator.NewVerboseProgressReporter(target, len(reqs), verbose)
-		batchResponses, err := service.TranslateBatch(ctx, reqs, progress)
-		responses = append(responses, batchResponses...)
-		if err != nil {
-			fmt.Printf("Translation failed for %s: %v\n", target, err)
-			return
-		}
-	}
-
-	if len(responses) == 0 {
-		fmt.Println("No strings to translate. Exiting.")
-		return
-	}
-
-	// Process results
-	successCount := 0
-	errorCount := 0
-	for _, resp := range responses {
-		if resp.Error != nil {
-			if verbose {
-				fmt.Printf("Error translating %s to %s: %v\n", resp.Key, resp.TargetLanguage, resp.Error)
-			}
-			errorCount++
-		} else {
-			successCount++
-		}
-	}
-
-	if verbose {
-		fmt.Printf("Translation completed: %d successful, %d failed\n", successCount, errorCount)
-	}
-
-	if errorCount > 0 {
-		fmt.Println("Errors detected during translation. Stopping without applying translations.")
-		return
-	}
-
-	// Apply translations
-	if verbose {
-		fmt.Println("Applying translations...")
-	}
-	translator.ApplyTranslations(xcstrings, responses)
-
-	// Save output
-	if verbose {
-		fmt.Printf("Saving output to %s...\n", outputFile)
-	}
-	err = tm.SaveXCStrings(outputFile, xcstrings)
-	if err != nil {
-		fmt.Printf("Error saving output file: %v\n", err)
-		return
-	}
-
-	fmt.Printf("Translation completed successfully!\n")
-	fmt.Printf("Results saved to: %s\n", outputFile)
+	runTranslation(opts, provider, 300*time.Second)
 }
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fdddf/xcstrings-translator/internal/translator"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// memoryCmd groups the translation memory maintenance commands.
+var memoryCmd = &cobra.Command{
+	Use:   "memory",
+	Short: "Inspect, prune and export the translation memory",
+	Long: `Every successful provider translation is remembered in a local translation memory,
+keyed by source text, languages, provider, model and prompt fingerprint. Later runs reuse
+it instead of paying for the same text again.
+
+The memory is opt-in: set global.translation_memory or --translation-memory to the
+file it is kept in, typically one inside the project.`,
+}
+
+var memoryStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Summarise the translation memory per provider and language pair",
+	RunE:  runMemoryStats,
+}
+
+var memoryListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List remembered translations",
+	RunE:  runMemoryList,
+}
+
+var memoryPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Forget remembered translations",
+	Long: `Forget the translations matching every given filter, for example those unused for 90 days:
+
+  xcstrings-translator memory prune --unused-for 90d
+
+Use --all to empty the memory.`,
+	RunE: runMemoryPrune,
+}
+
+var memoryExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export remembered translations as JSON or CSV",
+	RunE:  runMemoryExport,
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{memoryListCmd, memoryPruneCmd, memoryExportCmd} {
+		cmd.Flags().String("provider", "", "Only entries from this provider")
+		cmd.Flags().String("model", "", "Only entries from this model")
+		cmd.Flags().String("language", "", "Only entries with this source or target language")
+		cmd.Flags().String("unused-for", "", "Only entries not used for this long, e.g. 720h or 30d")
+	}
+	memoryPruneCmd.Flags().Bool("all", false, "Forget every entry")
+	memoryExportCmd.Flags().String("format", "json", "Export format (json, csv)")
+	memoryExportCmd.Flags().String("file", "", "Write the export to this file instead of standard output")
+
+	memoryCmd.AddCommand(memoryStatsCmd, memoryListCmd, memoryPruneCmd, memoryExportCmd)
+	rootCmd.AddCommand(memoryCmd)
+}
+
+// loadMemoryPath returns the configured translation memory setting; see
+// translateOptions.memoryPath for how it is interpreted.
+func loadMemoryPath(cmd *cobra.Command) string {
+	path := viper.GetString("global.translation_memory")
+	if cmd.Flags().Changed("translation-memory") {
+		path, _ = cmd.Flags().GetString("translation-memory")
+	}
+	return path
+}
+
+// openMemory opens the configured translation memory for maintenance.
+func openMemory(cmd *cobra.Command) (*translator.TranslationMemory, error) {
+	path := translateOptions{Memory: loadMemoryPath(cmd)}.memoryPath()
+	if path == "" {
+		return nil, errors.New("no translation memory configured; set global.translation_memory or --translation-memory")
+	}
+	return translator.OpenTranslationMemory(path)
+}
+
+// loadMemoryFilter reads the shared filter flags.
+func loadMemoryFilter(cmd *cobra.Command) (translator.MemoryFilter, error) {
+	var filter translator.MemoryFilter
+	filter.Provider, _ = cmd.Flags().GetString("provider")
+	filter.Model, _ = cmd.Flags().GetString("model")
+	filter.Language, _ = cmd.Flags().GetString("language")
+
+	unusedFor, _ := cmd.Flags().GetString("unused-for")
+	if unusedFor != "" {
+		age, err := parseAge(unusedFor)
+		if err != nil {
+			return filter, err
+		}
+		filter.UnusedSince = time.Now().Add(-age)
+	}
+	return filter, nil
+}
+
+// parseAge parses a duration, also accepting whole days such as "30d".
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	age, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q: %v", value, err)
+	}
+	return age, nil
+}
+
+// filteredEntries returns the memory entries matching the command's filter flags.
+func filteredEntries(cmd *cobra.Command, memory *translator.TranslationMemory) ([]translator.MemoryEntry, error) {
+	filter, err := loadMemoryFilter(cmd)
+	if err != nil {
+		return nil, err
+	}
+	var entries []translator.MemoryEntry
+	for _, entry := range memory.Entries() {
+		if filter.Match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func runMemoryStats(cmd *cobra.Command, args []string) error {
+	memory, err := openMemory(cmd)
+	if err != nil {
+		return err
+	}
+
+	type group struct {
+		entries, hits int
+	}
+	groups := map[string]*group{}
+	for _, entry := range memory.Entries() {
+		name := entry.Provider
+		if entry.Model != "" {
+			name += " (" + entry.Model + ")"
+		}
+		name += fmt.Sprintf("  %s -> %s", entry.SourceLanguage, entry.TargetLanguage)
+		if groups[name] == nil {
+			groups[name] = &group{}
+		}
+		groups[name].entries++
+		groups[name].hits += entry.Hits
+	}
+
+	fmt.Printf("Translation memory: %s\n", memory.Path())
+	fmt.Printf("Entries: %d\n", memory.Len())
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("  %s: %d entries, %d reuses\n", name, groups[name].entries, groups[name].hits)
+	}
+	return nil
+}
+
+func runMemoryList(cmd *cobra.Command, args []string) error {
+	memory, err := openMemory(cmd)
+	if err != nil {
+		return err
+	}
+	entries, err := filteredEntries(cmd, memory)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		fmt.Printf("[%s %s -> %s] %q => %q (%d reuses, last used %s)\n",
+			entry.Provider, entry.SourceLanguage, entry.TargetLanguage, entry.Source, entry.Translation,
+			entry.Hits, entry.UsedAt.Local().Format("2006-01-02"))
+	}
+	fmt.Printf("%d entries.\n", len(entries))
+	return nil
+}
+
+func runMemoryPrune(cmd *cobra.Command, args []string) error {
+	filter, err := loadMemoryFilter(cmd)
+	if err != nil {
+		return err
+	}
+	all, _ := cmd.Flags().GetBool("all")
+	if filter == (translator.MemoryFilter{}) && !all {
+		return errors.New("give a filter such as --unused-for 90d, or --all to forget every entry")
+	}
+
+	memory, err := openMemory(cmd)
+	if err != nil {
+		return err
+	}
+	pruned := memory.Prune(filter)
+	if err := memory.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Pruned %d entries; %d left in %s.\n", pruned, memory.Len(), memory.Path())
+	return nil
+}
+
+func runMemoryExport(cmd *cobra.Command, args []string) error {
+	memory, err := openMemory(cmd)
+	if err != nil {
+		return err
+	}
+	entries, err := filteredEntries(cmd, memory)
+	if err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("format")
+	file, _ := cmd.Flags().GetString("file")
+	out := os.Stdout
+	if file != "" {
+		f, err := os.Create(file)
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := translator.WriteMemoryEntries(out, entries, format); err != nil {
+		return err
+	}
+	if file != "" {
+		fmt.Printf("Exported %d entries to %s\n", len(entries), file)
+	}
+	return nil
+}
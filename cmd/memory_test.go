@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fdddf/xcstrings-translator/internal/translator"
+)
+
+// writeMemory saves a memory with a few entries and returns its path.
+func writeMemory(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "memory.json")
+	memory, err := translator.OpenTranslationMemory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	memory.Store(translator.MemoryKey{Source: "Save", SourceLanguage: "en", TargetLanguage: "de", Provider: "deepl"}, "Speichern")
+	memory.Store(translator.MemoryKey{Source: "Open", SourceLanguage: "en", TargetLanguage: "de", Provider: "deepl"}, "Öffnen")
+	memory.Store(translator.MemoryKey{Source: "Save", SourceLanguage: "en", TargetLanguage: "ja", Provider: "openai", Model: "gpt-4o"}, "保存")
+	memory.Lookup(translator.MemoryKey{Source: "Save", SourceLanguage: "en", TargetLanguage: "de", Provider: "deepl"})
+	if err := memory.Save(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMemoryCommands(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+		// left is the number of entries in the memory afterwards.
+		left int
+	}{
+		{"stats", []string{"memory", "stats"}, []string{
+			"Entries: 3",
+			"deepl  en -> de: 2 entries, 1 reuses",
+			"openai (gpt-4o)  en -> ja: 1 entries, 0 reuses",
+		}, 3},
+		{"list", []string{"memory", "list", "--provider", "deepl"}, []string{
+			`[deepl en -> de] "Open" => "Öffnen" (0 reuses`,
+			`[deepl en -> de] "Save" => "Speichern" (1 reuses`,
+			"2 entries.",
+		}, 3},
+		{"list by language", []string{"memory", "list", "--language", "ja"}, []string{`"Save" => "保存"`, "1 entries."}, 3},
+		{"prune by language", []string{"memory", "prune", "--language", "de"}, []string{"Pruned 2 entries; 1 left"}, 1},
+		{"prune nothing unused", []string{"memory", "prune", "--unused-for", "30d"}, []string{"Pruned 0 entries; 3 left"}, 3},
+		{"prune all", []string{"memory", "prune", "--all"}, []string{"Pruned 3 entries; 0 left"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeMemory(t)
+			out, err := execute(t, append(tt.args, "--translation-memory", path)...)
+			if err != nil {
+				t.Fatalf("%s: %v", strings.Join(tt.args, " "), err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("output does not contain %q:\n%s", want, out)
+				}
+			}
+			memory, err := translator.OpenTranslationMemory(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if memory.Len() != tt.left {
+				t.Errorf("%d entries left, want %d", memory.Len(), tt.left)
+			}
+		})
+	}
+}
+
+func TestMemoryExportCommand(t *testing.T) {
+	path := writeMemory(t)
+	file := filepath.Join(t.TempDir(), "export.csv")
+	out, err := execute(t, "memory", "export", "--translation-memory", path, "--provider", "openai", "--format", "csv", "--file", file)
+	if err != nil {
+		t.Fatalf("memory export: %v", err)
+	}
+	if !strings.Contains(out, "Exported 1 entries") {
+		t.Errorf("output = %q", out)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "openai,gpt-4o,en,ja,Save,保存,") {
+		t.Errorf("export =\n%s", data)
+	}
+}
+
+func TestMemoryCommandErrors(t *testing.T) {
+	path := writeMemory(t)
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"prune without a filter", []string{"memory", "prune", "--translation-memory", path}},
+		{"invalid age", []string{"memory", "prune", "--unused-for", "soon", "--translation-memory", path}},
+		{"unknown export format", []string{"memory", "export", "--format", "xml", "--translation-memory", path}},
+		{"memory turned off", []string{"memory", "stats", "--translation-memory", "off"}},
+		{"no memory configured", []string{"memory", "stats"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := execute(t, tt.args...); err == nil {
+				t.Errorf("%s succeeded", strings.Join(tt.args, " "))
+			}
+		})
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"90d", 90 * 24 * time.Hour, false},
+		{"0d", 0, false},
+		{"720h", 720 * time.Hour, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"-1d", 0, true},
+		{"xd", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseAge(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseAge(%q) = %s, %v", tt.value, got, err)
+		}
+	}
+}
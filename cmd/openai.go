@@ -1,11 +1,9 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"time"
 
-	tm "github.com/fdddf/xcstrings-translator/internal/model"
 	"github.com/fdddf/xcstrings-translator/internal/translator"
 
 	"github.com/spf13/cobra"
@@ -41,35 +39,10 @@ func init() {
 }
 
 func runOpenAITranslate(cmd *cobra.Command, args []string) {
-	// Get configuration values with fallbacks
-	inputFile := viper.GetString("global.input_file")
-	if cmd.Flags().Changed("input") {
-		inputFile, _ = cmd.Flags().GetString("input")
-	}
-
-	outputFile := viper.GetString("global.output_file")
-	if cmd.Flags().Changed("output") {
-		outputFile, _ = cmd.Flags().GetString("output")
-	}
-
-	sourceLang := viper.GetString("global.source_language")
-	if cmd.Flags().Changed("source-language") {
-		sourceLang, _ = cmd.Flags().GetString("source-language")
-	}
-
-	targetLangs := viper.GetStringSlice("global.target_languages")
-	if cmd.Flags().Changed("target-languages") {
-		targetLangs, _ = cmd.Flags().GetStringSlice("target-languages")
-	}
-
-	concurrency := viper.GetInt("global.concurrency")
-	if cmd.Flags().Changed("concurrency") {
-		concurrency, _ = cmd.Flags().GetInt("concurrency")
-	}
-
-	verbose := viper.GetBool("global.verbose")
-	if cmd.Flags().Changed("verbose") {
-		verbose, _ = cmd.Flags().GetBool("verbose")
+	opts, err := loadTranslateOptions(cmd)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
 	}
 
 	// Get OpenAI specific config
@@ -98,113 +71,17 @@ func runOpenAITranslate(cmd *cobra.Command, args []string) {
 		maxTokens, _ = cmd.Flags().GetInt("max-tokens")
 	}
 
-	if verbose {
+	if opts.Verbose {
 		fmt.Printf("Starting OpenAI Translate with:\n")
-		fmt.Printf("  Input file: %s\n", inputFile)
-		fmt.Printf("  Output file: %s\n", outputFile)
-		fmt.Printf("  Source language: %s\n", sourceLang)
-		fmt.Printf("  Target languages: %v\n", targetLangs)
-		fmt.Printf("  Concurrency: %d\n", concurrency)
+		opts.printOptions()
 		fmt.Printf("  API base URL: %s\n", apiBaseURL)
 		fmt.Printf("  Model: %s\n", model)
 		fmt.Printf("  Temperature: %.2f\n", temperature)
 		fmt.Printf("  Max tokens: %d\n", maxTokens)
 	}
 
-	// Load xcstrings file
-	if verbose {
-		fmt.Println("Loading xcstrings file...")
-	}
-	xcstrings, err := tm.LoadXCStrings(inputFile)
-	if err != nil {
-		fmt.Printf("Error loading xcstrings file: %v\n", err)
-		return
-	}
-
-	// Override source language if specified
-	if sourceLang != "" {
-		xcstrings.SourceLanguage = sourceLang
-	}
-
 	// Create translator
 	provider := translator.NewOpenAITranslator(apiKey, apiBaseURL, model, temperature, maxTokens)
 
-	// Create translation service
-	service := translator.NewTranslationService(
-		provider,
-		concurrency,
-		600*time.Second, // 10 minute timeout (OpenAI can be slow)
-	)
-
-	// Run translation
-	if verbose {
-		fmt.Println("Starting translation...")
-	}
-	ctx := context.Background()
-	var responses []tm.TranslationResponse
-	for _, target := range targetLangs {
-		reqs := translator.CreateTranslationRequestsForLanguage(xcstrings, target)
-		if len(reqs) == 0 {
-			continue
-		}
-
-		if verbose {
-			fmt.Printf("Translating to %s (%d strings)...\n", target, len(reqs))
-		}
-
-		progress := translator.NewVerboseProgressReporter(target, len(reqs), verbose)
-		batchResponses, err := service.TranslateBatch(ctx, reqs, progress)
-		responses = append(responses, batchResponses...)
-		if err != nil {
-			fmt.Printf("Translation failed for %s: %v\n", target, err)
-			return
-		}
-	}
-
-	if len(responses) == 0 {
-		fmt.Println("No strings to translate. Exiting.")
-		return
-	}
-
-	// Process results
-	successCount := 0
-	errorCount := 0
-	for _, resp := range responses {
-		if resp.Error != nil {
-			if verbose {
-				fmt.Printf("Error translating %s to %s: %v\n", resp.Key, resp.TargetLanguage, resp.Error)
-			}
-			errorCount++
-		} else {
-			successCount++
-		}
-	}
-
-	if verbose {
-		fmt.Printf("Translation completed: %d successful, %d failed\n", successCount, errorCount)
-	}
-
-	if errorCount > 0 {
-		fmt.Println("Errors detected during translation. Stopping without applying translations.")
-		return
-	}
-
-	// Apply translations
-	if verbose {
-		fmt.Println("Applying translations...")
-	}
-	translator.ApplyTranslations(xcstrings, responses)
-
-	// Save output
-	if verbose {
-		fmt.Printf("Saving output to %s...\n", outputFile)
-	}
-	err = tm.SaveXCStrings(outputFile, xcstrings)
-	if err != nil {
-		fmt.Printf("Error saving output file: %v\n", err)
-		return
-	}
-
-	fmt.Printf("Translation completed successfully!\n")
-	fmt.Printf("Results saved to: %s\n", outputFile)
+	runTranslation(opts, provider, 600*time.Second) // 10 minute timeout (OpenAI can be slow)
 }
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+	"github.com/fdddf/xcstrings-translator/internal/translator"
+)
+
+// planTranslation prints what runTranslation would send to provider per
+// language, with its estimated cost, without contacting it. The translation
+// memory is read but not updated.
+func planTranslation(opts translateOptions, provider model.TranslationProvider) error {
+	xcstrings, err := model.LoadXCStrings(opts.InputFile)
+	if err != nil {
+		fmt.Printf("Error loading xcstrings file: %v\n", err)
+		return err
+	}
+	if opts.SourceLanguage != "" {
+		xcstrings.SourceLanguage = opts.SourceLanguage
+	}
+
+	policy := opts.Retranslate
+	targets := opts.TargetLanguages
+	var onlyFailed *translator.FailureReport
+	if opts.OnlyFailed != "" {
+		report, err := translator.LoadFailureReport(opts.OnlyFailed)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return err
+		}
+		onlyFailed = &report
+		policy = translator.RetranslatePolicy{All: true}
+		targets = report.Languages()
+	}
+
+	protected, err := opts.protectedTerms()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return err
+	}
+
+	var memory *translator.TranslationMemory
+	if path := opts.memoryPath(); path != "" {
+		memory, err = translator.OpenTranslationMemory(path)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return err
+		}
+	}
+
+	service := translator.NewTranslationService(provider, opts.Concurrency, 0)
+	service.Name = opts.Provider
+	service.BatchLimits = opts.Batch
+	service.Glossary = opts.Glossary
+	service.Protected = protected
+	service.Memory = memory.Scope(opts.Provider, provider)
+
+	prices := loadPriceTable()
+	var plans []translator.LanguagePlan
+	for _, target := range targets {
+		reqs := translator.CreateTranslationRequestsForLanguage(xcstrings, target, policy)
+		if onlyFailed != nil {
+			reqs = onlyFailed.Filter(reqs)
+		}
+		plans = append(plans, service.Plan(target, reqs, prices))
+	}
+
+	fmt.Printf("Dry run for %s on %s; nothing is sent.\n\n", opts.Provider, opts.InputFile)
+	if err := translator.WritePlan(os.Stdout, plans); err != nil {
+		return err
+	}
+	fmt.Println()
+	if _, ok := prices.Lookup(opts.Provider, translator.ModelName(provider)); !ok {
+		fmt.Printf("No price for %s in the pricing section, so the cost is not estimated.\n", opts.Provider)
+	}
+	if opts.Resume {
+		fmt.Println("Translations saved in the checkpoint are not subtracted.")
+	}
+	fmt.Println("Tokens and costs are estimates; language models bill the tokens they actually use.")
+	return nil
+}
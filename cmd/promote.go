@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+	"github.com/fdddf/xcstrings-translator/internal/translator"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// promoteCmd bulk-moves reviewed translations to a new state.
+var promoteCmd = &cobra.Command{
+	Use:   "promote",
+	Short: "Mark reviewed translations as translated",
+	Long: `Move string units from one state to another, by default from needs_review to translated.
+
+Use --languages and --keys to limit the promotion, for example after reviewing the
+Japanese settings screen:
+
+  xcstrings-translator promote -i Localizable.xcstrings --languages ja --keys "settings.*"
+
+The input file is updated in place unless --output is given.`,
+	RunE: runPromote,
+}
+
+func init() {
+	rootCmd.AddCommand(promoteCmd)
+
+	promoteCmd.Flags().StringSlice("languages", []string{}, "Languages to promote (default: all except the source language)")
+	promoteCmd.Flags().StringSlice("keys", []string{}, "Glob patterns for keys to promote, e.g. \"settings.*\" (default: all keys)")
+	promoteCmd.Flags().String("from", translator.StateNeedsReview, "State to promote from")
+	promoteCmd.Flags().String("to", translator.StateTranslated, "State to promote to")
+}
+
+func runPromote(cmd *cobra.Command, args []string) error {
+	inputFile := viper.GetString("global.input_file")
+	if cmd.Flags().Changed("input") {
+		inputFile, _ = cmd.Flags().GetString("input")
+	}
+
+	outputFile := inputFile
+	if cmd.Flags().Changed("output") {
+		outputFile, _ = cmd.Flags().GetString("output")
+	}
+
+	languages, _ := cmd.Flags().GetStringSlice("languages")
+	keys, _ := cmd.Flags().GetStringSlice("keys")
+	from, _ := cmd.Flags().GetString("from")
+	to, _ := cmd.Flags().GetString("to")
+
+	xcstrings, err := model.LoadXCStrings(inputFile)
+	if err != nil {
+		return fmt.Errorf("error loading xcstrings file: %w", err)
+	}
+
+	promoted, err := translator.PromoteStates(xcstrings, translator.PromoteOptions{
+		Languages:   languages,
+		KeyPatterns: keys,
+		From:        from,
+		To:          to,
+	})
+	if err != nil {
+		return err
+	}
+
+	if promoted == 0 {
+		fmt.Printf("No %s units matched. Nothing to do.\n", from)
+		return nil
+	}
+
+	if err := model.SaveXCStrings(outputFile, xcstrings); err != nil {
+		return fmt.Errorf("error saving output file: %w", err)
+	}
+
+	fmt.Printf("Promoted %d units from %s to %s.\n", promoted, from, to)
+	fmt.Printf("Results saved to: %s\n", outputFile)
+	return nil
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+const promoteCatalog = `{
+  "sourceLanguage" : "en",
+  "strings" : {
+    "Hello" : {
+      "localizations" : {
+        "de" : {
+          "stringUnit" : {
+            "state" : "needs_review",
+            "value" : "Hallo"
+          }
+        },
+        "ja" : {
+          "stringUnit" : {
+            "state" : "needs_review",
+            "value" : "こんにちは"
+          }
+        }
+      }
+    },
+    "settings.title" : {
+      "localizations" : {
+        "ja" : {
+          "stringUnit" : {
+            "state" : "needs_review",
+            "value" : "設定"
+          }
+        }
+      }
+    }
+  },
+  "version" : "1.0"
+}`
+
+// writeCatalog writes content to a catalog file in a temporary directory.
+func writeCatalog(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "Localizable.xcstrings")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// unitStates maps "key|language" to the state of each plain unit in path.
+func unitStates(t *testing.T, path string) map[string]string {
+	t.Helper()
+	xcstrings, err := model.LoadXCStrings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	states := map[string]string{}
+	for key, entry := range xcstrings.Strings {
+		for language, loc := range entry.Localizations {
+			states[key+"|"+language] = loc.State()
+		}
+	}
+	return states
+}
+
+func TestPromoteCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want map[string]string
+		out  string
+	}{
+		{"everything", nil, map[string]string{
+			"Hello|de": "translated", "Hello|ja": "translated", "settings.title|ja": "translated",
+		}, "Promoted 3 units from needs_review to translated."},
+		{"language and keys", []string{"--languages", "ja", "--keys", "settings.*"}, map[string]string{
+			"Hello|de": "needs_review", "Hello|ja": "needs_review", "settings.title|ja": "translated",
+		}, "Promoted 1 units"},
+		{"other states", []string{"--from", "translated", "--to", "new"}, map[string]string{
+			"Hello|de": "needs_review", "Hello|ja": "needs_review", "settings.title|ja": "needs_review",
+		}, "No translated units matched."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeCatalog(t, promoteCatalog)
+			out, err := execute(t, append([]string{"promote", "-i", path}, tt.args...)...)
+			if err != nil {
+				t.Fatalf("promote: %v", err)
+			}
+			if !strings.Contains(out, tt.out) {
+				t.Errorf("output %q does not contain %q", out, tt.out)
+			}
+			states := unitStates(t, path)
+			for unit, want := range tt.want {
+				if states[unit] != want {
+					t.Errorf("%s state = %q, want %q", unit, states[unit], want)
+				}
+			}
+		})
+	}
+}
+
+func TestPromoteCommandOutput(t *testing.T) {
+	input := writeCatalog(t, promoteCatalog)
+	output := filepath.Join(t.TempDir(), "Promoted.xcstrings")
+	if _, err := execute(t, "promote", "-i", input, "-o", output); err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	if data, _ := os.ReadFile(input); string(data) != promoteCatalog {
+		t.Error("promote with --output changed the input file")
+	}
+	if got := unitStates(t, output)["Hello|de"]; got != "translated" {
+		t.Errorf("output Hello|de state = %q, want translated", got)
+	}
+}
+
+func TestPromoteCommandInvalidState(t *testing.T) {
+	path := writeCatalog(t, promoteCatalog)
+	if _, err := execute(t, "promote", "-i", path, "--to", "stale"); err == nil {
+		t.Error("promote accepted --to stale")
+	}
+}
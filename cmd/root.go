@@ -60,6 +60,32 @@ func init() {
 	rootCmd.PersistentFlags().StringSliceP("target-languages", "t", []string{}, "Target language codes (e.g., zh-Hans, ja, ko)")
 	rootCmd.PersistentFlags().Int("concurrency", 0, "Number of concurrent translation requests")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Enable verbose output")
+	rootCmd.PersistentFlags().Int("max-attempts", 0, "Attempts per request before giving up on transient errors (default 3)")
+	rootCmd.PersistentFlags().Float64("requests-per-second", 0, "Maximum requests per second to the provider (0 = unlimited)")
+	rootCmd.PersistentFlags().Int("characters-per-minute", 0, "Maximum source characters per minute sent to the provider (0 = unlimited)")
+	rootCmd.PersistentFlags().Int("tokens-per-minute", 0, "Maximum estimated LLM tokens per minute (0 = unlimited)")
+	rootCmd.PersistentFlags().Int("batch-size", 0, "Maximum texts per call for providers that accept several (1 = one text per call)")
+	rootCmd.PersistentFlags().StringToString("qa", map[string]string{}, "QA rule severities, e.g. length=off,punctuation=error (rules: placeholders, whitespace, punctuation, length, untranslated, glossary, protected)")
+	rootCmd.PersistentFlags().StringSlice("do-not-translate", []string{}, "Terms kept verbatim, such as app and feature names (added to <input>.do-not-translate.txt)")
+	rootCmd.PersistentFlags().String("glossary-file", "", "Project glossary (YAML or JSON) of terms with mandated translations")
+	rootCmd.PersistentFlags().StringSlice("fallback", []string{}, "Providers to try in order when the main one fails, e.g. google,openai")
+	rootCmd.PersistentFlags().StringSlice("fallback-on", []string{}, "Error classes that send a string to the next provider (default quota,unsupported)")
+	rootCmd.PersistentFlags().Bool("continue-on-error", false, "Keep translating after failures and save the successful translations")
+	rootCmd.PersistentFlags().Int("max-errors", 0, "With --continue-on-error, abort after this many failures (0 = unlimited)")
+	rootCmd.PersistentFlags().String("failure-report", "", "Write failed units to this JSON file (default <output>.failures.json with --continue-on-error)")
+	rootCmd.PersistentFlags().String("usage-report", "", "Write the characters, tokens, time and cost per language and provider to this JSON file")
+	rootCmd.PersistentFlags().String("event-log", "", "Write every request's queued, started, retrying, succeeded and failed events to this JSON lines file")
+	rootCmd.PersistentFlags().String("only-failed", "", "Only translate the units listed in a failure report from a previous run")
+	rootCmd.PersistentFlags().String("checkpoint", "", "Journal completed translations to this file (default <output>.checkpoint.jsonl, \"off\" to disable)")
+	rootCmd.PersistentFlags().String("translation-memory", "", "Translation memory file reused across runs (off unless set)")
+	rootCmd.PersistentFlags().Bool("resume", false, "Resume an interrupted run from its checkpoint instead of starting over")
+	rootCmd.PersistentFlags().Bool("fresh", false, "Discard the checkpoint of an earlier run and start over")
+	rootCmd.PersistentFlags().Float64("max-cost", 0, "Stop sending once the estimated cost would exceed this, priced from the pricing section (0 = unlimited)")
+	rootCmd.PersistentFlags().Int("max-characters", 0, "Stop sending once this many source characters would be exceeded (0 = unlimited)")
+	rootCmd.PersistentFlags().Int("max-total-tokens", 0, "Stop sending once this many LLM tokens would be exceeded (0 = unlimited; not --max-tokens, the OpenAI completion limit)")
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Show what would be translated and its estimated cost without contacting the provider")
+	rootCmd.PersistentFlags().String("output-state", "", "State written on machine translations (needs_review, translated, new; default needs_review)")
+	rootCmd.PersistentFlags().StringSlice("retranslate", []string{}, "Also retranslate units in these states (needs_review, stale, translated) or \"all\"")
 
 	// Bind flags to Viper
 	viper.BindPFlag("global.input_file", rootCmd.PersistentFlags().Lookup("input"))
@@ -68,6 +94,22 @@ func init() {
 	viper.BindPFlag("global.target_languages", rootCmd.PersistentFlags().Lookup("target-languages"))
 	viper.BindPFlag("global.concurrency", rootCmd.PersistentFlags().Lookup("concurrency"))
 	viper.BindPFlag("global.verbose", rootCmd.PersistentFlags().Lookup("verbose"))
+	viper.BindPFlag("global.output_state", rootCmd.PersistentFlags().Lookup("output-state"))
+	viper.BindPFlag("global.retranslate", rootCmd.PersistentFlags().Lookup("retranslate"))
+	viper.BindPFlag("global.continue_on_error", rootCmd.PersistentFlags().Lookup("continue-on-error"))
+	viper.BindPFlag("global.max_errors", rootCmd.PersistentFlags().Lookup("max-errors"))
+	viper.BindPFlag("global.failure_report", rootCmd.PersistentFlags().Lookup("failure-report"))
+	viper.BindPFlag("global.usage_report", rootCmd.PersistentFlags().Lookup("usage-report"))
+	viper.BindPFlag("global.event_log", rootCmd.PersistentFlags().Lookup("event-log"))
+	viper.BindPFlag("global.checkpoint", rootCmd.PersistentFlags().Lookup("checkpoint"))
+	viper.BindPFlag("global.translation_memory", rootCmd.PersistentFlags().Lookup("translation-memory"))
+	viper.BindPFlag("global.glossary_file", rootCmd.PersistentFlags().Lookup("glossary-file"))
+	viper.BindPFlag("global.fallback", rootCmd.PersistentFlags().Lookup("fallback"))
+	viper.BindPFlag("global.fallback_on", rootCmd.PersistentFlags().Lookup("fallback-on"))
+	viper.BindPFlag("global.do_not_translate", rootCmd.PersistentFlags().Lookup("do-not-translate"))
+	viper.BindPFlag("global.budget.max_cost", rootCmd.PersistentFlags().Lookup("max-cost"))
+	viper.BindPFlag("global.budget.max_characters", rootCmd.PersistentFlags().Lookup("max-characters"))
+	viper.BindPFlag("global.budget.max_tokens", rootCmd.PersistentFlags().Lookup("max-total-tokens"))
 }
 
 // initConfig reads in config file and ENV variables if set
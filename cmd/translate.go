@@ -0,0 +1,767 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+	"github.com/fdddf/xcstrings-translator/internal/translator"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// translateOptions holds the global settings shared by every provider command.
+type translateOptions struct {
+	Provider        string
+	InputFile       string
+	OutputFile      string
+	SourceLanguage  string
+	TargetLanguages []string
+	Concurrency     int
+	Verbose         bool
+	Retranslate     translator.RetranslatePolicy
+	OutputState     string
+	Retry           translator.RetryPolicy
+	RateLimit       translator.RateLimit
+	Batch           model.BatchLimits
+	Validator       *translator.Validator
+	Glossary        *translator.Glossary
+	DoNotTranslate  []string
+	ContinueOnError bool
+	MaxErrors       int
+	FailureReport   string
+	UsageReport     string
+	EventLog        string
+	OnlyFailed      string
+	Checkpoint      string
+	Resume          bool
+	Fresh           bool
+	DryRun          bool
+	Memory          string
+	Fallbacks       []fallbackOptions
+	FallbackOn      []string
+	Budget          translator.BudgetLimit
+}
+
+// fallbackOptions are the settings of one provider in the fallback chain,
+// read from its config section only; provider flags apply to the command's
+// own provider.
+type fallbackOptions struct {
+	Provider  string
+	Retry     translator.RetryPolicy
+	RateLimit translator.RateLimit
+	Batch     model.BatchLimits
+}
+
+// loadTranslateOptions resolves the global settings with command-line flags
+// taking precedence over the config file and environment.
+func loadTranslateOptions(cmd *cobra.Command) (translateOptions, error) {
+	opts := translateOptions{Provider: cmd.Name()}
+
+	opts.InputFile = viper.GetString("global.input_file")
+	if cmd.Flags().Changed("input") {
+		opts.InputFile, _ = cmd.Flags().GetString("input")
+	}
+
+	opts.OutputFile = viper.GetString("global.output_file")
+	if cmd.Flags().Changed("output") {
+		opts.OutputFile, _ = cmd.Flags().GetString("output")
+	}
+
+	opts.SourceLanguage = viper.GetString("global.source_language")
+	if cmd.Flags().Changed("source-language") {
+		opts.SourceLanguage, _ = cmd.Flags().GetString("source-language")
+	}
+
+	opts.TargetLanguages = viper.GetStringSlice("global.target_languages")
+	if cmd.Flags().Changed("target-languages") {
+		opts.TargetLanguages, _ = cmd.Flags().GetStringSlice("target-languages")
+	}
+
+	opts.Concurrency = viper.GetInt("global.concurrency")
+	if cmd.Flags().Changed("concurrency") {
+		opts.Concurrency, _ = cmd.Flags().GetInt("concurrency")
+	}
+
+	opts.Verbose = viper.GetBool("global.verbose")
+	if cmd.Flags().Changed("verbose") {
+		opts.Verbose, _ = cmd.Flags().GetBool("verbose")
+	}
+
+	retranslate := viper.GetStringSlice("global.retranslate")
+	if cmd.Flags().Changed("retranslate") {
+		retranslate, _ = cmd.Flags().GetStringSlice("retranslate")
+	}
+	policy, err := translator.ParseRetranslatePolicy(retranslate)
+	if err != nil {
+		return opts, err
+	}
+	opts.Retranslate = policy
+
+	opts.OutputState = viper.GetString("global.output_state")
+	if cmd.Flags().Changed("output-state") {
+		opts.OutputState, _ = cmd.Flags().GetString("output-state")
+	}
+	if opts.OutputState == "" {
+		opts.OutputState = translator.DefaultOutputState
+	}
+	if err := translator.ValidateOutputState(opts.OutputState); err != nil {
+		return opts, err
+	}
+
+	opts.Retry = loadRetryPolicy(cmd, cmd.Name())
+	opts.RateLimit = loadRateLimit(cmd, cmd.Name())
+	opts.Batch = loadBatchLimits(cmd, cmd.Name())
+
+	opts.Validator, err = loadValidator(cmd)
+	if err != nil {
+		return opts, err
+	}
+
+	opts.DoNotTranslate = viper.GetStringSlice("global.do_not_translate")
+	if cmd.Flags().Changed("do-not-translate") {
+		opts.DoNotTranslate, _ = cmd.Flags().GetStringSlice("do-not-translate")
+	}
+
+	glossaryFile := viper.GetString("global.glossary_file")
+	if cmd.Flags().Changed("glossary-file") {
+		glossaryFile, _ = cmd.Flags().GetString("glossary-file")
+	}
+	if glossaryFile != "" {
+		opts.Glossary, err = translator.LoadGlossary(glossaryFile)
+		if err != nil {
+			return opts, err
+		}
+	}
+
+	opts.ContinueOnError = viper.GetBool("global.continue_on_error")
+	if cmd.Flags().Changed("continue-on-error") {
+		opts.ContinueOnError, _ = cmd.Flags().GetBool("continue-on-error")
+	}
+
+	opts.MaxErrors = viper.GetInt("global.max_errors")
+	if cmd.Flags().Changed("max-errors") {
+		opts.MaxErrors, _ = cmd.Flags().GetInt("max-errors")
+	}
+
+	opts.FailureReport = viper.GetString("global.failure_report")
+	if cmd.Flags().Changed("failure-report") {
+		opts.FailureReport, _ = cmd.Flags().GetString("failure-report")
+	}
+
+	opts.UsageReport = viper.GetString("global.usage_report")
+	if cmd.Flags().Changed("usage-report") {
+		opts.UsageReport, _ = cmd.Flags().GetString("usage-report")
+	}
+
+	opts.EventLog = viper.GetString("global.event_log")
+	if cmd.Flags().Changed("event-log") {
+		opts.EventLog, _ = cmd.Flags().GetString("event-log")
+	}
+
+	opts.OnlyFailed, _ = cmd.Flags().GetString("only-failed")
+
+	opts.Checkpoint = viper.GetString("global.checkpoint")
+	if cmd.Flags().Changed("checkpoint") {
+		opts.Checkpoint, _ = cmd.Flags().GetString("checkpoint")
+	}
+	opts.Fallbacks, opts.FallbackOn, err = loadFallbacks(cmd, opts.Provider)
+	if err != nil {
+		return opts, err
+	}
+
+	opts.Budget = loadBudget(cmd)
+	opts.Resume, _ = cmd.Flags().GetBool("resume")
+	opts.Fresh, _ = cmd.Flags().GetBool("fresh")
+	opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+	opts.Memory = loadMemoryPath(cmd)
+	if opts.Resume && opts.checkpointPath() == "" {
+		return opts, errors.New("--resume needs a checkpoint; remove --checkpoint off")
+	}
+	if opts.Resume && opts.Fresh {
+		return opts, errors.New("--resume and --fresh cannot be used together")
+	}
+
+	return opts, nil
+}
+
+// loadBudget reads global.budget; --max-cost, --max-characters and
+// --max-total-tokens override it.
+func loadBudget(cmd *cobra.Command) translator.BudgetLimit {
+	limit := translator.BudgetLimit{
+		MaxCost:       viper.GetFloat64("global.budget.max_cost"),
+		MaxCharacters: viper.GetInt("global.budget.max_characters"),
+		MaxTokens:     viper.GetInt("global.budget.max_tokens"),
+	}
+	if cmd.Flags().Changed("max-cost") {
+		limit.MaxCost, _ = cmd.Flags().GetFloat64("max-cost")
+	}
+	if cmd.Flags().Changed("max-characters") {
+		limit.MaxCharacters, _ = cmd.Flags().GetInt("max-characters")
+	}
+	if cmd.Flags().Changed("max-total-tokens") {
+		limit.MaxTokens, _ = cmd.Flags().GetInt("max-total-tokens")
+	}
+	return limit
+}
+
+// loadRetryPolicy merges the global retry settings with the provider's own
+// retry section; --max-attempts overrides both.
+func loadRetryPolicy(cmd *cobra.Command, provider string) translator.RetryPolicy {
+	policy := translator.DefaultRetryPolicy()
+	for _, section := range []string{"global.retry", provider + ".retry"} {
+		if viper.IsSet(section + ".max_attempts") {
+			policy.MaxAttempts = viper.GetInt(section + ".max_attempts")
+		}
+		if viper.IsSet(section + ".initial_backoff") {
+			policy.InitialBackoff = viper.GetDuration(section + ".initial_backoff")
+		}
+		if viper.IsSet(section + ".max_backoff") {
+			policy.MaxBackoff = viper.GetDuration(section + ".max_backoff")
+		}
+		if viper.IsSet(section + ".jitter") {
+			policy.Jitter = viper.GetFloat64(section + ".jitter")
+		}
+	}
+	if cmd.Flags().Changed("max-attempts") {
+		policy.MaxAttempts, _ = cmd.Flags().GetInt("max-attempts")
+	}
+	return policy
+}
+
+// loadRateLimit reads the provider's rate_limit section; the rate flags
+// override it.
+func loadRateLimit(cmd *cobra.Command, provider string) translator.RateLimit {
+	limit := configRateLimit(provider)
+	if cmd.Flags().Changed("requests-per-second") {
+		limit.RequestsPerSecond, _ = cmd.Flags().GetFloat64("requests-per-second")
+	}
+	if cmd.Flags().Changed("characters-per-minute") {
+		limit.CharactersPerMinute, _ = cmd.Flags().GetInt("characters-per-minute")
+	}
+	if cmd.Flags().Changed("tokens-per-minute") {
+		limit.TokensPerMinute, _ = cmd.Flags().GetInt("tokens-per-minute")
+	}
+	return limit
+}
+
+// configRateLimit reads the provider's rate_limit section.
+func configRateLimit(provider string) translator.RateLimit {
+	return translator.RateLimit{
+		RequestsPerSecond:   viper.GetFloat64(provider + ".rate_limit.requests_per_second"),
+		CharactersPerMinute: viper.GetInt(provider + ".rate_limit.characters_per_minute"),
+		TokensPerMinute:     viper.GetInt(provider + ".rate_limit.tokens_per_minute"),
+	}
+}
+
+// loadBatchLimits reads the provider's batch section; --batch-size overrides
+// its max_items.
+func loadBatchLimits(cmd *cobra.Command, provider string) model.BatchLimits {
+	limits := configBatchLimits(provider)
+	if cmd.Flags().Changed("batch-size") {
+		limits.MaxItems, _ = cmd.Flags().GetInt("batch-size")
+	}
+	return limits
+}
+
+// configBatchLimits reads the provider's batch section.
+func configBatchLimits(provider string) model.BatchLimits {
+	return model.BatchLimits{
+		MaxItems: viper.GetInt(provider + ".batch.max_items"),
+		MaxBytes: viper.GetInt(provider + ".batch.max_bytes"),
+	}
+}
+
+// loadValidator reads the qa section; --qa overrides single rules.
+func loadValidator(cmd *cobra.Command) (*translator.Validator, error) {
+	validator := translator.DefaultValidator()
+	for rule, severity := range viper.GetStringMapString("qa.rules") {
+		validator.Severities[rule] = severity
+	}
+	if cmd.Flags().Changed("qa") {
+		rules, _ := cmd.Flags().GetStringToString("qa")
+		for rule, severity := range rules {
+			validator.Severities[rule] = severity
+		}
+	}
+	if err := translator.ValidateSeverities(validator.Severities); err != nil {
+		return nil, err
+	}
+	if viper.IsSet("qa.min_length_ratio") {
+		validator.MinLengthRatio = viper.GetFloat64("qa.min_length_ratio")
+	}
+	if viper.IsSet("qa.max_length_ratio") {
+		validator.MaxLengthRatio = viper.GetFloat64("qa.max_length_ratio")
+	}
+	if viper.IsSet("qa.min_length_source") {
+		validator.MinLengthSource = viper.GetInt("qa.min_length_source")
+	}
+	return validator, nil
+}
+
+// printOptions prints the shared settings in verbose mode.
+func (o translateOptions) printOptions() {
+	fmt.Printf("  Input file: %s\n", o.InputFile)
+	fmt.Printf("  Output file: %s\n", o.OutputFile)
+	fmt.Printf("  Source language: %s\n", o.SourceLanguage)
+	fmt.Printf("  Target languages: %v\n", o.TargetLanguages)
+	fmt.Printf("  Concurrency: %d\n", o.Concurrency)
+	fmt.Printf("  Retranslate: %s\n", o.Retranslate)
+	fmt.Printf("  Output state: %s\n", o.OutputState)
+	fmt.Printf("  Max attempts: %d (backoff %s..%s)\n", o.Retry.MaxAttempts, o.Retry.InitialBackoff, o.Retry.MaxBackoff)
+	if o.ContinueOnError {
+		fmt.Printf("  Continue on error: max %d errors (0 = unlimited)\n", o.MaxErrors)
+	}
+	if o.OnlyFailed != "" {
+		fmt.Printf("  Only failed units from: %s\n", o.OnlyFailed)
+	}
+	if path := o.memoryPath(); path != "" {
+		fmt.Printf("  Translation memory: %s\n", path)
+	}
+	if o.Glossary != nil {
+		fmt.Printf("  Glossary: %d terms\n", o.Glossary.Len())
+	}
+	if len(o.Fallbacks) > 0 {
+		chain := []string{o.Provider}
+		for _, fallback := range o.Fallbacks {
+			chain = append(chain, fallback.Provider)
+		}
+		fmt.Printf("  Fallback chain: %s (on %s)\n", strings.Join(chain, " -> "), strings.Join(o.FallbackOn, ", "))
+	}
+	if o.Budget.Enabled() {
+		fmt.Printf("  Budget: cost %g, %d characters, %d tokens (0 = unlimited)\n", o.Budget.MaxCost, o.Budget.MaxCharacters, o.Budget.MaxTokens)
+	}
+	if o.Batch.MaxItems > 0 || o.Batch.MaxBytes > 0 {
+		fmt.Printf("  Batch limits: %d texts, %d bytes (0 = provider default)\n", o.Batch.MaxItems, o.Batch.MaxBytes)
+	}
+	if o.RateLimit.Enabled() {
+		fmt.Printf("  Rate limit: %g req/s, %d chars/min, %d tokens/min (0 = unlimited)\n",
+			o.RateLimit.RequestsPerSecond, o.RateLimit.CharactersPerMinute, o.RateLimit.TokensPerMinute)
+	}
+}
+
+// failureReportPath returns where the failure report goes: the configured path,
+// or next to the output file when continuing on errors.
+func (o translateOptions) failureReportPath() string {
+	if o.FailureReport != "" || !o.ContinueOnError {
+		return o.FailureReport
+	}
+	return strings.TrimSuffix(o.OutputFile, ".xcstrings") + ".failures.json"
+}
+
+// checkpointPath returns the journal path: the configured path, next to the
+// output file by default, or "" when checkpoints are turned off.
+func (o translateOptions) checkpointPath() string {
+	switch o.Checkpoint {
+	case "off":
+		return ""
+	case "":
+		return strings.TrimSuffix(o.OutputFile, ".xcstrings") + ".checkpoint.jsonl"
+	}
+	return o.Checkpoint
+}
+
+// doNotTranslatePath returns the input catalog's own do-not-translate list.
+func (o translateOptions) doNotTranslatePath() string {
+	return strings.TrimSuffix(o.InputFile, ".xcstrings") + ".do-not-translate.txt"
+}
+
+// protectedTerms merges the configured terms with those listed for the
+// input catalog.
+func (o translateOptions) protectedTerms() (*translator.ProtectedTerms, error) {
+	catalogTerms, err := translator.LoadProtectedTerms(o.doNotTranslatePath())
+	if err != nil {
+		return nil, err
+	}
+	return translator.NewProtectedTerms(slices.Concat(o.DoNotTranslate, catalogTerms)), nil
+}
+
+// memoryPath returns the translation memory file, or "" when none is
+// configured. The memory is opt-in: translations are only shared between runs
+// that name the same file.
+func (o translateOptions) memoryPath() string {
+	if o.Memory == "off" {
+		return ""
+	}
+	return o.Memory
+}
+
+// openCheckpoint opens the run's journal for xcstrings, or returns nil when
+// checkpoints are off. An old journal holds paid-for translations, so it is
+// only discarded when --fresh asks for it.
+func openCheckpoint(opts translateOptions, xcstrings *model.XCStrings) (*translator.Checkpoint, []model.TranslationResponse, error) {
+	path := opts.checkpointPath()
+	if path == "" {
+		return nil, nil, nil
+	}
+	if !opts.Resume {
+		if info, err := os.Stat(path); err == nil && info.Size() > 0 {
+			if !opts.Fresh {
+				return nil, nil, fmt.Errorf("checkpoint %s from an earlier run exists; use --resume to continue it, --fresh to discard it or --checkpoint off", path)
+			}
+			fmt.Printf("Discarding checkpoint %s from an earlier run\n", path)
+		}
+	}
+	run := translator.CheckpointRun{InputFile: opts.InputFile, Provider: opts.Provider}
+	return translator.OpenCheckpoint(path, run, xcstrings, opts.Resume)
+}
+
+// qaSummaryLimit is how many QA findings are listed outside verbose mode.
+const qaSummaryLimit = 20
+
+// printQASummary lists the translations QA rejected and warned about.
+func printQASummary(responses []model.TranslationResponse, verbose bool) {
+	var rejected, warnings []string
+	for _, resp := range responses {
+		unit := resp.Key + " (" + resp.TargetLanguage + ")"
+		if resp.Path != "" {
+			unit = resp.Key + " [" + resp.Path + "] (" + resp.TargetLanguage + ")"
+		}
+		if translator.IsRejected(resp.Error) {
+			rejected = append(rejected, fmt.Sprintf("  %s: %v", unit, resp.Error))
+		}
+		for _, warning := range resp.Warnings {
+			warnings = append(warnings, fmt.Sprintf("  %s: %s", unit, warning))
+		}
+	}
+
+	for _, section := range []struct {
+		title string
+		lines []string
+	}{
+		{"QA rejected %d translations:", rejected},
+		{"QA raised %d warnings:", warnings},
+	} {
+		if len(section.lines) == 0 {
+			continue
+		}
+		fmt.Printf(section.title+"\n", len(section.lines))
+		for i, line := range section.lines {
+			if !verbose && i == qaSummaryLimit {
+				fmt.Printf("  ... %d more (use --verbose to list all)\n", len(section.lines)-i)
+				break
+			}
+			fmt.Println(line)
+		}
+	}
+}
+
+// runTranslation loads the input catalog, translates it with provider and saves
+// the result. Failed units are written to the failure report. Translations QA
+// rejected are left out and listed. Without ContinueOnError any other failure
+// leaves the output untouched; with it the successes
+// are saved and only exceeding MaxErrors is returned as an error, besides
+// loading and saving failures. Successes are journaled to the checkpoint until
+// the output is saved, so an interrupted run can continue with --resume. A dry
+// run only prints the plan.
+func runTranslation(opts translateOptions, provider model.TranslationProvider, timeout time.Duration) error {
+	if opts.DryRun {
+		return planTranslation(opts, provider)
+	}
+	verbose := opts.Verbose
+
+	// Load xcstrings file
+	if verbose {
+		fmt.Println("Loading xcstrings file...")
+	}
+	xcstrings, err := model.LoadXCStrings(opts.InputFile)
+	if err != nil {
+		fmt.Printf("Error loading xcstrings file: %v\n", err)
+		return err
+	}
+
+	// Override source language if specified
+	if opts.SourceLanguage != "" {
+		xcstrings.SourceLanguage = opts.SourceLanguage
+	}
+
+	// Restrict the run to the units a previous run reported as failed
+	policy := opts.Retranslate
+	targets := opts.TargetLanguages
+	var onlyFailed *translator.FailureReport
+	if opts.OnlyFailed != "" {
+		report, err := translator.LoadFailureReport(opts.OnlyFailed)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return err
+		}
+		onlyFailed = &report
+		// Failed units keep whatever state they had, so ignore the policy.
+		policy = translator.RetranslatePolicy{All: true}
+		targets = report.Languages()
+	}
+
+	// Replay the checkpoint of an interrupted run
+	checkpoint, resumed, err := openCheckpoint(opts, xcstrings)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return err
+	}
+	defer checkpoint.Close()
+	if stale := checkpoint.Stale(); stale > 0 {
+		fmt.Printf("Ignoring %d checkpoint translations whose source text has changed\n", stale)
+	}
+	if len(resumed) > 0 {
+		translator.ApplyTranslations(xcstrings, resumed, opts.OutputState)
+		fmt.Printf("Resumed %d translations from %s\n", len(resumed), checkpoint.Path())
+	}
+
+	// Protect the configured terms and those listed for this catalog
+	protected, err := opts.protectedTerms()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return err
+	}
+	if verbose && protected.Len() > 0 {
+		fmt.Printf("Protecting %d do-not-translate terms\n", protected.Len())
+	}
+
+	// Open the translation memory shared with earlier runs
+	var memory *translator.TranslationMemory
+	if path := opts.memoryPath(); path != "" {
+		memory, err = translator.OpenTranslationMemory(path)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return err
+		}
+		defer func() {
+			if err := memory.Save(); err != nil {
+				fmt.Printf("Error saving translation memory: %v\n", err)
+			}
+		}()
+	}
+
+	// Create translation service
+	prices := loadPriceTable()
+	service := translator.NewTranslationService(provider, opts.Concurrency, timeout)
+	service.Name = opts.Provider
+	service.Retry = opts.Retry
+	service.Limiter = translator.NewRateLimiter(opts.RateLimit)
+	service.BatchLimits = opts.Batch
+	service.Validator = opts.Validator
+	service.Glossary = opts.Glossary
+	service.Protected = protected
+	service.ContinueOnError = opts.ContinueOnError
+	service.MaxErrors = opts.MaxErrors
+	service.Checkpoint = checkpoint
+	service.Memory = memory.Scope(opts.Provider, provider)
+	service.FallbackOn = opts.FallbackOn
+	service.Budget = translator.NewBudget(opts.Budget, prices)
+	service.Events = translator.NewEventBus()
+	if verbose {
+		service.Events.Subscribe(translator.NewProgressPrinter(os.Stdout))
+	}
+	if opts.EventLog != "" {
+		eventLog, err := os.Create(opts.EventLog)
+		if err != nil {
+			err = fmt.Errorf("failed to create event log: %w", err)
+			fmt.Printf("Error: %v\n", err)
+			return err
+		}
+		defer eventLog.Close()
+		service.Events.Subscribe(translator.NewEventLog(eventLog))
+	}
+	addFallbacks(service, opts, memory)
+	if opts.Budget.MaxCost > 0 {
+		for next := service; next != nil; next = next.Fallback {
+			if _, ok := prices.Lookup(next.Name, translator.ModelName(next.Provider)); !ok {
+				err := fmt.Errorf("--max-cost needs a price for %s in the pricing section", next.Name)
+				fmt.Printf("Error: %v\n", err)
+				return err
+			}
+		}
+	}
+
+	// Run translation
+	if verbose {
+		fmt.Println("Starting translation...")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var responses []model.TranslationResponse
+	var batchErr error
+	planned := map[string]int{}
+	for _, target := range targets {
+		reqs := translator.CreateTranslationRequestsForLanguage(xcstrings, target, policy)
+		if onlyFailed != nil {
+			reqs = onlyFailed.Filter(reqs)
+		}
+		reqs = checkpoint.Pending(reqs)
+		if len(reqs) == 0 {
+			continue
+		}
+		planned[target] = len(reqs)
+		if batchErr != nil {
+			// Out of budget: only count what is left.
+			continue
+		}
+
+		if verbose {
+			fmt.Printf("Translating to %s (%d strings)...\n", target, len(reqs))
+		}
+
+		// Leave room for the time the rate limiter spreads the batch over.
+		service.Timeout = timeout + opts.RateLimit.MinDuration(len(reqs))
+
+		batchResponses, err := service.TranslateBatch(ctx, reqs)
+		responses = append(responses, batchResponses...)
+		if errors.Is(err, translator.ErrBudgetExceeded) {
+			fmt.Printf("Budget reached while translating to %s; nothing more is sent.\n", target)
+			batchErr = err
+			continue
+		}
+		if err != nil {
+			fmt.Printf("Translation failed for %s: %v\n", target, err)
+			batchErr = err
+			break
+		}
+	}
+
+	if len(responses) == 0 && batchErr == nil && len(resumed) == 0 {
+		checkpoint.Remove()
+		fmt.Println("No strings to translate. Exiting.")
+		return nil
+	}
+
+	// Units left over by the budget are not failures; report them at the end.
+	var remaining []string
+	if errors.Is(batchErr, translator.ErrBudgetExceeded) {
+		responses, remaining = budgetRemainder(responses, planned, targets)
+		batchErr = nil
+	}
+
+	// Process results
+	successCount := 0
+	errorCount := 0
+	rejectedCount := 0
+	cachedCount := 0
+	for _, resp := range responses {
+		if translator.IsRejected(resp.Error) {
+			// Listed by the QA summary; the rest of the run is still applied.
+			rejectedCount++
+		} else if resp.Error != nil {
+			if verbose {
+				fmt.Printf("Error translating %s to %s: %v\n", resp.Key, resp.TargetLanguage, resp.Error)
+			}
+			errorCount++
+		} else {
+			successCount++
+			if resp.Cached {
+				cachedCount++
+			}
+		}
+	}
+	printQASummary(responses, verbose)
+	if len(opts.Fallbacks) > 0 {
+		printProviderSummary(responses)
+	}
+	usage := translator.NewUsageReport(responses, prices)
+	usage.InputFile = opts.InputFile
+	if usage.Billed() {
+		fmt.Println("Usage:")
+		translator.WriteUsageSummary(os.Stdout, usage)
+	}
+	if opts.UsageReport != "" {
+		if err := translator.WriteUsageReport(opts.UsageReport, usage); err != nil {
+			fmt.Printf("Error writing usage report: %v\n", err)
+		} else {
+			fmt.Printf("Usage report written to %s\n", opts.UsageReport)
+		}
+	}
+	if duplicates := service.Duplicates(); duplicates > 0 {
+		fmt.Printf("%d duplicate strings reused a translation from the same run (%d provider calls saved).\n", duplicates, service.SavedCalls())
+	}
+	if cachedCount > 0 {
+		fmt.Printf("%d translations reused from the translation memory.\n", cachedCount)
+	}
+
+	if verbose {
+		fmt.Printf("Translation completed: %d successful, %d rejected, %d failed\n", successCount, rejectedCount, errorCount)
+	}
+
+	reportPath := opts.failureReportPath()
+	if errorCount+rejectedCount > 0 && reportPath != "" {
+		report := translator.NewFailureReport(responses)
+		report.InputFile = opts.InputFile
+		report.Provider = opts.Provider
+		if err := translator.WriteFailureReport(reportPath, report); err != nil {
+			fmt.Printf("Error writing failure report: %v\n", err)
+		} else {
+			fmt.Printf("%d failed units written to %s; retry them with --only-failed %s\n", len(report.Failures), reportPath, reportPath)
+		}
+	}
+
+	if !opts.ContinueOnError && (errorCount > 0 || batchErr != nil) {
+		fmt.Println("Errors detected during translation. Stopping without applying translations.")
+		if checkpoint != nil && successCount+len(resumed) > 0 {
+			fmt.Printf("%d translations kept in %s; rerun with --resume to continue.\n", successCount+len(resumed), checkpoint.Path())
+		}
+		return nil
+	}
+
+	// Apply translations
+	if verbose {
+		fmt.Println("Applying translations...")
+	}
+	translator.ApplyTranslations(xcstrings, responses, opts.OutputState)
+
+	// Save output
+	if verbose {
+		fmt.Printf("Saving output to %s...\n", opts.OutputFile)
+	}
+	err = model.SaveXCStrings(opts.OutputFile, xcstrings)
+	if err != nil {
+		fmt.Printf("Error saving output file: %v\n", err)
+		return err
+	}
+	checkpoint.Remove()
+
+	if errorCount > 0 || batchErr != nil {
+		fmt.Printf("Translation completed with %d failures.\n", errorCount)
+	} else if rejectedCount > 0 {
+		fmt.Printf("Translation completed; %d translations rejected by QA were left out.\n", rejectedCount)
+	} else {
+		fmt.Printf("Translation completed successfully!\n")
+	}
+	fmt.Printf("Results saved to: %s\n", opts.OutputFile)
+	if len(remaining) > 0 {
+		printBudgetSummary(service.Budget, remaining)
+	}
+	return batchErr
+}
+
+// budgetRemainder drops the responses the budget refused and lists, per
+// language, how many of the planned units were not translated.
+func budgetRemainder(responses []model.TranslationResponse, planned map[string]int, targets []string) ([]model.TranslationResponse, []string) {
+	done := map[string]int{}
+	var kept []model.TranslationResponse
+	for _, resp := range responses {
+		if errors.Is(resp.Error, translator.ErrBudgetExceeded) {
+			continue
+		}
+		kept = append(kept, resp)
+		done[resp.TargetLanguage]++
+	}
+	var remaining []string
+	for _, target := range targets {
+		if left := planned[target] - done[target]; left > 0 {
+			remaining = append(remaining, fmt.Sprintf("%s %d", target, left))
+		}
+	}
+	return kept, remaining
+}
+
+// printBudgetSummary reports what the run spent and the work it left.
+func printBudgetSummary(budget *translator.Budget, remaining []string) {
+	usage, cost := budget.Spent()
+	fmt.Printf("Budget reached after %d characters, %d tokens, cost %.4f.\n", usage.Characters, usage.PromptTokens+usage.CompletionTokens, cost)
+	fmt.Printf("Strings left untranslated: %s. Rerun with a larger budget to finish them.\n", strings.Join(remaining, ", "))
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+	"github.com/fdddf/xcstrings-translator/internal/translator"
+
+	"github.com/spf13/viper"
+)
+
+func TestProtectedTerms(t *testing.T) {
+	input := writeCatalog(t, promoteCatalog)
+	listPath := strings.TrimSuffix(input, ".xcstrings") + ".do-not-translate.txt"
+
+	tests := []struct {
+		name       string
+		configured []string
+		list       string
+		text       string
+		want       []string
+	}{
+		{"no terms", nil, "", "Upgrade to Pro", nil},
+		{"configured only", []string{"Pro"}, "", "Upgrade to Pro", []string{"Pro"}},
+		{"catalog list only", nil, "# Plans\nPro\n", "Upgrade to Pro", []string{"Pro"}},
+		{"both merged", []string{"Pro"}, "Live Activities\n", "Pro with Live Activities", []string{"Pro", "Live Activities"}},
+		{"listed twice", []string{"Pro"}, "Pro\n", "Upgrade to Pro", []string{"Pro"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(listPath)
+			if tt.list != "" {
+				if err := os.WriteFile(listPath, []byte(tt.list), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			opts := translateOptions{InputFile: input, DoNotTranslate: tt.configured}
+			protected, err := opts.protectedTerms()
+			if err != nil {
+				t.Fatalf("protectedTerms: %v", err)
+			}
+			if got := protected.Find(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("Find(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+			if len(opts.DoNotTranslate) != len(tt.configured) {
+				t.Errorf("configured terms changed to %q", opts.DoNotTranslate)
+			}
+		})
+	}
+}
+
+func TestOpenCheckpointExistingJournal(t *testing.T) {
+	input := writeCatalog(t, promoteCatalog)
+	xcstrings, err := model.LoadXCStrings(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal := strings.TrimSuffix(input, ".xcstrings") + ".checkpoint.jsonl"
+
+	tests := []struct {
+		name       string
+		opts       translateOptions
+		wantErr    bool
+		wantReplay int
+	}{
+		{"no flag", translateOptions{}, true, 0},
+		{"resume", translateOptions{Resume: true}, false, 1},
+		{"fresh", translateOptions{Fresh: true}, false, 0},
+		{"off", translateOptions{Checkpoint: "off"}, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := translator.CheckpointRun{InputFile: input, Provider: "google"}
+			earlier, _, err := translator.OpenCheckpoint(journal, run, xcstrings, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			earlier.Record(model.TranslationResponse{Key: "Hello", TargetLanguage: "fr", TranslatedText: "Bonjour"}, "Hello")
+			earlier.Close()
+
+			opts := tt.opts
+			opts.InputFile, opts.OutputFile, opts.Provider = input, input, "google"
+			checkpoint, replay, err := openCheckpoint(opts, xcstrings)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openCheckpoint error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(replay) != tt.wantReplay {
+				t.Errorf("replayed %d translations, want %d", len(replay), tt.wantReplay)
+			}
+			if checkpoint != nil {
+				checkpoint.Close()
+			}
+
+			// Only --fresh may throw the earlier translations away.
+			kept, replay, err := translator.OpenCheckpoint(journal, run, xcstrings, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			kept.Close()
+			want := 1
+			if tt.opts.Fresh {
+				want = 0
+			}
+			if len(replay) != want {
+				t.Errorf("journal holds %d translations, want %d", len(replay), want)
+			}
+		})
+	}
+}
+
+func TestLoadValidatorLengthSettings(t *testing.T) {
+	settings := map[string]any{"qa.min_length_ratio": 0.5, "qa.max_length_ratio": 2, "qa.min_length_source": 4}
+	for key, value := range settings {
+		viper.Set(key, value)
+	}
+	t.Cleanup(func() {
+		for key := range settings {
+			viper.Set(key, nil)
+		}
+	})
+
+	resetFlags(rootCmd)
+	validator, err := loadValidator(rootCmd)
+	if err != nil {
+		t.Fatalf("loadValidator: %v", err)
+	}
+	if validator.MinLengthRatio != 0.5 || validator.MaxLengthRatio != 2 || validator.MinLengthSource != 4 {
+		t.Errorf("length settings = %g, %g, %d; want 0.5, 2, 4", validator.MinLengthRatio, validator.MaxLengthRatio, validator.MinLengthSource)
+	}
+}
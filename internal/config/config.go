@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 // Config represents the application configuration
 type Config struct {
 	Global GlobalConfig `mapstructure:"global"`
@@ -7,6 +9,9 @@ type Config struct {
 	DeepL  DeepLConfig  `mapstructure:"deepl"`
 	Baidu  BaiduConfig  `mapstructure:"baidu"`
 	OpenAI OpenAIConfig `mapstructure:"openai"`
+	QA     QAConfig     `mapstructure:"qa"`
+	// Pricing maps provider names to what they charge, for cost estimates.
+	Pricing map[string]PriceConfig `mapstructure:"pricing"`
 }
 
 // GlobalConfig contains global configuration settings
@@ -17,35 +22,130 @@ type GlobalConfig struct {
 	TargetLanguages []string `mapstructure:"target_languages"`
 	Concurrency     int      `mapstructure:"concurrency"`
 	Verbose         bool     `mapstructure:"verbose"`
+	// Retranslate lists extra unit states to translate again (needs_review,
+	// stale, translated) or "all". Missing and "new" units are always translated.
+	Retranslate []string `mapstructure:"retranslate"`
+	// OutputState is the state written on machine translations; needs_review
+	// by default so a human signs off before they count as translated.
+	OutputState string `mapstructure:"output_state"`
+	// ContinueOnError saves successful translations even when some fail;
+	// MaxErrors (0 = unlimited) aborts the run after that many failures.
+	ContinueOnError bool `mapstructure:"continue_on_error"`
+	MaxErrors       int  `mapstructure:"max_errors"`
+	// FailureReport is the JSON file failed units are written to.
+	FailureReport string `mapstructure:"failure_report"`
+	// UsageReport is the JSON file the run's usage per language and
+	// provider is written to.
+	UsageReport string `mapstructure:"usage_report"`
+	// EventLog is the JSON lines file every request event is written to.
+	EventLog string `mapstructure:"event_log"`
+	// Checkpoint is the journal of completed translations used by --resume;
+	// empty means next to the output file, "off" disables it.
+	Checkpoint string `mapstructure:"checkpoint"`
+	// TranslationMemory is the local cache of earlier translations; empty
+	// means the user cache directory, "off" disables it.
+	TranslationMemory string `mapstructure:"translation_memory"`
+	// GlossaryFile is the project glossary of terms with mandated translations.
+	GlossaryFile string `mapstructure:"glossary_file"`
+	// DoNotTranslate lists terms kept verbatim in every catalog; a catalog's
+	// <name>.do-not-translate.txt adds its own.
+	DoNotTranslate []string `mapstructure:"do_not_translate"`
+	// Fallback lists providers tried in order when the main one fails with
+	// one of the FallbackOn error classes (default: quota, unsupported).
+	Fallback   []string `mapstructure:"fallback"`
+	FallbackOn []string `mapstructure:"fallback_on"`
+	// Budget caps what a run may spend; zero values are unlimited.
+	Budget BudgetConfig `mapstructure:"budget"`
+	// Retry is the default retry policy; each provider section may override it.
+	Retry RetryConfig `mapstructure:"retry"`
+}
+
+// RetryConfig controls retries of transient failures (429, 5xx, network errors)
+type RetryConfig struct {
+	MaxAttempts    int           `mapstructure:"max_attempts"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+	Jitter         float64       `mapstructure:"jitter"`
+}
+
+// BudgetConfig caps a run's spending: the cost priced from the pricing
+// section, source characters and language model tokens
+type BudgetConfig struct {
+	MaxCost       float64 `mapstructure:"max_cost"`
+	MaxCharacters int     `mapstructure:"max_characters"`
+	MaxTokens     int     `mapstructure:"max_tokens"`
+}
+
+// RateLimitConfig is a provider's throughput budget; zero values are unlimited
+type RateLimitConfig struct {
+	RequestsPerSecond   float64 `mapstructure:"requests_per_second"`
+	CharactersPerMinute int     `mapstructure:"characters_per_minute"`
+	TokensPerMinute     int     `mapstructure:"tokens_per_minute"`
+}
+
+// QAConfig sets the severity (error, warning, off) of each QA rule, the
+// length ratio the length rule accepts and the shortest source it checks
+type QAConfig struct {
+	Rules           map[string]string `mapstructure:"rules"`
+	MinLengthRatio  float64           `mapstructure:"min_length_ratio"`
+	MaxLengthRatio  float64           `mapstructure:"max_length_ratio"`
+	MinLengthSource int               `mapstructure:"min_length_source"`
+}
+
+// PriceConfig is what a provider charges per million characters or tokens;
+// Models overrides it for single models
+type PriceConfig struct {
+	PerMillionCharacters   float64                `mapstructure:"per_million_characters"`
+	PerMillionInputTokens  float64                `mapstructure:"per_million_input_tokens"`
+	PerMillionOutputTokens float64                `mapstructure:"per_million_output_tokens"`
+	Models                 map[string]PriceConfig `mapstructure:"models"`
+}
+
+// BatchConfig caps the texts sent in one call to providers that accept
+// several; zero values keep the provider's own limits
+type BatchConfig struct {
+	MaxItems int `mapstructure:"max_items"`
+	MaxBytes int `mapstructure:"max_bytes"`
 }
 
 // GoogleConfig contains Google Translate configuration
 type GoogleConfig struct {
-	APIKey   string `mapstructure:"api_key"`
-	Model    string `mapstructure:"model"`
-	Glossary string `mapstructure:"glossary"`
+	APIKey    string          `mapstructure:"api_key"`
+	Model     string          `mapstructure:"model"`
+	Glossary  string          `mapstructure:"glossary"`
+	Retry     RetryConfig     `mapstructure:"retry"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	Batch     BatchConfig     `mapstructure:"batch"`
 }
 
 // DeepLConfig contains DeepL configuration
 type DeepLConfig struct {
-	APIKey    string `mapstructure:"api_key"`
-	IsFree    bool   `mapstructure:"is_free"`
-	Formality string `mapstructure:"formality"`
+	APIKey    string          `mapstructure:"api_key"`
+	IsFree    bool            `mapstructure:"is_free"`
+	Formality string          `mapstructure:"formality"`
+	Retry     RetryConfig     `mapstructure:"retry"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	Batch     BatchConfig     `mapstructure:"batch"`
 }
 
 // BaiduConfig contains Baidu Translate configuration
 type BaiduConfig struct {
-	AppID     string `mapstructure:"app_id"`
-	AppSecret string `mapstructure:"app_secret"`
+	AppID     string          `mapstructure:"app_id"`
+	AppSecret string          `mapstructure:"app_secret"`
+	Retry     RetryConfig     `mapstructure:"retry"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	Batch     BatchConfig     `mapstructure:"batch"`
 }
 
 // OpenAIConfig contains OpenAI configuration
 type OpenAIConfig struct {
-	APIKey      string  `mapstructure:"api_key"`
-	APIBaseURL  string  `mapstructure:"api_base_url"`
-	Model       string  `mapstructure:"model"`
-	Temperature float64 `mapstructure:"temperature"`
-	MaxTokens   int     `mapstructure:"max_tokens"`
+	APIKey      string          `mapstructure:"api_key"`
+	APIBaseURL  string          `mapstructure:"api_base_url"`
+	Model       string          `mapstructure:"model"`
+	Temperature float64         `mapstructure:"temperature"`
+	MaxTokens   int             `mapstructure:"max_tokens"`
+	Retry       RetryConfig     `mapstructure:"retry"`
+	RateLimit   RateLimitConfig `mapstructure:"rate_limit"`
 }
 
 // DefaultConfig returns a configuration with default values
@@ -58,6 +158,14 @@ func DefaultConfig() *Config {
 			TargetLanguages: []string{"zh-Hans"},
 			Concurrency:     5,
 			Verbose:         false,
+			Retranslate:     []string{},
+			OutputState:     "needs_review",
+			Retry: RetryConfig{
+				MaxAttempts:    3,
+				InitialBackoff: time.Second,
+				MaxBackoff:     30 * time.Second,
+				Jitter:         0.2,
+			},
 		},
 		Google: GoogleConfig{
 			Model: "nmt",
@@ -66,12 +174,29 @@ func DefaultConfig() *Config {
 			IsFree:    false,
 			Formality: "default",
 		},
-		Baidu: BaiduConfig{},
+		Baidu: BaiduConfig{
+			// The standard tier allows one query per second.
+			RateLimit: RateLimitConfig{RequestsPerSecond: 1},
+		},
 		OpenAI: OpenAIConfig{
 			APIBaseURL:  "https://api.openai.com",
 			Model:       "gpt-3.5-turbo",
 			Temperature: 0.3,
 			MaxTokens:   1024,
 		},
+		QA: QAConfig{
+			Rules: map[string]string{
+				"placeholders": "error",
+				"whitespace":   "warning",
+				"punctuation":  "warning",
+				"length":       "warning",
+				"untranslated": "warning",
+				"glossary":     "warning",
+				"protected":    "warning",
+			},
+			MinLengthRatio:  0.25,
+			MaxLengthRatio:  3,
+			MinLengthSource: 10,
+		},
 	}
 }
@@ -0,0 +1,178 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// encodeXcode writes a decoded JSON value using the formatting Xcode applies to
+// String Catalogs (NSJSONSerialization pretty printing with sorted keys):
+// two-space indentation, `"key" : value` separators, keys in Foundation's
+// case-insensitive numeric order, escaped forward slashes, raw non-ASCII text
+// and no trailing newline.
+func encodeXcode(buf *bytes.Buffer, value any, depth int) error {
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return compareKeys(keys[i], keys[j]) < 0 })
+
+		buf.WriteString("{\n")
+		for i, key := range keys {
+			writeIndent(buf, depth+1)
+			writeString(buf, key)
+			buf.WriteString(" : ")
+			if err := encodeXcode(buf, v[key], depth+1); err != nil {
+				return err
+			}
+			if i < len(keys)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		if len(keys) == 0 {
+			buf.WriteByte('\n')
+		}
+		writeIndent(buf, depth)
+		buf.WriteByte('}')
+	case []any:
+		buf.WriteString("[\n")
+		for i, item := range v {
+			writeIndent(buf, depth+1)
+			if err := encodeXcode(buf, item, depth+1); err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		if len(v) == 0 {
+			buf.WriteByte('\n')
+		}
+		writeIndent(buf, depth)
+		buf.WriteByte(']')
+	case string:
+		writeString(buf, v)
+	case json.Number:
+		buf.WriteString(v.String())
+	case bool:
+		if v {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	case nil:
+		buf.WriteString("null")
+	default:
+		return fmt.Errorf("unsupported JSON value %T", value)
+	}
+	return nil
+}
+
+func writeIndent(buf *bytes.Buffer, depth int) {
+	for i := 0; i < depth; i++ {
+		buf.WriteString("  ")
+	}
+}
+
+// writeString quotes s the way NSJSONSerialization does.
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '/':
+			buf.WriteString(`\/`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// compareKeys orders keys like Foundation's compare with the numeric,
+// caseInsensitive and forcedOrdering options: digit runs compare by value,
+// letters compare without case, and exact ties fall back to a literal compare.
+func compareKeys(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		ra, na := utf8.DecodeRuneInString(a[i:])
+		rb, nb := utf8.DecodeRuneInString(b[j:])
+
+		if isDigit(ra) && isDigit(rb) {
+			ea, eb := digitRunEnd(a, i), digitRunEnd(b, j)
+			if c := compareNumeric(a[i:ea], b[j:eb]); c != 0 {
+				return c
+			}
+			i, j = ea, eb
+			continue
+		}
+
+		fa, fb := unicode.ToLower(ra), unicode.ToLower(rb)
+		if fa != fb {
+			if fa < fb {
+				return -1
+			}
+			return 1
+		}
+		i += na
+		j += nb
+	}
+
+	switch {
+	case len(a)-i < len(b)-j:
+		return -1
+	case len(a)-i > len(b)-j:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func digitRunEnd(s string, start int) int {
+	end := start
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	return end
+}
+
+// compareNumeric compares two runs of ASCII digits by numeric value.
+func compareNumeric(a, b string) int {
+	ta, tb := strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(ta) != len(tb) {
+		if len(ta) < len(tb) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(ta, tb)
+}
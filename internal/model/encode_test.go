@@ -0,0 +1,86 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestCompareKeys(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"a", "a", 0},
+		{"", "a", -1},
+		{"abc", "abcd", -1},
+		// Letters compare without case, ties fall back to a literal compare.
+		{"apple", "Banana", -1},
+		{"Zebra", "apple", 1},
+		{"A", "a", -1},
+		{"Save", "save", -1},
+		// Digit runs compare by value.
+		{"item2", "item10", -1},
+		{"Item 2", "item 10", -1},
+		{"%lld items", "%lld files", 1},
+		{"v1.10", "v1.9", 1},
+		{"007", "7", -1},
+		{"7", "007", 1},
+		{"10 apples", "9 pears", 1},
+		// Non-ASCII keys compare after ASCII letters.
+		{"é", "z", 1},
+		{"Ärger", "ärger", -1},
+	}
+	for _, tt := range tests {
+		if got := sign(compareKeys(tt.a, tt.b)); got != tt.want {
+			t.Errorf("compareKeys(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeXcode(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"empty object", map[string]any{}, "{\n\n}"},
+		{"empty array", []any{}, "[\n\n]"},
+		{"scalars", []any{true, false, nil, json.Number("2"), json.Number("0.5")},
+			"[\n  true,\n  false,\n  null,\n  2,\n  0.5\n]"},
+		{"sorted keys", map[string]any{"b": "2", "A": "1", "a10": "4", "a9": "3"},
+			"{\n  \"A\" : \"1\",\n  \"a9\" : \"3\",\n  \"a10\" : \"4\",\n  \"b\" : \"2\"\n}"},
+		{"nested", map[string]any{"strings": map[string]any{"k": map[string]any{}}},
+			"{\n  \"strings\" : {\n    \"k\" : {\n\n    }\n  }\n}"},
+		{"escapes", "a/b \"q\" \\ \t\n\r\b\f\x01", `"a\/b \"q\" \\ \t\n\r\b\f\u0001"`},
+		{"raw unicode", "日本語 🙂 <&>", `"日本語 🙂 <&>"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := encodeXcode(&buf, tt.value, 0); err != nil {
+				t.Fatalf("encodeXcode: %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("encodeXcode =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeXcodeUnsupported(t *testing.T) {
+	var buf bytes.Buffer
+	if err := encodeXcode(&buf, map[string]any{"n": 1}, 0); err == nil {
+		t.Error("encodeXcode accepted an int; values must come from a json.Number decoder")
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,254 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// RawFields keeps JSON object members the model does not know about, so that
+// anything Xcode (or another tool) wrote survives a load/save cycle verbatim.
+type RawFields map[string]json.RawMessage
+
+// extraFields returns the members of the JSON object in data whose names are not in known.
+func extraFields(data []byte, known ...string) (RawFields, error) {
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	for _, name := range known {
+		delete(all, name)
+	}
+	if len(all) == 0 {
+		return nil, nil
+	}
+	return RawFields(all), nil
+}
+
+// object starts an output object pre-populated with the unknown members.
+func (r RawFields) object() map[string]any {
+	obj := make(map[string]any, len(r)+4)
+	for name, raw := range r {
+		obj[name] = raw
+	}
+	return obj
+}
+
+// marshalObject encodes obj without HTML escaping so that characters such as
+// '<', '>' and '&' are written the same way Xcode writes them.
+func marshalObject(obj map[string]any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(obj); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// UnmarshalJSON decodes the catalog and keeps unknown top-level keys.
+func (x *XCStrings) UnmarshalJSON(data []byte) error {
+	type plain XCStrings
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	extra, err := extraFields(data, "sourceLanguage", "strings", "version")
+	if err != nil {
+		return err
+	}
+	*x = XCStrings(p)
+	x.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the catalog including unknown top-level keys.
+func (x XCStrings) MarshalJSON() ([]byte, error) {
+	obj := x.Extra.object()
+	obj["sourceLanguage"] = x.SourceLanguage
+	strs := x.Strings
+	if strs == nil {
+		strs = map[string]StringEntry{}
+	}
+	obj["strings"] = strs
+	obj["version"] = x.Version
+	return marshalObject(obj)
+}
+
+// UnmarshalJSON decodes the entry and keeps unknown keys.
+func (e *StringEntry) UnmarshalJSON(data []byte) error {
+	type plain StringEntry
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	extra, err := extraFields(data, "comment", "extractionState", "isCommentAutoGenerated", "localizations", "shouldTranslate")
+	if err != nil {
+		return err
+	}
+	*e = StringEntry(p)
+	e.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the entry including unknown keys.
+func (e StringEntry) MarshalJSON() ([]byte, error) {
+	obj := e.Extra.object()
+	if e.Comment != "" {
+		obj["comment"] = e.Comment
+	}
+	if e.ExtractionState != "" {
+		obj["extractionState"] = e.ExtractionState
+	}
+	if e.IsCommentAutoGenerated != nil {
+		obj["isCommentAutoGenerated"] = *e.IsCommentAutoGenerated
+	}
+	if e.Localizations != nil {
+		obj["localizations"] = e.Localizations
+	}
+	if e.ShouldTranslate != nil {
+		obj["shouldTranslate"] = *e.ShouldTranslate
+	}
+	return marshalObject(obj)
+}
+
+// UnmarshalJSON decodes the localization and keeps unknown keys.
+func (l *Localization) UnmarshalJSON(data []byte) error {
+	type plain Localization
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	extra, err := extraFields(data, "stringUnit", "variations", "substitutions")
+	if err != nil {
+		return err
+	}
+	*l = Localization(p)
+	l.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the localization including unknown keys.
+func (l Localization) MarshalJSON() ([]byte, error) {
+	obj := l.Extra.object()
+	if l.StringUnit != nil {
+		obj["stringUnit"] = l.StringUnit
+	}
+	if l.Substitutions != nil {
+		obj["substitutions"] = l.Substitutions
+	}
+	if l.Variations != nil {
+		obj["variations"] = l.Variations
+	}
+	return marshalObject(obj)
+}
+
+// UnmarshalJSON decodes the string unit and keeps unknown keys.
+func (u *StringUnit) UnmarshalJSON(data []byte) error {
+	type plain StringUnit
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	extra, err := extraFields(data, "state", "value")
+	if err != nil {
+		return err
+	}
+	*u = StringUnit(p)
+	u.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the string unit including unknown keys.
+func (u StringUnit) MarshalJSON() ([]byte, error) {
+	obj := u.Extra.object()
+	obj["state"] = u.State
+	obj["value"] = u.Value
+	return marshalObject(obj)
+}
+
+// UnmarshalJSON decodes the variations and keeps unknown variation kinds.
+func (v *Variations) UnmarshalJSON(data []byte) error {
+	type plain Variations
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	extra, err := extraFields(data, "plural", "device")
+	if err != nil {
+		return err
+	}
+	*v = Variations(p)
+	v.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the variations including unknown variation kinds.
+func (v Variations) MarshalJSON() ([]byte, error) {
+	obj := v.Extra.object()
+	if v.Device != nil {
+		obj["device"] = v.Device
+	}
+	if v.Plural != nil {
+		obj["plural"] = v.Plural
+	}
+	return marshalObject(obj)
+}
+
+// UnmarshalJSON decodes the variation and keeps unknown keys.
+func (v *Variation) UnmarshalJSON(data []byte) error {
+	type plain Variation
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	extra, err := extraFields(data, "stringUnit", "variations")
+	if err != nil {
+		return err
+	}
+	*v = Variation(p)
+	v.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the variation including unknown keys.
+func (v Variation) MarshalJSON() ([]byte, error) {
+	obj := v.Extra.object()
+	if v.StringUnit != nil {
+		obj["stringUnit"] = v.StringUnit
+	}
+	if v.Variations != nil {
+		obj["variations"] = v.Variations
+	}
+	return marshalObject(obj)
+}
+
+// UnmarshalJSON decodes the substitution and keeps unknown keys.
+func (s *Substitution) UnmarshalJSON(data []byte) error {
+	type plain Substitution
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	extra, err := extraFields(data, "argNum", "formatSpecifier", "variations")
+	if err != nil {
+		return err
+	}
+	*s = Substitution(p)
+	s.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the substitution including unknown keys.
+func (s Substitution) MarshalJSON() ([]byte, error) {
+	obj := s.Extra.object()
+	if s.ArgNum != 0 {
+		obj["argNum"] = s.ArgNum
+	}
+	if s.FormatSpecifier != "" {
+		obj["formatSpecifier"] = s.FormatSpecifier
+	}
+	if s.Variations != nil {
+		obj["variations"] = s.Variations
+	}
+	return marshalObject(obj)
+}
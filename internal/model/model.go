@@ -1,10 +1,12 @@
 package model
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 )
 
 // XCStrings represents the structure of a Localizable.xcstrings file
@@ -12,23 +14,74 @@ type XCStrings struct {
 	SourceLanguage string                 `json:"sourceLanguage"`
 	Strings        map[string]StringEntry `json:"strings"`
 	Version        string                 `json:"version"`
+	Extra          RawFields              `json:"-"`
 }
 
 // StringEntry represents a single string entry with its localizations
 type StringEntry struct {
-	Localizations   map[string]Localization `json:"localizations,omitempty"`
-	ShouldTranslate *bool                   `json:"shouldTranslate,omitempty"`
+	Comment                string                  `json:"comment,omitempty"`
+	ExtractionState        string                  `json:"extractionState,omitempty"`
+	IsCommentAutoGenerated *bool                   `json:"isCommentAutoGenerated,omitempty"`
+	Localizations          map[string]Localization `json:"localizations,omitempty"`
+	ShouldTranslate        *bool                   `json:"shouldTranslate,omitempty"`
+	Extra                  RawFields               `json:"-"`
 }
 
-// Localization represents a localization for a specific language
+// Localization represents a localization for a specific language.
+// A localization carries either a plain stringUnit or variations, and may
+// additionally define substitutions referenced from its text via %#@name@.
 type Localization struct {
-	StringUnit StringUnit `json:"stringUnit"`
+	StringUnit    *StringUnit             `json:"stringUnit,omitempty"`
+	Variations    *Variations             `json:"variations,omitempty"`
+	Substitutions map[string]Substitution `json:"substitutions,omitempty"`
+	Extra         RawFields               `json:"-"`
+}
+
+// Value returns the text of the plain stringUnit, or "" when there is none.
+func (l Localization) Value() string {
+	if l.StringUnit == nil {
+		return ""
+	}
+	return l.StringUnit.Value
+}
+
+// State returns the state of the plain stringUnit, or "" when there is none.
+func (l Localization) State() string {
+	if l.StringUnit == nil {
+		return ""
+	}
+	return l.StringUnit.State
 }
 
 // StringUnit contains the translation state and value
 type StringUnit struct {
-	State string `json:"state"`
-	Value string `json:"value"`
+	State string    `json:"state"`
+	Value string    `json:"value"`
+	Extra RawFields `json:"-"`
+}
+
+// Variations groups alternative forms of a string, keyed by plural category
+// (zero, one, two, few, many, other) or by device class (iphone, ipad, mac, ...).
+type Variations struct {
+	Plural map[string]Variation `json:"plural,omitempty"`
+	Device map[string]Variation `json:"device,omitempty"`
+	Extra  RawFields            `json:"-"`
+}
+
+// Variation is a single variant: either a final stringUnit or further nested
+// variations (for example a device variant that is itself pluralised).
+type Variation struct {
+	StringUnit *StringUnit `json:"stringUnit,omitempty"`
+	Variations *Variations `json:"variations,omitempty"`
+	Extra      RawFields   `json:"-"`
+}
+
+// Substitution describes a %#@name@ placeholder and the variations it expands to.
+type Substitution struct {
+	ArgNum          int         `json:"argNum,omitempty"`
+	FormatSpecifier string      `json:"formatSpecifier,omitempty"`
+	Variations      *Variations `json:"variations,omitempty"`
+	Extra           RawFields   `json:"-"`
 }
 
 // TranslationRequest represents a request to translate a string
@@ -37,14 +90,65 @@ type TranslationRequest struct {
 	Text           string
 	SourceLanguage string
 	TargetLanguage string
+	// Path locates the stringUnit inside the localization (see Localization.Unit).
+	Path string
+	// Comment is the developer comment from the catalog, used as translation context.
+	Comment string
+	// Current is the translation the target already has, which this request
+	// replaces; empty for units that are not translated yet.
+	Current string
+	// Masked means the placeholders in Text were replaced by tokens the
+	// provider must keep: <x id="n"/> tags for tag-aware providers, which
+	// also get XML-escaped text, {n} for engines that rewrite ⟦n⟧, and ⟦n⟧
+	// otherwise.
+	Masked bool
+	// Glossary lists the project glossary terms used in Text with their
+	// mandated translation into TargetLanguage.
+	Glossary []GlossaryTerm
+	// Protected lists the do-not-translate terms used in Text, which are
+	// masked like placeholders and must come back verbatim.
+	Protected []string
+}
+
+// GlossaryTerm is a term whose translation is fixed by the project glossary.
+type GlossaryTerm struct {
+	Term          string
+	Translation   string
+	CaseSensitive bool
+	PartOfSpeech  string
+	Note          string
 }
 
 // TranslationResponse represents a response from a translation provider
 type TranslationResponse struct {
 	Key            string
 	TargetLanguage string
+	Path           string
 	TranslatedText string
-	Error          error
+	// Provider names the provider that produced the response, when known,
+	// and Model the model it used, if it has any.
+	Provider string
+	Model    string
+	// Cached is set when the translation came from the translation memory
+	// instead of the provider.
+	Cached bool
+	// Warnings lists the QA rules the translation broke without being rejected.
+	Warnings []string
+	// Usage is what the provider call billed for this text; zero for cached
+	// and failed translations.
+	Usage Usage
+	// Latency is the time spent in the provider call, retries included; a
+	// batch call is shared evenly among its texts.
+	Latency time.Duration
+	Error   error
+}
+
+// Usage is what a provider bills for translating: source characters for
+// machine translation services, tokens for language models.
+type Usage struct {
+	Characters       int
+	PromptTokens     int
+	CompletionTokens int
 }
 
 // TranslationProvider defines the interface for translation providers
@@ -52,6 +156,32 @@ type TranslationProvider interface {
 	Translate(ctx context.Context, req TranslationRequest) (TranslationResponse, error)
 }
 
+// BatchLimits bounds what a BatchTranslationProvider accepts in one call.
+type BatchLimits struct {
+	// MaxItems is the most texts per call; 1 or less disables batching.
+	MaxItems int
+	// MaxBytes caps the summed UTF-8 size of the texts per call; 0 means no cap.
+	MaxBytes int
+	// SharedContext means the call carries one comment for all of its texts,
+	// so only requests with the same comment may share a call.
+	SharedContext bool
+	// SingleLine means texts containing newlines cannot share a call.
+	SingleLine bool
+}
+
+// BatchTranslationProvider is implemented by providers that can translate
+// several texts in one call. The service batches requests for them and falls
+// back to Translate for everything else.
+type BatchTranslationProvider interface {
+	TranslationProvider
+	// BatchLimits returns the provider's limits per call.
+	BatchLimits() BatchLimits
+	// TranslateBatch translates reqs, which share their source and target
+	// language, and returns one response per request in the same order. An
+	// error means the whole call failed.
+	TranslateBatch(ctx context.Context, reqs []TranslationRequest) ([]TranslationResponse, error)
+}
+
 // LoadXCStrings loads an xcstrings file from disk
 func LoadXCStrings(filePath string) (*XCStrings, error) {
 	data, err := os.ReadFile(filePath)
@@ -92,11 +222,24 @@ func SaveXCStrings(filePath string, xcstrings *XCStrings) error {
 	return nil
 }
 
-// MarshalXCStrings marshals xcstrings to pretty JSON.
+// MarshalXCStrings marshals xcstrings using Xcode's own formatting, so that
+// loading and saving an untouched catalog yields a byte-identical file.
 func MarshalXCStrings(xcstrings *XCStrings) ([]byte, error) {
-	data, err := json.MarshalIndent(xcstrings, "", "  ")
+	data, err := json.Marshal(xcstrings)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal JSON: %v", err)
 	}
-	return data, nil
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var tree any
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := encodeXcode(&buf, tree, 0); err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON: %v", err)
+	}
+	return buf.Bytes(), nil
 }
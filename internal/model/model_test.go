@@ -0,0 +1,101 @@
+package model
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRoundTrip checks that parsing and saving an untouched catalog yields
+// the very same bytes, for catalogs as Xcode writes them.
+func TestRoundTrip(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.xcstrings"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no catalogs in testdata")
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			xcstrings, err := ParseXCStrings(want)
+			if err != nil {
+				t.Fatalf("ParseXCStrings: %v", err)
+			}
+			got, err := MarshalXCStrings(xcstrings)
+			if err != nil {
+				t.Fatalf("MarshalXCStrings: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("round trip changed the catalog at byte %d:\n%s", firstDifference(got, want), got)
+			}
+		})
+	}
+}
+
+// TestRoundTripFields checks that the fixtures are parsed into the fields
+// they exercise, so the round trip is not carried by Extra alone.
+func TestRoundTripFields(t *testing.T) {
+	basic, err := LoadXCStrings(filepath.Join("testdata", "basic.xcstrings"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := basic.Strings["%lld items"].Comment; got != "Item count on the cart screen" {
+		t.Errorf("comment = %q", got)
+	}
+	if got := basic.Strings["Item 2"].ExtractionState; got != "manual" {
+		t.Errorf("extractionState = %q", got)
+	}
+	if got := basic.Strings["Line\tone\nLine two"].ShouldTranslate; got == nil || *got {
+		t.Errorf("shouldTranslate = %v, want false", got)
+	}
+	open := basic.Strings["Open https://example.com/help"]
+	if _, ok := open.Extra["x-owner"]; !ok {
+		t.Error("unknown entry key x-owner was not kept")
+	}
+	if got := open.Localizations["ja"].StringUnit.Value; got != "https://example.com/help を開く" {
+		t.Errorf("ja value = %q", got)
+	}
+	if _, ok := basic.Extra["x-generator"]; !ok {
+		t.Error("unknown top-level key x-generator was not kept")
+	}
+
+	variations, err := LoadXCStrings(filepath.Join("testdata", "variations.xcstrings"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(variations.Strings["%lld files"].Localizations["ar"].Variations.Plural); got != 6 {
+		t.Errorf("ar plural forms = %d, want 6", got)
+	}
+	watch := variations.Strings["Unread %lld"].Localizations["fr"].Unit("device.applewatch.plural.one")
+	if watch == nil || watch.Value != "%lld non lu" {
+		t.Errorf("nested device plural unit = %+v", watch)
+	}
+	photos := variations.Strings["You have %#@photos@ in %#@albums@"].Localizations["de"].Substitutions["photos"]
+	if photos.ArgNum != 1 || photos.FormatSpecifier != "lld" {
+		t.Errorf("photos substitution = %+v", photos)
+	}
+
+	export, err := LoadXCStrings(filepath.Join("testdata", "xcode-export.xcstrings"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := export.Strings[""].ShouldTranslate; got == nil || *got {
+		t.Errorf("empty key shouldTranslate = %v, want false", got)
+	}
+}
+
+func firstDifference(a, b []byte) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return min(len(a), len(b))
+}
@@ -0,0 +1,185 @@
+package model
+
+import (
+	"sort"
+	"strings"
+)
+
+// A unit path addresses a single stringUnit inside a Localization using
+// dot-separated "kind.name" pairs, for example "plural.one",
+// "device.ipad.plural.other" or "substitutions.count.plural.few". The empty
+// path is the top-level stringUnit of the localization.
+
+// UnitRef is a stringUnit together with the path it was found at.
+type UnitRef struct {
+	Path string
+	Unit StringUnit
+}
+
+// JoinPath appends a "kind.name" pair to a unit path.
+func JoinPath(path, kind, name string) string {
+	if path == "" {
+		return kind + "." + name
+	}
+	return path + "." + kind + "." + name
+}
+
+func splitPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+// Unit returns the stringUnit at path, or nil when the localization has none there.
+func (l Localization) Unit(path string) *StringUnit {
+	segs := splitPath(path)
+	if len(segs) == 0 {
+		return l.StringUnit
+	}
+	if segs[0] == "substitutions" && len(segs) > 2 {
+		sub, ok := l.Substitutions[segs[1]]
+		if !ok {
+			return nil
+		}
+		return sub.Variations.unit(segs[2:])
+	}
+	return l.Variations.unit(segs)
+}
+
+// SetUnit stores unit at path, creating intermediate variations as needed.
+// A localization holds either a plain stringUnit or variations, as Xcode
+// writes them, so setting one drops the other; substitutions are kept.
+func (l *Localization) SetUnit(path string, unit StringUnit) {
+	segs := splitPath(path)
+	if len(segs) == 0 {
+		l.StringUnit = &unit
+		l.Variations = nil
+		return
+	}
+	if segs[0] == "substitutions" && len(segs) > 2 {
+		if l.Substitutions == nil {
+			l.Substitutions = make(map[string]Substitution)
+		}
+		sub := l.Substitutions[segs[1]]
+		if sub.Variations == nil {
+			sub.Variations = &Variations{}
+		}
+		sub.Variations.setUnit(segs[2:], unit)
+		l.Substitutions[segs[1]] = sub
+		return
+	}
+	if l.Variations == nil {
+		l.Variations = &Variations{}
+	}
+	if l.Variations.setUnit(segs, unit) {
+		l.StringUnit = nil
+	}
+}
+
+// Units lists every stringUnit in the localization, ordered by path.
+func (l Localization) Units() []UnitRef {
+	var refs []UnitRef
+	if l.StringUnit != nil {
+		refs = append(refs, UnitRef{Path: "", Unit: *l.StringUnit})
+	}
+	refs = l.Variations.collect("", refs)
+	for name, sub := range l.Substitutions {
+		refs = sub.Variations.collect(JoinPath("", "substitutions", name), refs)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Path < refs[j].Path })
+	return refs
+}
+
+// DisplayValue returns a representative text for the localization: the plain
+// value when there is one, otherwise the "other" plural form or the first variant.
+func (l Localization) DisplayValue() string {
+	if l.StringUnit != nil {
+		return l.StringUnit.Value
+	}
+	units := l.Units()
+	for _, ref := range units {
+		if strings.HasSuffix(ref.Path, "plural.other") {
+			return ref.Unit.Value
+		}
+	}
+	if len(units) > 0 {
+		return units[0].Unit.Value
+	}
+	return ""
+}
+
+// kind returns the variation map for a variation kind such as "plural".
+func (v *Variations) kind(name string) *map[string]Variation {
+	switch name {
+	case "plural":
+		return &v.Plural
+	case "device":
+		return &v.Device
+	default:
+		return nil
+	}
+}
+
+func (v *Variations) unit(segs []string) *StringUnit {
+	if v == nil || len(segs) < 2 {
+		return nil
+	}
+	m := v.kind(segs[0])
+	if m == nil {
+		return nil
+	}
+	variation, ok := (*m)[segs[1]]
+	if !ok {
+		return nil
+	}
+	if len(segs) == 2 {
+		return variation.StringUnit
+	}
+	return variation.Variations.unit(segs[2:])
+}
+
+// setUnit stores unit at segs and reports whether segs was a valid path.
+func (v *Variations) setUnit(segs []string, unit StringUnit) bool {
+	if len(segs) < 2 {
+		return false
+	}
+	m := v.kind(segs[0])
+	if m == nil {
+		return false
+	}
+	if *m == nil {
+		*m = make(map[string]Variation)
+	}
+	variation := (*m)[segs[1]]
+	if len(segs) == 2 {
+		variation.StringUnit = &unit
+		variation.Variations = nil
+	} else {
+		if variation.Variations == nil {
+			variation.Variations = &Variations{}
+		}
+		if !variation.Variations.setUnit(segs[2:], unit) {
+			return false
+		}
+		variation.StringUnit = nil
+	}
+	(*m)[segs[1]] = variation
+	return true
+}
+
+func (v *Variations) collect(prefix string, refs []UnitRef) []UnitRef {
+	if v == nil {
+		return refs
+	}
+	for _, kind := range []string{"device", "plural"} {
+		for name, variation := range *v.kind(kind) {
+			path := JoinPath(prefix, kind, name)
+			if variation.StringUnit != nil {
+				refs = append(refs, UnitRef{Path: path, Unit: *variation.StringUnit})
+			}
+			refs = variation.Variations.collect(path, refs)
+		}
+	}
+	return refs
+}
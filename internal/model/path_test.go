@@ -0,0 +1,144 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestJoinPath(t *testing.T) {
+	tests := []struct {
+		path, kind, name, want string
+	}{
+		{"", "plural", "one", "plural.one"},
+		{"device.ipad", "plural", "few", "device.ipad.plural.few"},
+		{"", "substitutions", "count", "substitutions.count"},
+	}
+	for _, tt := range tests {
+		if got := JoinPath(tt.path, tt.kind, tt.name); got != tt.want {
+			t.Errorf("JoinPath(%q, %q, %q) = %q, want %q", tt.path, tt.kind, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSetUnit(t *testing.T) {
+	paths := []string{
+		"",
+		"plural.one",
+		"plural.other",
+		"device.mac",
+		"device.ipad.plural.one",
+		"substitutions.count.plural.other",
+		"substitutions.count.device.iphone",
+	}
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			var loc Localization
+			loc.SetUnit(path, StringUnit{State: "translated", Value: "value at " + path})
+			got := loc.Unit(path)
+			if got == nil || got.Value != "value at "+path {
+				t.Fatalf("Unit(%q) = %+v after SetUnit", path, got)
+			}
+			if refs := loc.Units(); len(refs) != 1 || refs[0].Path != path {
+				t.Errorf("Units = %+v, want the one unit at %q", refs, path)
+			}
+		})
+	}
+}
+
+func TestSetUnitReplacesShape(t *testing.T) {
+	tests := []struct {
+		name  string
+		start []string
+		path  string
+		want  []string
+	}{
+		{"variations replace the plain unit", []string{""}, "plural.one", []string{"plural.one"}},
+		{"plain unit replaces variations", []string{"plural.one", "device.mac"}, "", []string{""}},
+		{"nested variations replace a variant's unit", []string{"device.ipad"}, "device.ipad.plural.one", []string{"device.ipad.plural.one"}},
+		{"a variant's unit replaces its nested variations", []string{"device.ipad.plural.one", "device.mac"}, "device.ipad", []string{"device.ipad", "device.mac"}},
+		{"substitutions are kept", []string{"", "substitutions.count.plural.one"}, "", []string{"", "substitutions.count.plural.one"}},
+		{"invalid path keeps the plain unit", []string{""}, "unknown.one", []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var loc Localization
+			for _, path := range tt.start {
+				loc.SetUnit(path, StringUnit{Value: "old"})
+			}
+			loc.SetUnit(tt.path, StringUnit{Value: "new"})
+			var paths []string
+			for _, ref := range loc.Units() {
+				paths = append(paths, ref.Path)
+			}
+			if !slices.Equal(paths, tt.want) {
+				t.Errorf("Units paths = %q, want %q", paths, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnitMissing(t *testing.T) {
+	var loc Localization
+	loc.SetUnit("plural.one", StringUnit{Value: "one"})
+	for _, path := range []string{"", "plural.other", "device.mac", "plural", "plural.one.device.mac", "substitutions.count.plural.one", "unknown.one"} {
+		if got := loc.Unit(path); got != nil {
+			t.Errorf("Unit(%q) = %+v, want nil", path, got)
+		}
+	}
+}
+
+func TestUnits(t *testing.T) {
+	xcstrings, err := ParseXCStrings([]byte(`{"sourceLanguage": "en", "version": "1.0", "strings": {"k": {"localizations": {"en": {
+  "stringUnit": {"state": "translated", "value": "%#@count@ on %#@device@"},
+  "variations": {"device": {
+    "iphone": {"stringUnit": {"state": "translated", "value": "iPhone"}},
+    "ipad": {"variations": {"plural": {
+      "one": {"stringUnit": {"state": "new", "value": "one iPad"}},
+      "other": {"stringUnit": {"state": "new", "value": "iPads"}}
+    }}}
+  }},
+  "substitutions": {"count": {"argNum": 1, "formatSpecifier": "lld", "variations": {"plural": {
+    "other": {"stringUnit": {"state": "translated", "value": "%arg items"}}
+  }}}}
+}}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	loc := xcstrings.Strings["k"].Localizations["en"]
+
+	var paths []string
+	for _, ref := range loc.Units() {
+		paths = append(paths, ref.Path)
+		if got := loc.Unit(ref.Path); got == nil || got.Value != ref.Unit.Value {
+			t.Errorf("Unit(%q) = %+v, want %+v", ref.Path, got, ref.Unit)
+		}
+	}
+	want := []string{"", "device.ipad.plural.one", "device.ipad.plural.other", "device.iphone", "substitutions.count.plural.other"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("Units paths = %q, want %q", paths, want)
+	}
+}
+
+func TestDisplayValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		units map[string]string
+		want  string
+	}{
+		{"plain", map[string]string{"": "Hello"}, "Hello"},
+		{"plural other", map[string]string{"plural.one": "one file", "plural.other": "files"}, "files"},
+		{"first variant", map[string]string{"device.mac": "Click", "device.iphone": "Tap"}, "Tap"},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var loc Localization
+			for path, value := range tt.units {
+				loc.SetUnit(path, StringUnit{Value: value})
+			}
+			if got := loc.DisplayValue(); got != tt.want {
+				t.Errorf("DisplayValue = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -37,6 +37,7 @@ type Payload struct {
 type UILocalization struct {
 	Key          string            `json:"key"`
 	Source       string            `json:"source"`
+	Comment      string            `json:"comment,omitempty"`
 	State        string            `json:"state"`
 	Translations map[string]string `json:"translations"`
 	Missing      []string          `json:"missing"`
@@ -49,7 +50,67 @@ type TranslateRequest struct {
 	SourceLanguage  string         `json:"sourceLanguage"`
 	Concurrency     int            `json:"concurrency"`
 	TimeoutSeconds  int            `json:"timeoutSeconds"`
+	Retranslate     []string       `json:"retranslate"`
+	OutputState     string         `json:"outputState"`
+	MaxAttempts     int            `json:"maxAttempts"`
+	ContinueOnError bool           `json:"continueOnError"`
+	MaxErrors       int            `json:"maxErrors"`
 	Config          ProviderConfig `json:"config"`
+	// QA maps QA rules to error, warning or off; unset rules keep their default.
+	QA map[string]string `json:"qa"`
+	// DoNotTranslate lists terms masked before sending and kept verbatim.
+	DoNotTranslate []string `json:"doNotTranslate"`
+	// Fallback lists providers tried in order when Provider fails with one of
+	// the FallbackOn error classes (default: quota, unsupported).
+	Fallback   []FallbackProvider `json:"fallback"`
+	FallbackOn []string           `json:"fallbackOn"`
+}
+
+// FallbackProvider is one provider of a fallback chain with its own options.
+type FallbackProvider struct {
+	Provider string         `json:"provider"`
+	Config   ProviderConfig `json:"config"`
+}
+
+// CompareRequest describes a comparison of providers on a sample of the
+// loaded catalog.
+type CompareRequest struct {
+	Providers       []ComparedProvider `json:"providers"`
+	TargetLanguages []string           `json:"targetLanguages"`
+	SourceLanguage  string             `json:"sourceLanguage"`
+	// Keys are glob patterns selecting the keys to compare; empty selects all.
+	Keys []string `json:"keys"`
+	// Sample caps the keys compared, 20 when unset; negative compares them all.
+	Sample         int               `json:"sample"`
+	Concurrency    int               `json:"concurrency"`
+	TimeoutSeconds int               `json:"timeoutSeconds"`
+	MaxAttempts    int               `json:"maxAttempts"`
+	QA             map[string]string `json:"qa"`
+	DoNotTranslate []string          `json:"doNotTranslate"`
+	// Pick "auto" marks the best candidate of every string as its winner.
+	Pick string `json:"pick"`
+}
+
+// ComparedProvider is one provider of a comparison; Price, when given,
+// estimates the cost of its candidates.
+type ComparedProvider struct {
+	Provider string            `json:"provider"`
+	Config   ProviderConfig    `json:"config"`
+	Price    *translator.Price `json:"price"`
+}
+
+// ApplyPicksRequest writes translations picked from a comparison into the catalog.
+type ApplyPicksRequest struct {
+	Picks       []ComparePick `json:"picks"`
+	OutputState string        `json:"outputState"`
+}
+
+// ComparePick is the translation picked for one unit.
+type ComparePick struct {
+	Key      string `json:"key"`
+	Language string `json:"language"`
+	Path     string `json:"path"`
+	Text     string `json:"text"`
 }
 
 // ProviderConfig is the union of provider-specific options we support.
@@ -64,6 +125,13 @@ type ProviderConfig struct {
 	MaxTokens   int     `json:"maxTokens"`
 	Formality   string  `json:"formality"`
 	IsFree      bool    `json:"isFree"`
+	// Rate limits; zero is unlimited.
+	RequestsPerSecond   float64 `json:"requestsPerSecond"`
+	CharactersPerMinute int     `json:"charactersPerMinute"`
+	TokensPerMinute     int     `json:"tokensPerMinute"`
+	// BatchSize caps the texts per call for batching providers; zero keeps
+	// the provider's limit and 1 turns batching off.
+	BatchSize int `json:"batchSize"`
 }
 
 // ServerState holds the in-memory working copy of the xcstrings data.
@@ -83,6 +151,28 @@ type Job struct {
 	Done      int       `json:"done"`
 	Total     int       `json:"total"`
 	UpdatedAt time.Time `json:"updatedAt"`
+	// Failures lists the units that could not be translated.
+	Failures []translator.Failure `json:"failures,omitempty"`
+	// Warnings lists the translations QA warned about.
+	Warnings []QAWarning `json:"warnings,omitempty"`
+	// Duplicates counts the strings that reused the translation of an
+	// identical one; SavedCalls the provider calls this saved.
+	Duplicates int `json:"duplicates,omitempty"`
+	SavedCalls int `json:"savedCalls,omitempty"`
+	// Providers counts the successful translations per provider.
+	Providers map[string]int `json:"providers,omitempty"`
+	// Retries counts the provider calls tried again after a transient failure.
+	Retries int `json:"retries,omitempty"`
+	// Usage sums the characters, tokens and time spent per language and provider.
+	Usage *translator.UsageReport `json:"usage,omitempty"`
+}
+
+// QAWarning is a QA finding on a translation that was still applied.
+type QAWarning struct {
+	Key      string `json:"key"`
+	Language string `json:"language"`
+	Path     string `json:"path,omitempty"`
+	Message  string `json:"message"`
 }
 
 // Serve starts the Fiber server using the embedded UI assets.
@@ -126,6 +216,8 @@ func NewApp() (*fiber.App, error) {
 	api.Post("/translate", state.handleTranslate)
 	api.Get("/progress", state.handleProgress)
 	api.Get("/export", state.handleExport)
+	api.Post("/compare", state.handleCompare)
+	api.Post("/compare/apply", state.handleApplyPicks)
 
 	app.Use("/", filesystem.New(filesystem.Config{
 		Root:         http.FS(distFS),
@@ -231,6 +323,17 @@ func (s *ServerState) handleTranslate(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "targetLanguages is required")
 	}
 
+	policy, err := translator.ParseRetranslatePolicy(req.Retranslate)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := translator.ValidateOutputState(req.OutputState); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := translator.ValidateSeverities(req.QA); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
 	s.mu.RLock()
 	xc := s.xcstrings
 	s.mu.RUnlock()
@@ -243,7 +346,7 @@ func (s *ServerState) handleTranslate(c *fiber.Ctx) error {
 		xc.SourceLanguage = req.SourceLanguage
 	}
 
-	requests := translator.CreateTranslationRequests(xc, req.TargetLanguages)
+	requests := translator.CreateTranslationRequests(xc, req.TargetLanguages, policy)
 	job := s.startJob(len(requests))
 
 	// If nothing to do, finish immediately.
@@ -252,11 +355,132 @@ func (s *ServerState) handleTranslate(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"jobId": job.ID})
 	}
 
-	go s.runTranslation(job, xc, req)
+	go s.runTranslation(job, xc, req, policy)
 
 	return c.JSON(fiber.Map{"jobId": job.ID})
 }
 
+// handleCompare translates a sample of the catalog with every requested
+// provider and returns the candidates side by side. The catalog is not changed.
+func (s *ServerState) handleCompare(c *fiber.Ctx) error {
+	var req CompareRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	if len(req.Providers) < 2 {
+		return fiber.NewError(fiber.StatusBadRequest, "at least two providers are required")
+	}
+	if len(req.TargetLanguages) == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "targetLanguages is required")
+	}
+	if err := translator.ValidateSeverities(req.QA); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if req.Pick != "" && req.Pick != "auto" {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown pick mode %q (want auto)", req.Pick))
+	}
+
+	s.mu.RLock()
+	xc := s.xcstrings
+	s.mu.RUnlock()
+	if xc == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "upload a xcstrings file first")
+	}
+
+	sample := req.Sample
+	if sample == 0 {
+		sample = 20
+	}
+	s.mu.RLock()
+	requests, err := translator.ComparisonRequests(xc, translator.CompareOptions{
+		SourceLanguage: req.SourceLanguage,
+		Languages:      req.TargetLanguages,
+		KeyPatterns:    req.Keys,
+		Sample:         sample,
+	})
+	s.mu.RUnlock()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	timeout := time.Duration(req.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 300 * time.Second
+	}
+	validator := translator.DefaultValidator()
+	for rule, severity := range req.QA {
+		validator.Severities[rule] = severity
+	}
+	protected := translator.NewProtectedTerms(req.DoNotTranslate)
+
+	prices := translator.PriceTable{}
+	var services []*translator.TranslationService
+	for _, compared := range req.Providers {
+		name := strings.ToLower(compared.Provider)
+		provider, err := buildProvider(name, compared.Config)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("%s: %v", name, err))
+		}
+		service := translator.NewTranslationService(provider, concurrency, timeout)
+		service.Name = name
+		if req.MaxAttempts > 0 {
+			service.Retry.MaxAttempts = req.MaxAttempts
+		}
+		service.Limiter = translator.NewRateLimiter(translator.RateLimit{
+			RequestsPerSecond:   compared.Config.RequestsPerSecond,
+			CharactersPerMinute: compared.Config.CharactersPerMinute,
+			TokensPerMinute:     compared.Config.TokensPerMinute,
+		})
+		service.Validator = validator
+		service.Protected = protected
+		services = append(services, service)
+		if compared.Price != nil {
+			prices[name] = *compared.Price
+		}
+	}
+
+	comparison := translator.Compare(c.UserContext(), requests, services, prices)
+	if req.Pick == "auto" {
+		comparison.PickBest()
+	}
+	c.Set("Content-Type", "application/json")
+	return translator.WriteComparison(c.Response().BodyWriter(), comparison, "json")
+}
+
+// handleApplyPicks writes the translations picked from a comparison into the
+// loaded catalog.
+func (s *ServerState) handleApplyPicks(c *fiber.Ctx) error {
+	var req ApplyPicksRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	if err := translator.ValidateOutputState(req.OutputState); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	responses := make([]model.TranslationResponse, len(req.Picks))
+	for i, pick := range req.Picks {
+		if pick.Key == "" || pick.Language == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "every pick needs a key and a language")
+		}
+		responses[i] = model.TranslationResponse{Key: pick.Key, TargetLanguage: pick.Language, Path: pick.Path, TranslatedText: pick.Text}
+	}
+
+	s.mu.Lock()
+	if s.xcstrings == nil {
+		s.mu.Unlock()
+		return fiber.NewError(fiber.StatusBadRequest, "upload a xcstrings file first")
+	}
+	translator.ApplyTranslations(s.xcstrings, responses, req.OutputState)
+	s.mu.Unlock()
+
+	return c.JSON(s.buildPayload(nil))
+}
+
 func (s *ServerState) buildPayload(targets []string) *Payload {
 	s.mu.RLock()
 	xc := s.xcstrings
@@ -306,7 +530,7 @@ func flattenEntries(xc *model.XCStrings, targets []string) []UILocalization {
 		entry := xc.Strings[key]
 		translations := make(map[string]string)
 		for lang, loc := range entry.Localizations {
-			translations[lang] = loc.StringUnit.Value
+			translations[lang] = loc.DisplayValue()
 		}
 
 		sourceText := translations[xc.SourceLanguage]
@@ -316,7 +540,7 @@ func flattenEntries(xc *model.XCStrings, targets []string) []UILocalization {
 
 		state := ""
 		if sourceLoc, ok := entry.Localizations[xc.SourceLanguage]; ok {
-			state = sourceLoc.StringUnit.State
+			state = sourceLoc.State()
 		}
 
 		missing := []string{}
@@ -329,6 +553,7 @@ func flattenEntries(xc *model.XCStrings, targets []string) []UILocalization {
 		entries = append(entries, UILocalization{
 			Key:          key,
 			Source:       sourceText,
+			Comment:      entry.Comment,
 			State:        state,
 			Translations: translations,
 			Missing:      missing,
@@ -436,7 +661,7 @@ func (s *ServerState) finishJob(status, msg string) {
 	s.job.UpdatedAt = time.Now()
 }
 
-func (s *ServerState) runTranslation(job *Job, xc *model.XCStrings, req TranslateRequest) {
+func (s *ServerState) runTranslation(job *Job, xc *model.XCStrings, req TranslateRequest, policy translator.RetranslatePolicy) {
 	provider, err := buildProvider(strings.ToLower(req.Provider), req.Config)
 	if err != nil {
 		s.finishJob("error", err.Error())
@@ -454,19 +679,53 @@ func (s *ServerState) runTranslation(job *Job, xc *model.XCStrings, req Translat
 	}
 
 	service := translator.NewTranslationService(provider, concurrency, timeout)
-	ctx := context.Background()
-
-	progressBuilder := func(target string, total int) translator.ProgressReporter {
-		return func(done, total int, resp model.TranslationResponse) {
-			if resp.Error == nil {
-				s.applyResponse(resp)
-			}
-			s.incrementJob(1)
+	service.Name = strings.ToLower(req.Provider)
+	if req.MaxAttempts > 0 {
+		service.Retry.MaxAttempts = req.MaxAttempts
+	}
+	rateLimit := translator.RateLimit{
+		RequestsPerSecond:   req.Config.RequestsPerSecond,
+		CharactersPerMinute: req.Config.CharactersPerMinute,
+		TokensPerMinute:     req.Config.TokensPerMinute,
+	}
+	service.Limiter = translator.NewRateLimiter(rateLimit)
+	service.BatchLimits.MaxItems = req.Config.BatchSize
+	service.Validator = translator.DefaultValidator()
+	for rule, severity := range req.QA {
+		service.Validator.Severities[rule] = severity
+	}
+	service.Protected = translator.NewProtectedTerms(req.DoNotTranslate)
+	service.ContinueOnError = req.ContinueOnError
+	service.MaxErrors = req.MaxErrors
+	service.FallbackOn = req.FallbackOn
+	service.Events = translator.NewEventBus(s.jobTracker(req.OutputState))
+	last := service
+	for _, fallback := range req.Fallback {
+		name := strings.ToLower(fallback.Provider)
+		provider, err := buildProvider(name, fallback.Config)
+		if err != nil {
+			s.finishJob("error", fmt.Sprintf("fallback %s: %v", name, err))
+			return
 		}
+		next := translator.NewTranslationService(provider, concurrency, timeout)
+		next.Name = name
+		next.Retry = service.Retry
+		next.Limiter = translator.NewRateLimiter(translator.RateLimit{
+			RequestsPerSecond:   fallback.Config.RequestsPerSecond,
+			CharactersPerMinute: fallback.Config.CharactersPerMinute,
+			TokensPerMinute:     fallback.Config.TokensPerMinute,
+		})
+		next.BatchLimits.MaxItems = fallback.Config.BatchSize
+		next.Validator = service.Validator
+		next.FallbackOn = req.FallbackOn
+		next.Events = service.Events
+		last.Fallback = next
+		last = next
 	}
+	ctx := context.Background()
 
-	responses, translateErr := translator.TranslatePerLanguage(ctx, xc, req.TargetLanguages, service, progressBuilder)
-	translator.ApplyTranslations(xc, responses)
+	responses, translateErr := translator.TranslatePerLanguage(ctx, xc, req.TargetLanguages, policy, service)
+	translator.ApplyTranslations(xc, responses, req.OutputState)
 
 	if len(req.TargetLanguages) > 0 {
 		s.mu.Lock()
@@ -474,20 +733,68 @@ func (s *ServerState) runTranslation(job *Job, xc *model.XCStrings, req Translat
 		s.mu.Unlock()
 	}
 
+	failures := translator.NewFailureReport(responses).Failures
+	s.mu.Lock()
+	if s.job != nil && len(failures) > 0 {
+		s.job.Failures = failures
+	}
+	if s.job != nil {
+		s.job.Duplicates = service.Duplicates()
+		s.job.SavedCalls = service.SavedCalls()
+		usage := translator.NewUsageReport(responses, nil)
+		s.job.Usage = &usage
+		if len(req.Fallback) > 0 {
+			s.job.Providers = map[string]int{}
+		}
+		for _, resp := range responses {
+			if s.job.Providers != nil && resp.Error == nil {
+				s.job.Providers[resp.Provider]++
+			}
+			for _, warning := range resp.Warnings {
+				s.job.Warnings = append(s.job.Warnings, QAWarning{Key: resp.Key, Language: resp.TargetLanguage, Path: resp.Path, Message: warning})
+			}
+		}
+	}
+	s.mu.Unlock()
+
 	if translateErr != nil {
 		s.finishJob("error", translateErr.Error())
 		return
 	}
 
+	if len(failures) > 0 {
+		s.finishJob("done", fmt.Sprintf("%d translations failed.", len(failures)))
+		return
+	}
 	s.finishJob("done", "")
 }
 
+// jobTracker follows the events of a translation: finished requests advance
+// the job and successful ones are applied with state right away.
+func (s *ServerState) jobTracker(state string) translator.EventSubscriber {
+	return translator.EventFunc(func(event translator.Event) {
+		switch event.Kind {
+		case translator.EventSucceeded:
+			s.applyResponse(*event.Response, state)
+			s.incrementJob(1)
+		case translator.EventFailed:
+			s.incrementJob(1)
+		case translator.EventRetrying:
+			s.mu.Lock()
+			if s.job != nil {
+				s.job.Retries++
+			}
+			s.mu.Unlock()
+		}
+	})
+}
+
 // applyResponse applies a single successful translation response.
-func (s *ServerState) applyResponse(resp model.TranslationResponse) {
+func (s *ServerState) applyResponse(resp model.TranslationResponse, state string) {
 	if resp.Error != nil {
 		return
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	translator.ApplyTranslations(s.xcstrings, []model.TranslationResponse{resp})
+	translator.ApplyTranslations(s.xcstrings, []model.TranslationResponse{resp}, state)
 }
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/fdddf/xcstrings-translator/internal/model"
 
@@ -55,23 +56,86 @@ func (b *BaiduTranslator) generateSign(q, salt string) string {
 	return hex.EncodeToString(h.Sum(nil))
 }
 
+// baiduBatchLimits keeps the newline-joined query below the API's
+// recommended 6000 bytes.
+var baiduBatchLimits = model.BatchLimits{MaxItems: 50, MaxBytes: 5000, SingleLine: true}
+
 // Translate translates a string using Baidu Translate API
 func (b *BaiduTranslator) Translate(ctx context.Context, req model.TranslationRequest) (model.TranslationResponse, error) {
+	results, err := b.translateQuery(ctx, req.Text, req.SourceLanguage, req.TargetLanguage)
+	if err != nil {
+		return model.TranslationResponse{
+			Key:            req.Key,
+			TargetLanguage: req.TargetLanguage,
+			Error:          err,
+		}, nil
+	}
+
+	return model.TranslationResponse{
+		Key:            req.Key,
+		TargetLanguage: req.TargetLanguage,
+		TranslatedText: strings.Join(results, "\n"),
+	}, nil
+}
+
+// MasksWithBraces reports that Baidu needs {n} placeholder tokens: it
+// rewrites ⟦n⟧, but passes brace tokens through, at worst full-width.
+func (b *BaiduTranslator) MasksWithBraces() bool {
+	return true
+}
+
+// BatchLimits returns Baidu's limits per query.
+func (b *BaiduTranslator) BatchLimits() model.BatchLimits {
+	return baiduBatchLimits
+}
+
+// TranslateBatch joins the texts with newlines into one query; Baidu returns
+// one result per line, so none of the texts may contain a newline itself.
+func (b *BaiduTranslator) TranslateBatch(ctx context.Context, reqs []model.TranslationRequest) ([]model.TranslationResponse, error) {
+	lines := make([]string, len(reqs))
+	for i, req := range reqs {
+		if strings.Contains(req.Text, "\n") {
+			return nil, fmt.Errorf("cannot batch multi-line text for key %s", req.Key)
+		}
+		lines[i] = req.Text
+	}
+
+	results, err := b.translateQuery(ctx, strings.Join(lines, "\n"), reqs[0].SourceLanguage, reqs[0].TargetLanguage)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) != len(lines) {
+		return nil, fmt.Errorf("got %d translations for %d texts", len(results), len(lines))
+	}
+
+	responses := make([]model.TranslationResponse, len(reqs))
+	for i, req := range reqs {
+		responses[i] = model.TranslationResponse{
+			Key:            req.Key,
+			TargetLanguage: req.TargetLanguage,
+			TranslatedText: results[i],
+		}
+	}
+	return responses, nil
+}
+
+// translateQuery sends q to Baidu and returns the translation of each of its lines.
+func (b *BaiduTranslator) translateQuery(ctx context.Context, q, sourceLanguage, targetLanguage string) ([]string, error) {
 	apiURL := "https://fanyi-api.baidu.com/api/trans/vip/translate"
 
 	// Generate random salt
 	salt := strconv.Itoa(rand.Intn(1000000000))
 
 	// Generate sign
-	sign := b.generateSign(req.Text, salt)
+	sign := b.generateSign(q, salt)
 
 	// Convert language codes to Baidu format
-	sourceLang := convertToBaiduLang(req.SourceLanguage)
-	targetLang := convertToBaiduLang(req.TargetLanguage)
+	sourceLang := convertToBaiduLang(sourceLanguage)
+	targetLang := convertToBaiduLang(targetLanguage)
 
 	// Prepare form data
 	formData := url.Values{}
-	formData.Set("q", req.Text)
+	formData.Set("q", q)
 	formData.Set("from", sourceLang)
 	formData.Set("to", targetLang)
 	formData.Set("appid", b.AppID)
@@ -84,52 +148,31 @@ func (b *BaiduTranslator) Translate(ctx context.Context, req model.TranslationRe
 		Post(apiURL)
 
 	if err != nil {
-		return model.TranslationResponse{
-			Key:            req.Key,
-			TargetLanguage: req.TargetLanguage,
-			Error:          fmt.Errorf("request failed: %v", err),
-		}, nil
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 
 	if resp.StatusCode() != http.StatusOK {
-		return model.TranslationResponse{
-			Key:            req.Key,
-			TargetLanguage: req.TargetLanguage,
-			Error:          fmt.Errorf("API request failed with status code: %d, response: %s", resp.StatusCode(), resp.String()),
-		}, nil
+		return nil, newAPIError(resp)
 	}
 
 	var translationResponse BaiduTranslateResponse
-	err = json.Unmarshal(resp.Body(), &translationResponse)
-	if err != nil {
-		return model.TranslationResponse{
-			Key:            req.Key,
-			TargetLanguage: req.TargetLanguage,
-			Error:          fmt.Errorf("failed to parse response: %v", err),
-		}, nil
+	if err := json.Unmarshal(resp.Body(), &translationResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %v", err)
 	}
 
 	if translationResponse.ErrorCode != "" {
-		return model.TranslationResponse{
-			Key:            req.Key,
-			TargetLanguage: req.TargetLanguage,
-			Error:          fmt.Errorf("API error: %s - %s", translationResponse.ErrorCode, translationResponse.ErrorMsg),
-		}, nil
+		return nil, &APIError{StatusCode: resp.StatusCode(), Code: translationResponse.ErrorCode, Message: translationResponse.ErrorMsg}
 	}
 
 	if len(translationResponse.TransResult) == 0 {
-		return model.TranslationResponse{
-			Key:            req.Key,
-			TargetLanguage: req.TargetLanguage,
-			Error:          fmt.Errorf("no translation results"),
-		}, nil
+		return nil, fmt.Errorf("no translation results")
 	}
 
-	return model.TranslationResponse{
-		Key:            req.Key,
-		TargetLanguage: req.TargetLanguage,
-		TranslatedText: translationResponse.TransResult[0].Dst,
-	}, nil
+	results := make([]string, len(translationResponse.TransResult))
+	for i, r := range translationResponse.TransResult {
+		results[i] = r.Dst
+	}
+	return results, nil
 }
 
 // convertToBaiduLang converts standard language codes to Baidu format
@@ -0,0 +1,213 @@
+package translator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// batchLimits returns the limits batches are packed to, or false when the
+// provider translates one text per call.
+func (s *TranslationService) batchLimits() (model.BatchLimits, bool) {
+	provider, ok := s.Provider.(model.BatchTranslationProvider)
+	if !ok {
+		return model.BatchLimits{}, false
+	}
+	limits := provider.BatchLimits()
+	if s.BatchLimits.MaxItems > 0 {
+		limits.MaxItems = s.BatchLimits.MaxItems
+	}
+	if s.BatchLimits.MaxBytes > 0 {
+		limits.MaxBytes = s.BatchLimits.MaxBytes
+	}
+	return limits, limits.MaxItems > 1
+}
+
+// batchGroup is what requests sharing a call must have in common.
+type batchGroup struct {
+	source, target, comment string
+}
+
+// packBatches splits requests into the jobs handed to workers: one request
+// each, or as many as the provider's limits allow for a batch provider.
+// Requests keep their order within a language pair. MaxBytes is measured on
+// the text as it is sent, masked and escaped, which is longer than the source.
+func (s *TranslationService) packBatches(requests []model.TranslationRequest) [][]model.TranslationRequest {
+	limits, ok := s.batchLimits()
+	if !ok {
+		jobs := make([][]model.TranslationRequest, len(requests))
+		for i, req := range requests {
+			jobs[i] = []model.TranslationRequest{req}
+		}
+		return jobs
+	}
+
+	var jobs [][]model.TranslationRequest
+	open := map[batchGroup]int{}
+	size := map[batchGroup]int{}
+	for _, req := range requests {
+		if limits.SingleLine && strings.Contains(req.Text, "\n") {
+			jobs = append(jobs, []model.TranslationRequest{req})
+			continue
+		}
+
+		group := batchGroup{source: req.SourceLanguage, target: req.TargetLanguage}
+		if limits.SharedContext {
+			group.comment = req.Comment
+		}
+
+		sent, _ := s.mask(req)
+		bytes := len(sent.Text)
+		i, ok := open[group]
+		full := ok && (len(jobs[i]) >= limits.MaxItems ||
+			limits.MaxBytes > 0 && size[group]+bytes > limits.MaxBytes)
+		if !ok || full {
+			jobs = append(jobs, nil)
+			i = len(jobs) - 1
+			open[group] = i
+			size[group] = 0
+		}
+		jobs[i] = append(jobs[i], req)
+		size[group] += bytes
+	}
+	return jobs
+}
+
+// translateJob translates one job, answering what it can from the memory and
+// sending the rest to the provider in a single call. Requests the provider
+// could not translate go down the fallback chain.
+func (s *TranslationService) translateJob(ctx context.Context, job []model.TranslationRequest) []model.TranslationResponse {
+	return s.fallBack(ctx, job, s.translateOwn(ctx, job))
+}
+
+// translateOwn translates one job with the service's own provider.
+func (s *TranslationService) translateOwn(ctx context.Context, job []model.TranslationRequest) []model.TranslationResponse {
+	if len(job) == 1 {
+		return []model.TranslationResponse{s.translate(ctx, job[0])}
+	}
+
+	var responses []model.TranslationResponse
+	var pending []model.TranslationRequest
+	for _, req := range job {
+		if text, ok := s.lookup(req); ok {
+			responses = append(responses, s.cached(req, text))
+			continue
+		}
+		pending = append(pending, req)
+	}
+
+	if len(pending) == 0 {
+		return responses
+	}
+	sent := make([]model.TranslationRequest, len(pending))
+	masks := make([]*placeholderMask, len(pending))
+	for i, req := range pending {
+		sent[i], masks[i] = s.mask(req)
+	}
+	for i, resp := range s.call(ctx, pending, sent) {
+		responses = append(responses, s.finish(pending[i], masks[i], resp))
+	}
+	return responses
+}
+
+// call sends sent, the masked form of reqs, to the provider in one call,
+// within the budget, and returns one response per request with the usage it
+// was billed. Characters are counted on the original text, before masking
+// escaped it and added tags.
+func (s *TranslationService) call(ctx context.Context, reqs, sent []model.TranslationRequest) []model.TranslationResponse {
+	metered, _ := s.Provider.(TokenMetered)
+	meteredTokens := metered != nil && metered.MetersTokens()
+	var estimate model.Usage
+	for i, req := range sent {
+		usage := EstimateUsage(req)
+		usage.Characters = utf8.RuneCountInString(reqs[i].Text)
+		if !meteredTokens {
+			usage.PromptTokens, usage.CompletionTokens = 0, 0
+		}
+		estimate = addUsage(estimate, usage)
+	}
+
+	modelName := ModelName(s.Provider)
+	if err := s.Budget.reserve(s.Name, modelName, estimate); err != nil {
+		return failAll(sent, err)
+	}
+
+	for _, req := range sent {
+		s.Events.emitRequest(EventStarted, req, Event{Provider: s.Name, Attempt: 1})
+	}
+	start := time.Now()
+	var responses []model.TranslationResponse
+	if len(sent) == 1 {
+		responses = []model.TranslationResponse{s.translateWithRetry(ctx, sent[0])}
+	} else {
+		responses = s.translateBatchWithRetry(ctx, sent)
+	}
+	latency := time.Since(start) / time.Duration(len(sent))
+
+	var billed model.Usage
+	for i := range responses {
+		responses[i].Model = modelName
+		responses[i].Latency = latency
+		if responses[i].Error != nil {
+			continue
+		}
+		responses[i].Usage.Characters = utf8.RuneCountInString(reqs[i].Text)
+		// Language models that report no usage are charged the estimate.
+		if meteredTokens && tokens(responses[i].Usage) == 0 {
+			usage := EstimateUsage(sent[i])
+			responses[i].Usage.PromptTokens, responses[i].Usage.CompletionTokens = usage.PromptTokens, usage.CompletionTokens
+		}
+		billed = addUsage(billed, responses[i].Usage)
+	}
+	s.Budget.settle(s.Name, modelName, estimate, billed)
+	return responses
+}
+
+// failAll answers every request with err.
+func failAll(reqs []model.TranslationRequest, err error) []model.TranslationResponse {
+	responses := make([]model.TranslationResponse, len(reqs))
+	for i, req := range reqs {
+		responses[i] = model.TranslationResponse{Key: req.Key, TargetLanguage: req.TargetLanguage, Error: err}
+	}
+	return responses
+}
+
+// translateBatchWithRetry sends reqs to the batch provider in one call,
+// retrying the whole call like translateWithRetry does for a single request.
+// It returns one response per request; if the call fails they all carry the error.
+func (s *TranslationService) translateBatchWithRetry(ctx context.Context, reqs []model.TranslationRequest) []model.TranslationResponse {
+	provider := s.Provider.(model.BatchTranslationProvider)
+
+	for attempt := 1; ; attempt++ {
+		if err := s.Limiter.WaitBatch(ctx, reqs); err != nil {
+			return failAll(reqs, err)
+		}
+
+		responses, err := provider.TranslateBatch(ctx, reqs)
+		if err == nil && len(responses) != len(reqs) {
+			err = fmt.Errorf("batch returned %d translations for %d texts", len(responses), len(reqs))
+		}
+		if err == nil {
+			return responses
+		}
+		if attempt >= s.Retry.MaxAttempts || !IsRetryable(err) {
+			return failAll(reqs, err)
+		}
+
+		delay := s.Retry.Backoff(attempt, err)
+		for _, req := range reqs {
+			s.Events.emitRequest(EventRetrying, req, Event{Provider: s.Name, Attempt: attempt + 1, Delay: delay, Err: err})
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return failAll(reqs, err)
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package translator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// fakeBatchProvider is a batch provider translating like fakeProvider. It
+// records the texts of every batch call, and respond, when set, replaces its
+// answer to them.
+type fakeBatchProvider struct {
+	fakeProvider
+	limits  model.BatchLimits
+	respond func(reqs []model.TranslationRequest) ([]model.TranslationResponse, error)
+
+	batchMu sync.Mutex
+	batches [][]string
+}
+
+func (p *fakeBatchProvider) BatchLimits() model.BatchLimits {
+	return p.limits
+}
+
+func (p *fakeBatchProvider) TranslateBatch(ctx context.Context, reqs []model.TranslationRequest) ([]model.TranslationResponse, error) {
+	texts := make([]string, len(reqs))
+	for i, req := range reqs {
+		texts[i] = req.Text
+	}
+	p.batchMu.Lock()
+	p.batches = append(p.batches, texts)
+	p.batchMu.Unlock()
+
+	if p.respond != nil {
+		return p.respond(reqs)
+	}
+	responses := make([]model.TranslationResponse, len(reqs))
+	for i, req := range reqs {
+		responses[i] = model.TranslationResponse{Key: req.Key, TargetLanguage: req.TargetLanguage, TranslatedText: "[" + req.TargetLanguage + "] " + req.Text}
+	}
+	return responses, nil
+}
+
+// tagAwareBatchProvider is a fakeBatchProvider that keeps XML tags.
+type tagAwareBatchProvider struct {
+	fakeBatchProvider
+}
+
+func (p *tagAwareBatchProvider) HandlesTags() bool {
+	return true
+}
+
+// jobKeys lists the keys of each job.
+func jobKeys(jobs [][]model.TranslationRequest) [][]string {
+	keys := make([][]string, len(jobs))
+	for i, job := range jobs {
+		for _, req := range job {
+			keys[i] = append(keys[i], req.Key)
+		}
+	}
+	return keys
+}
+
+func TestPackBatches(t *testing.T) {
+	withComment := func(req model.TranslationRequest, comment string) model.TranslationRequest {
+		req.Comment = comment
+		return req
+	}
+	five := []model.TranslationRequest{
+		request("a", "One", "de"),
+		request("b", "Two", "de"),
+		request("c", "Three", "de"),
+		request("d", "Four", "de"),
+		request("e", "Five", "de"),
+	}
+
+	tests := []struct {
+		name      string
+		provider  model.TranslationProvider
+		overrides model.BatchLimits
+		requests  []model.TranslationRequest
+		want      [][]string
+	}{
+		{"single provider", &fakeProvider{}, model.BatchLimits{}, five[:3],
+			[][]string{{"a"}, {"b"}, {"c"}}},
+		{"max items", &fakeBatchProvider{limits: model.BatchLimits{MaxItems: 2}}, model.BatchLimits{}, five,
+			[][]string{{"a", "b"}, {"c", "d"}, {"e"}}},
+		{"max items overridden", &fakeBatchProvider{limits: model.BatchLimits{MaxItems: 50}}, model.BatchLimits{MaxItems: 3}, five,
+			[][]string{{"a", "b", "c"}, {"d", "e"}}},
+		{"batching turned off", &fakeBatchProvider{limits: model.BatchLimits{MaxItems: 50}}, model.BatchLimits{MaxItems: 1}, five[:2],
+			[][]string{{"a"}, {"b"}}},
+		// "One" and "Two" are 3 bytes, "Three" 5 and "Four" 4.
+		{"max bytes", &fakeBatchProvider{limits: model.BatchLimits{MaxItems: 50, MaxBytes: 8}}, model.BatchLimits{}, five[:4],
+			[][]string{{"a", "b"}, {"c"}, {"d"}}},
+		{"max bytes overridden", &fakeBatchProvider{limits: model.BatchLimits{MaxItems: 50, MaxBytes: 8}}, model.BatchLimits{MaxBytes: 100}, five[:4],
+			[][]string{{"a", "b", "c", "d"}}},
+		{"oversized text still sent", &fakeBatchProvider{limits: model.BatchLimits{MaxItems: 50, MaxBytes: 2}}, model.BatchLimits{}, five[:2],
+			[][]string{{"a"}, {"b"}}},
+		{"language pairs", &fakeBatchProvider{limits: model.BatchLimits{MaxItems: 50}}, model.BatchLimits{}, []model.TranslationRequest{
+			request("a", "One", "de"), request("b", "One", "fr"), request("c", "Two", "de"), request("d", "Two", "fr"),
+		}, [][]string{{"a", "c"}, {"b", "d"}}},
+		{"comments ignored", &fakeBatchProvider{limits: model.BatchLimits{MaxItems: 50}}, model.BatchLimits{}, []model.TranslationRequest{
+			withComment(five[0], "Title"), withComment(five[1], "Button"),
+		}, [][]string{{"a", "b"}}},
+		{"shared context", &fakeBatchProvider{limits: model.BatchLimits{MaxItems: 50, SharedContext: true}}, model.BatchLimits{}, []model.TranslationRequest{
+			withComment(five[0], "Title"), withComment(five[1], "Button"), withComment(five[2], "Title"),
+		}, [][]string{{"a", "c"}, {"b"}}},
+		// "%@ of %@" is 8 bytes but 26 once masked as <x id="n"/> tags.
+		{"max bytes of the masked text", &tagAwareBatchProvider{fakeBatchProvider{limits: model.BatchLimits{MaxItems: 50, MaxBytes: 40}}}, model.BatchLimits{}, []model.TranslationRequest{
+			request("a", "%@ of %@", "de"), request("b", "%@ of %@", "de"), request("c", "One", "de"),
+		}, [][]string{{"a"}, {"b", "c"}}},
+		{"single line", &fakeBatchProvider{limits: model.BatchLimits{MaxItems: 50, SingleLine: true}}, model.BatchLimits{}, []model.TranslationRequest{
+			five[0], request("multi", "Line one\nLine two", "de"), five[1],
+		}, [][]string{{"a", "b"}, {"multi"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestService(tt.provider)
+			service.BatchLimits = tt.overrides
+			got := jobKeys(service.packBatches(tt.requests))
+			if !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("jobs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslateBatchOrdering(t *testing.T) {
+	provider := &fakeBatchProvider{limits: model.BatchLimits{MaxItems: 3}}
+	service := newTestService(provider)
+	service.Concurrency = 2
+	var requests []model.TranslationRequest
+	want := map[string]string{}
+	for i := range 8 {
+		text := fmt.Sprintf("Text %d", i)
+		requests = append(requests, request(fmt.Sprintf("key%d", i), text, "de"))
+		want[fmt.Sprintf("key%d", i)] = "[de] " + text
+	}
+
+	responses, err := service.TranslateBatch(context.Background(), requests)
+	if err != nil {
+		t.Fatalf("TranslateBatch: %v", err)
+	}
+	for key, text := range translations(responses) {
+		if want[key] != text {
+			t.Errorf("%s = %q, want %q", key, text, want[key])
+		}
+	}
+	if len(responses) != len(requests) {
+		t.Errorf("%d responses for %d requests", len(responses), len(requests))
+	}
+	if len(provider.batches) != 3 || provider.calls() != 0 {
+		t.Errorf("%d batch calls and %d single calls, want 3 and 0", len(provider.batches), provider.calls())
+	}
+}
+
+func TestTranslateBatchResponseMismatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond func(reqs []model.TranslationRequest) ([]model.TranslationResponse, error)
+		wantErr string
+	}{
+		{"too few", func(reqs []model.TranslationRequest) ([]model.TranslationResponse, error) {
+			return []model.TranslationResponse{{TranslatedText: "Eins"}}, nil
+		}, "batch returned 1 translations for 3 texts"},
+		{"too many", func(reqs []model.TranslationRequest) ([]model.TranslationResponse, error) {
+			return make([]model.TranslationResponse, 4), nil
+		}, "batch returned 4 translations for 3 texts"},
+		{"call failed", func(reqs []model.TranslationRequest) ([]model.TranslationResponse, error) {
+			return nil, &APIError{StatusCode: 400, Message: "bad request"}
+		}, "bad request"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakeBatchProvider{limits: model.BatchLimits{MaxItems: 3}, respond: tt.respond}
+			service := newTestService(provider)
+			service.ContinueOnError = true
+			requests := []model.TranslationRequest{request("a", "One", "de"), request("b", "Two", "de"), request("c", "Three", "de")}
+
+			responses, err := service.TranslateBatch(context.Background(), requests)
+			if err != nil {
+				t.Fatalf("TranslateBatch: %v", err)
+			}
+			if got := failedKeys(responses); !slices.Equal(got, []string{"a", "b", "c"}) {
+				t.Fatalf("failed = %v, want every request", got)
+			}
+			for _, resp := range responses {
+				if !strings.Contains(resp.Error.Error(), tt.wantErr) {
+					t.Errorf("%s: error = %v, want %q", resp.Key, resp.Error, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestTranslateBatchWithMemoryHits(t *testing.T) {
+	provider := &fakeBatchProvider{limits: model.BatchLimits{MaxItems: 10}}
+	service := newTestService(provider)
+	service.Memory = openTestMemory(t).Scope("fake", provider)
+	service.Memory.Store(request("", "Two", "de"), "Zwei")
+	requests := []model.TranslationRequest{request("a", "One", "de"), request("b", "Two", "de"), request("c", "Three", "de")}
+
+	responses, err := service.TranslateBatch(context.Background(), requests)
+	if err != nil {
+		t.Fatalf("TranslateBatch: %v", err)
+	}
+	want := map[string]string{"a": "[de] One", "b": "Zwei", "c": "[de] Three"}
+	for key, text := range translations(responses) {
+		if want[key] != text {
+			t.Errorf("%s = %q, want %q", key, text, want[key])
+		}
+	}
+	if len(provider.batches) != 1 || !slices.Equal(provider.batches[0], []string{"One", "Three"}) {
+		t.Errorf("batches = %q, want only the texts not in memory", provider.batches)
+	}
+}
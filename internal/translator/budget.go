@@ -0,0 +1,150 @@
+package translator
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// ErrBudgetExceeded marks a request that was not sent because it would have
+// taken the run over its budget.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// BudgetLimit caps the spending of a run. Zero fields are unlimited.
+type BudgetLimit struct {
+	// MaxCost is in the currency of the price table.
+	MaxCost       float64
+	MaxCharacters int
+	MaxTokens     int
+}
+
+// Enabled reports whether any cap is set.
+func (l BudgetLimit) Enabled() bool {
+	return l.MaxCost > 0 || l.MaxCharacters > 0 || l.MaxTokens > 0
+}
+
+// TokenMetered is implemented by providers billed per token, such as language
+// models. Calls to the others do not count against a token budget.
+type TokenMetered interface {
+	MetersTokens() bool
+}
+
+// Budget tracks a run's spending against its limit. Every call reserves its
+// estimated usage before it is sent and settles with the usage the provider
+// reports, so concurrent calls cannot overshoot together. Once a call is
+// refused the budget stays exhausted, so no later, smaller call sneaks in.
+// It is shared by every service of a fallback chain; a nil Budget is unlimited.
+type Budget struct {
+	limit  BudgetLimit
+	prices PriceTable
+
+	mu        sync.Mutex
+	spent     model.Usage
+	cost      float64
+	reserved  model.Usage
+	held      float64
+	exhausted bool
+}
+
+// NewBudget returns a budget for limit, pricing calls with prices, or nil
+// when no cap is set.
+func NewBudget(limit BudgetLimit, prices PriceTable) *Budget {
+	if !limit.Enabled() {
+		return nil
+	}
+	return &Budget{limit: limit, prices: prices}
+}
+
+// Spent returns the usage and cost settled so far.
+func (b *Budget) Spent() (model.Usage, float64) {
+	if b == nil {
+		return model.Usage{}, 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spent, b.cost
+}
+
+// Exhausted reports whether a call has been refused.
+func (b *Budget) Exhausted() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exhausted
+}
+
+// reserve holds estimate for a call to provider's model, or fails with
+// ErrBudgetExceeded when it does not fit next to what is spent and held.
+func (b *Budget) reserve(provider, modelName string, estimate model.Usage) error {
+	if b == nil {
+		return nil
+	}
+	cost := b.price(provider, modelName, estimate)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.exhausted {
+		return ErrBudgetExceeded
+	}
+	var over string
+	switch {
+	case b.limit.MaxCharacters > 0 && b.spent.Characters+b.reserved.Characters+estimate.Characters > b.limit.MaxCharacters:
+		over = fmt.Sprintf("%d characters", b.limit.MaxCharacters)
+	case b.limit.MaxTokens > 0 && tokens(b.spent)+tokens(b.reserved)+tokens(estimate) > b.limit.MaxTokens:
+		over = fmt.Sprintf("%d tokens", b.limit.MaxTokens)
+	case b.limit.MaxCost > 0 && b.cost+b.held+cost > b.limit.MaxCost:
+		over = fmt.Sprintf("a cost of %g", b.limit.MaxCost)
+	}
+	if over != "" {
+		b.exhausted = true
+		return fmt.Errorf("%w: the next call would go over %s", ErrBudgetExceeded, over)
+	}
+	b.reserved = addUsage(b.reserved, estimate)
+	b.held += cost
+	return nil
+}
+
+// settle releases the estimate held for a call and books its actual usage.
+func (b *Budget) settle(provider, modelName string, estimate, actual model.Usage) {
+	if b == nil {
+		return
+	}
+	held := b.price(provider, modelName, estimate)
+	cost := b.price(provider, modelName, actual)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reserved = subtractUsage(b.reserved, estimate)
+	b.held -= held
+	b.spent = addUsage(b.spent, actual)
+	b.cost += cost
+}
+
+func (b *Budget) price(provider, modelName string, usage model.Usage) float64 {
+	price, _ := b.prices.Lookup(provider, modelName)
+	return price.Cost(usage)
+}
+
+func tokens(usage model.Usage) int {
+	return usage.PromptTokens + usage.CompletionTokens
+}
+
+func addUsage(a, b model.Usage) model.Usage {
+	return model.Usage{
+		Characters:       a.Characters + b.Characters,
+		PromptTokens:     a.PromptTokens + b.PromptTokens,
+		CompletionTokens: a.CompletionTokens + b.CompletionTokens,
+	}
+}
+
+func subtractUsage(a, b model.Usage) model.Usage {
+	return model.Usage{
+		Characters:       a.Characters - b.Characters,
+		PromptTokens:     a.PromptTokens - b.PromptTokens,
+		CompletionTokens: a.CompletionTokens - b.CompletionTokens,
+	}
+}
@@ -0,0 +1,114 @@
+package translator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+func TestBudgetReserveAtLimit(t *testing.T) {
+	// One unit of currency per character, so costs are exact.
+	prices := PriceTable{"deepl": {PerMillionCharacters: 1e6}}
+	chars := func(n int) model.Usage { return model.Usage{Characters: n} }
+	toks := func(prompt, completion int) model.Usage {
+		return model.Usage{PromptTokens: prompt, CompletionTokens: completion}
+	}
+
+	tests := []struct {
+		name     string
+		limit    BudgetLimit
+		provider string
+		calls    []model.Usage
+		// refused is the index of the first refused call, or -1.
+		refused int
+	}{
+		{"characters up to the limit", BudgetLimit{MaxCharacters: 10}, "deepl", []model.Usage{chars(4), chars(6)}, -1},
+		{"characters one over", BudgetLimit{MaxCharacters: 10}, "deepl", []model.Usage{chars(4), chars(7)}, 1},
+		{"single call over", BudgetLimit{MaxCharacters: 10}, "deepl", []model.Usage{chars(11)}, 0},
+		{"tokens up to the limit", BudgetLimit{MaxTokens: 100}, "openai", []model.Usage{toks(30, 20), toks(25, 25)}, -1},
+		{"tokens one over", BudgetLimit{MaxTokens: 100}, "openai", []model.Usage{toks(30, 20), toks(25, 26)}, 1},
+		{"cost up to the limit", BudgetLimit{MaxCost: 5}, "deepl", []model.Usage{chars(2), chars(3)}, -1},
+		{"cost one over", BudgetLimit{MaxCost: 5}, "deepl", []model.Usage{chars(2), chars(4)}, 1},
+		{"unpriced provider costs nothing", BudgetLimit{MaxCost: 5}, "google", []model.Usage{chars(100), chars(100)}, -1},
+		{"refusal is sticky", BudgetLimit{MaxCharacters: 10}, "deepl", []model.Usage{chars(9), chars(5), chars(1)}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := NewBudget(tt.limit, prices)
+			for i, usage := range tt.calls {
+				err := budget.reserve(tt.provider, "", usage)
+				switch {
+				case tt.refused >= 0 && i >= tt.refused:
+					if !errors.Is(err, ErrBudgetExceeded) {
+						t.Fatalf("call %d: reserve = %v, want ErrBudgetExceeded", i, err)
+					}
+				case err != nil:
+					t.Fatalf("call %d: reserve = %v", i, err)
+				}
+			}
+			if got := budget.Exhausted(); got != (tt.refused >= 0) {
+				t.Errorf("Exhausted = %v", got)
+			}
+		})
+	}
+}
+
+func TestBudgetSettle(t *testing.T) {
+	budget := NewBudget(BudgetLimit{MaxTokens: 100}, nil)
+	estimate := model.Usage{PromptTokens: 40, CompletionTokens: 40}
+	if err := budget.reserve("openai", "gpt-4o", estimate); err != nil {
+		t.Fatal(err)
+	}
+	// Held estimates count against the limit until they are settled.
+	if err := budget.reserve("openai", "gpt-4o", model.Usage{PromptTokens: 21}); err == nil {
+		t.Fatal("reserve over a held estimate succeeded")
+	}
+
+	budget = NewBudget(BudgetLimit{MaxTokens: 100}, nil)
+	if err := budget.reserve("openai", "gpt-4o", estimate); err != nil {
+		t.Fatal(err)
+	}
+	// The call came in under its estimate; the difference is free again.
+	budget.settle("openai", "gpt-4o", estimate, model.Usage{PromptTokens: 30, CompletionTokens: 20})
+	if spent, _ := budget.Spent(); spent.PromptTokens != 30 || spent.CompletionTokens != 20 {
+		t.Errorf("Spent = %+v", spent)
+	}
+	if err := budget.reserve("openai", "gpt-4o", model.Usage{PromptTokens: 25, CompletionTokens: 25}); err != nil {
+		t.Errorf("reserve up to the limit after settling = %v", err)
+	}
+	if err := budget.reserve("openai", "gpt-4o", model.Usage{CompletionTokens: 1}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("reserve past the limit = %v, want ErrBudgetExceeded", err)
+	}
+}
+
+func TestBudgetSettleCost(t *testing.T) {
+	prices := PriceTable{"openai": {PerMillionInputTokens: 1e6, PerMillionOutputTokens: 2e6}}
+	budget := NewBudget(BudgetLimit{MaxCost: 10}, prices)
+	estimate := model.Usage{PromptTokens: 2, CompletionTokens: 2}
+	if err := budget.reserve("openai", "", estimate); err != nil {
+		t.Fatal(err)
+	}
+	budget.settle("openai", "", estimate, model.Usage{PromptTokens: 2, CompletionTokens: 1})
+	if _, cost := budget.Spent(); cost != 4 {
+		t.Errorf("cost = %v, want 4", cost)
+	}
+	// 4 spent, so 6 is left: exactly three more output tokens.
+	if err := budget.reserve("openai", "", model.Usage{CompletionTokens: 3}); err != nil {
+		t.Errorf("reserve to the cost limit = %v", err)
+	}
+}
+
+func TestNilBudget(t *testing.T) {
+	if b := NewBudget(BudgetLimit{}, nil); b != nil {
+		t.Fatalf("NewBudget without a cap = %v, want nil", b)
+	}
+	var b *Budget
+	if err := b.reserve("openai", "", model.Usage{PromptTokens: 1 << 30}); err != nil {
+		t.Errorf("nil budget reserve = %v", err)
+	}
+	b.settle("openai", "", model.Usage{}, model.Usage{PromptTokens: 1})
+	if usage, cost := b.Spent(); usage != (model.Usage{}) || cost != 0 || b.Exhausted() {
+		t.Errorf("nil budget spent %+v, %v", usage, cost)
+	}
+}
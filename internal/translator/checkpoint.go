@@ -0,0 +1,248 @@
+package translator
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// CheckpointRun identifies the run a journal belongs to, written as its first
+// line. A journal is only resumed by a run of the same catalog and provider.
+type CheckpointRun struct {
+	InputFile string `json:"inputFile"`
+	Provider  string `json:"provider"`
+}
+
+// checkpointEntry is one line of the checkpoint journal. Source is a digest
+// of the source text that was translated.
+type checkpointEntry struct {
+	Key      string `json:"key"`
+	Language string `json:"language"`
+	Path     string `json:"path,omitempty"`
+	Source   string `json:"source"`
+	Text     string `json:"text"`
+	Provider string `json:"provider,omitempty"`
+}
+
+// unitID identifies one string unit of one language.
+type unitID struct{ key, language, path string }
+
+// Checkpoint is an append-only journal of successful translations, one JSON
+// object per line, so an interrupted run can be resumed without paying for
+// the same strings twice.
+type Checkpoint struct {
+	mu    sync.Mutex
+	path  string
+	file  *os.File
+	done  map[unitID]bool
+	stale int
+}
+
+// OpenCheckpoint opens the journal of run at path. With resume the entries
+// of an earlier run are replayed, except those whose source text in
+// xcstrings has changed since; a journal of another catalog or provider is
+// refused. Otherwise the journal starts empty.
+func OpenCheckpoint(path string, run CheckpointRun, xcstrings *model.XCStrings, resume bool) (*Checkpoint, []model.TranslationResponse, error) {
+	if abs, err := filepath.Abs(run.InputFile); err == nil {
+		run.InputFile = abs
+	}
+
+	var written *CheckpointRun
+	var entries []checkpointEntry
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if resume {
+		var err error
+		written, entries, err = readCheckpoint(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if written != nil && *written != run {
+			return nil, nil, fmt.Errorf("checkpoint %s belongs to a run of %s with %s; start over without --resume",
+				path, written.InputFile, written.Provider)
+		}
+	} else {
+		flags |= os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open checkpoint: %w", err)
+	}
+	c := &Checkpoint{path: path, file: file, done: map[unitID]bool{}}
+	if written == nil {
+		if err := c.append(run); err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+	}
+
+	var replay []model.TranslationResponse
+	for _, entry := range entries {
+		source, ok := sourceText(xcstrings, entry.Key, entry.Language, entry.Path)
+		if !ok || hashFingerprint(source) != entry.Source {
+			c.stale++
+			continue
+		}
+		c.done[unitID{entry.Key, entry.Language, entry.Path}] = true
+		replay = append(replay, model.TranslationResponse{
+			Key:            entry.Key,
+			TargetLanguage: entry.Language,
+			Path:           entry.Path,
+			TranslatedText: entry.Text,
+			Provider:       entry.Provider,
+		})
+	}
+	return c, replay, nil
+}
+
+// readCheckpoint parses the journal into the run it belongs to and its
+// entries. A missing file is an empty journal, and a truncated last line (the
+// process died mid-write) is ignored. A journal without a run line, written
+// by an older version, has entries that cannot be checked and is refused.
+func readCheckpoint(path string) (*CheckpointRun, []checkpointEntry, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	defer file.Close()
+
+	var run *CheckpointRun
+	var entries []checkpointEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if run == nil {
+			var header CheckpointRun
+			if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.InputFile == "" {
+				return nil, nil, fmt.Errorf("checkpoint %s does not say which catalog it belongs to; start over without --resume", path)
+			}
+			run = &header
+			continue
+		}
+		var entry checkpointEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	return run, entries, nil
+}
+
+// sourceText returns the source text of a unit of xcstrings, or false when
+// the catalog no longer has it.
+func sourceText(xcstrings *model.XCStrings, key, language, path string) (string, bool) {
+	entry, ok := xcstrings.Strings[key]
+	if !ok {
+		return "", false
+	}
+	units := sourceUnitsFor(entry.Localizations[xcstrings.SourceLanguage], language)
+	if len(units) == 0 && key != "" {
+		units = []sourceUnit{{Text: key}}
+	}
+	for _, unit := range units {
+		if unit.Path == path {
+			return unit.Text, true
+		}
+	}
+	return "", false
+}
+
+// Path returns the journal's file path.
+func (c *Checkpoint) Path() string {
+	return c.path
+}
+
+// Stale returns how many entries of the resumed journal were dropped because
+// their source text changed or left the catalog.
+func (c *Checkpoint) Stale() int {
+	if c == nil {
+		return 0
+	}
+	return c.stale
+}
+
+// Record appends a successful response, translated from source, to the
+// journal and syncs it to disk. Failed responses are not recorded. A nil
+// Checkpoint records nothing.
+func (c *Checkpoint) Record(resp model.TranslationResponse, source string) error {
+	if c == nil || resp.Error != nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.append(checkpointEntry{
+		Key:      resp.Key,
+		Language: resp.TargetLanguage,
+		Path:     resp.Path,
+		Source:   hashFingerprint(source),
+		Text:     resp.TranslatedText,
+		Provider: resp.Provider,
+	})
+	if err != nil {
+		return err
+	}
+	c.done[unitID{resp.Key, resp.TargetLanguage, resp.Path}] = true
+	return nil
+}
+
+// append writes v as a line of the journal and syncs it to disk.
+func (c *Checkpoint) append(v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := c.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := c.file.Sync(); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Pending drops the requests whose translation is already in the journal.
+func (c *Checkpoint) Pending(requests []model.TranslationRequest) []model.TranslationRequest {
+	if c == nil {
+		return requests
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var pending []model.TranslationRequest
+	for _, req := range requests {
+		if !c.done[unitID{req.Key, req.TargetLanguage, req.Path}] {
+			pending = append(pending, req)
+		}
+	}
+	return pending
+}
+
+// Close closes the journal, keeping it on disk for a later resume.
+func (c *Checkpoint) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.file.Close()
+}
+
+// Remove closes and deletes the journal once its translations are saved.
+func (c *Checkpoint) Remove() error {
+	if c == nil {
+		return nil
+	}
+	c.file.Close()
+	return os.Remove(c.path)
+}
@@ -0,0 +1,241 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+const checkpointCatalog = `{
+  "Hello": {},
+  "Goodbye": {},
+  "%lld files": {"localizations": {"en": {"variations": {"plural": {
+    "one": {"stringUnit": {"state": "translated", "value": "One file"}},
+    "other": {"stringUnit": {"state": "translated", "value": "Several files"}}
+  }}}}}
+}`
+
+// replayed lists the replayed responses as "key|language|path|text", sorted.
+func replayed(responses []model.TranslationResponse) []string {
+	var described []string
+	for _, resp := range responses {
+		described = append(described, resp.Key+"|"+resp.TargetLanguage+"|"+resp.Path+"|"+resp.TranslatedText)
+	}
+	slices.Sort(described)
+	return described
+}
+
+// openTestCheckpoint opens the journal at path for a deepl run of the catalog.
+func openTestCheckpoint(t *testing.T, path string, xcstrings *model.XCStrings, resume bool) (*Checkpoint, []model.TranslationResponse) {
+	t.Helper()
+	checkpoint, replay, err := OpenCheckpoint(path, CheckpointRun{InputFile: "Localizable.xcstrings", Provider: "deepl"}, xcstrings, resume)
+	if err != nil {
+		t.Fatalf("OpenCheckpoint: %v", err)
+	}
+	return checkpoint, replay
+}
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.checkpoint.jsonl")
+	xcstrings := parseCatalog(t, checkpointCatalog)
+
+	checkpoint, replay := openTestCheckpoint(t, path, xcstrings, false)
+	if len(replay) != 0 {
+		t.Fatalf("a new journal replayed %v", replay)
+	}
+	for _, record := range []struct {
+		resp   model.TranslationResponse
+		source string
+	}{
+		{model.TranslationResponse{Key: "Hello", TargetLanguage: "de", TranslatedText: "Hallo", Provider: "deepl"}, "Hello"},
+		{model.TranslationResponse{Key: "%lld files", TargetLanguage: "de", Path: "plural.one", TranslatedText: "Eine Datei"}, "One file"},
+		{model.TranslationResponse{Key: "Goodbye", TargetLanguage: "de", Error: errors.New("failed")}, "Goodbye"},
+	} {
+		if err := checkpoint.Record(record.resp, record.source); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	if err := checkpoint.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	checkpoint, replay = openTestCheckpoint(t, path, xcstrings, true)
+	defer checkpoint.Close()
+	want := []string{"%lld files|de|plural.one|Eine Datei", "Hello|de||Hallo"}
+	if got := replayed(replay); !slices.Equal(got, want) {
+		t.Errorf("replayed %q, want %q", got, want)
+	}
+	if checkpoint.Stale() != 0 {
+		t.Errorf("Stale = %d, want 0", checkpoint.Stale())
+	}
+}
+
+func TestCheckpointResumeSkipsFinishedUnits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.checkpoint.jsonl")
+	xcstrings := parseCatalog(t, checkpointCatalog)
+	requests := CreateTranslationRequestsForLanguage(xcstrings, "de", RetranslatePolicy{})
+
+	// The first run journals two units and is interrupted.
+	checkpoint, _ := openTestCheckpoint(t, path, xcstrings, false)
+	provider := &fakeProvider{}
+	service := newTestService(provider)
+	service.Checkpoint = checkpoint
+	var first []model.TranslationRequest
+	for _, req := range requests {
+		if req.Key != "Goodbye" && req.Path != "plural.other" {
+			first = append(first, req)
+		}
+	}
+	if _, err := service.TranslateBatch(context.Background(), first); err != nil {
+		t.Fatalf("TranslateBatch: %v", err)
+	}
+	checkpoint.Close()
+
+	// The resumed run only sends the rest.
+	checkpoint, replay := openTestCheckpoint(t, path, xcstrings, true)
+	defer checkpoint.Close()
+	if len(replay) != 2 {
+		t.Errorf("replayed %d units, want 2", len(replay))
+	}
+	provider = &fakeProvider{}
+	service = newTestService(provider)
+	service.Checkpoint = checkpoint
+	if _, err := service.TranslateBatch(context.Background(), checkpoint.Pending(requests)); err != nil {
+		t.Fatalf("TranslateBatch: %v", err)
+	}
+	slices.Sort(provider.texts)
+	if want := []string{"Goodbye", "Several files"}; !slices.Equal(provider.texts, want) {
+		t.Errorf("resumed run sent %q, want %q", provider.texts, want)
+	}
+	if pending := checkpoint.Pending(requests); len(pending) != 0 {
+		t.Errorf("%d units still pending after the resumed run", len(pending))
+	}
+}
+
+func TestCheckpointStartsOver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.checkpoint.jsonl")
+	xcstrings := parseCatalog(t, checkpointCatalog)
+	checkpoint, _ := openTestCheckpoint(t, path, xcstrings, false)
+	checkpoint.Record(model.TranslationResponse{Key: "Hello", TargetLanguage: "de", TranslatedText: "Hallo"}, "Hello")
+	checkpoint.Close()
+
+	checkpoint, _ = openTestCheckpoint(t, path, xcstrings, false)
+	checkpoint.Close()
+	checkpoint, replay := openTestCheckpoint(t, path, xcstrings, true)
+	defer checkpoint.Close()
+	if len(replay) != 0 {
+		t.Errorf("a journal opened without resume kept %v", replay)
+	}
+}
+
+func TestCheckpointRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.checkpoint.jsonl")
+	checkpoint, _ := openTestCheckpoint(t, path, parseCatalog(t, checkpointCatalog), false)
+	if err := checkpoint.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("journal still there after Remove: %v", err)
+	}
+}
+
+func TestNilCheckpoint(t *testing.T) {
+	var checkpoint *Checkpoint
+	requests := []model.TranslationRequest{request("Hello", "Hello", "de")}
+	if err := checkpoint.Record(model.TranslationResponse{Key: "Hello"}, "Hello"); err != nil {
+		t.Errorf("Record = %v", err)
+	}
+	if got := checkpoint.Pending(requests); len(got) != 1 {
+		t.Errorf("Pending = %v", got)
+	}
+	if err := checkpoint.Close(); err != nil {
+		t.Errorf("Close = %v", err)
+	}
+}
+
+func TestCheckpointResumeStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.checkpoint.jsonl")
+	checkpoint, _ := openTestCheckpoint(t, path, parseCatalog(t, checkpointCatalog), false)
+	checkpoint.Record(model.TranslationResponse{Key: "Hello", TargetLanguage: "de", TranslatedText: "Hallo"}, "Hello")
+	checkpoint.Record(model.TranslationResponse{Key: "Goodbye", TargetLanguage: "de", TranslatedText: "Tschüss"}, "Goodbye")
+	checkpoint.Record(model.TranslationResponse{Key: "%lld files", TargetLanguage: "de", Path: "plural.one", TranslatedText: "Eine Datei"}, "One file")
+	checkpoint.Record(model.TranslationResponse{Key: "%lld files", TargetLanguage: "de", Path: "plural.other", TranslatedText: "Mehrere Dateien"}, "Several files")
+	checkpoint.Close()
+
+	// Since the interruption, Goodbye left the catalog and the singular changed.
+	edited := parseCatalog(t, `{
+  "Hello": {},
+  "%lld files": {"localizations": {"en": {"variations": {"plural": {
+    "one": {"stringUnit": {"state": "translated", "value": "A single file"}},
+    "other": {"stringUnit": {"state": "translated", "value": "Several files"}}
+  }}}}}
+}`)
+	checkpoint, replay := openTestCheckpoint(t, path, edited, true)
+	defer checkpoint.Close()
+	want := []string{"%lld files|de|plural.other|Mehrere Dateien", "Hello|de||Hallo"}
+	if got := replayed(replay); !slices.Equal(got, want) {
+		t.Errorf("replayed %q, want %q", got, want)
+	}
+	if got := checkpoint.Stale(); got != 2 {
+		t.Errorf("Stale = %d, want 2", got)
+	}
+	pending := checkpoint.Pending(CreateTranslationRequestsForLanguage(edited, "de", RetranslatePolicy{}))
+	if got := describeRequests(pending); !slices.Equal(got, []string{"%lld files|plural.one|A single file"}) {
+		t.Errorf("pending = %q", got)
+	}
+}
+
+func TestCheckpointResumeOtherRun(t *testing.T) {
+	xcstrings := parseCatalog(t, checkpointCatalog)
+	tests := []struct {
+		name    string
+		journal string
+		run     CheckpointRun
+	}{
+		{"other catalog", "", CheckpointRun{InputFile: "Other.xcstrings", Provider: "deepl"}},
+		{"other provider", "", CheckpointRun{InputFile: "Localizable.xcstrings", Provider: "google"}},
+		{"no run line", `{"key":"Hello","language":"de","source":"x","text":"Hallo"}` + "\n", CheckpointRun{InputFile: "Localizable.xcstrings", Provider: "deepl"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "run.checkpoint.jsonl")
+			if tt.journal != "" {
+				os.WriteFile(path, []byte(tt.journal), 0644)
+			} else {
+				checkpoint, _ := openTestCheckpoint(t, path, xcstrings, false)
+				checkpoint.Record(model.TranslationResponse{Key: "Hello", TargetLanguage: "de", TranslatedText: "Hallo"}, "Hello")
+				checkpoint.Close()
+			}
+			if _, _, err := OpenCheckpoint(path, tt.run, xcstrings, true); err == nil {
+				t.Error("OpenCheckpoint resumed the journal of another run")
+			}
+		})
+	}
+}
+
+func TestCheckpointTruncatedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.checkpoint.jsonl")
+	xcstrings := parseCatalog(t, checkpointCatalog)
+	checkpoint, _ := openTestCheckpoint(t, path, xcstrings, false)
+	checkpoint.Record(model.TranslationResponse{Key: "Hello", TargetLanguage: "de", TranslatedText: "Hallo"}, "Hello")
+	checkpoint.Close()
+
+	// The process died while writing the next line.
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"key":"Goodbye","lang`)
+	file.Close()
+
+	checkpoint, replay := openTestCheckpoint(t, path, xcstrings, true)
+	defer checkpoint.Close()
+	if got := replayed(replay); !slices.Equal(got, []string{"Hello|de||Hallo"}) {
+		t.Errorf("replayed %q", got)
+	}
+}
@@ -0,0 +1,429 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// CompareOptions selects the units a comparison sends to every provider.
+type CompareOptions struct {
+	// SourceLanguage overrides the catalog's source language when set; the
+	// catalog itself is left as it is.
+	SourceLanguage string
+	Languages      []string
+	// KeyPatterns are glob patterns ("*" and "?") matched against string keys;
+	// empty matches every key.
+	KeyPatterns []string
+	// Sample caps the number of keys, picked evenly over the sorted matching
+	// keys so the sample spans the whole catalog; 0 compares every key.
+	Sample int
+}
+
+// Comparison holds the translations several providers made of the same units.
+type Comparison struct {
+	GeneratedAt    time.Time       `json:"generatedAt"`
+	SourceLanguage string          `json:"sourceLanguage"`
+	Providers      []string        `json:"providers"`
+	Rows           []ComparisonRow `json:"rows"`
+}
+
+// ComparisonRow is one unit with a candidate from each provider, in the
+// order of Comparison.Providers.
+type ComparisonRow struct {
+	Key        string      `json:"key"`
+	Language   string      `json:"language"`
+	Path       string      `json:"path,omitempty"`
+	Source     string      `json:"source"`
+	Comment    string      `json:"comment,omitempty"`
+	Candidates []Candidate `json:"candidates"`
+	// Winner names the provider whose candidate was picked, if any.
+	Winner string `json:"winner,omitempty"`
+}
+
+// Candidate is one provider's translation of a unit.
+type Candidate struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model,omitempty"`
+	Text     string `json:"text,omitempty"`
+	// Error is set when the provider failed or QA rejected the translation.
+	Error    string   `json:"error,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+	// LatencyMS is how long the provider took, retries and rate limiting included.
+	LatencyMS int64 `json:"latencyMs"`
+	// Cost is estimated from the provider's price; nil when it has none or
+	// the call failed.
+	Cost *float64 `json:"cost,omitempty"`
+}
+
+// ProviderSummary totals one provider's candidates in a comparison.
+type ProviderSummary struct {
+	Provider         string   `json:"provider"`
+	Translated       int      `json:"translated"`
+	Failed           int      `json:"failed"`
+	Warnings         int      `json:"warnings"`
+	Wins             int      `json:"wins"`
+	AverageLatencyMS int64    `json:"averageLatencyMs"`
+	Cost             *float64 `json:"cost,omitempty"`
+}
+
+// ComparisonRequests builds the requests a comparison sends: every unit of
+// the selected keys in each language, whatever its state, ordered by
+// language, key and path.
+func ComparisonRequests(xcstrings *model.XCStrings, opts CompareOptions) ([]model.TranslationRequest, error) {
+	if opts.SourceLanguage != "" && opts.SourceLanguage != xcstrings.SourceLanguage {
+		overridden := *xcstrings
+		overridden.SourceLanguage = opts.SourceLanguage
+		xcstrings = &overridden
+	}
+
+	var patterns []*regexp.Regexp
+	for _, pattern := range opts.KeyPatterns {
+		re, err := globToRegexp(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key pattern %q: %v", pattern, err)
+		}
+		patterns = append(patterns, re)
+	}
+
+	var keys []string
+	for key, entry := range xcstrings.Strings {
+		if entry.ShouldTranslate != nil && !*entry.ShouldTranslate {
+			continue
+		}
+		if matchesAny(patterns, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	keys = sampleKeys(keys, opts.Sample)
+
+	selected := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		selected[key] = true
+	}
+	var requests []model.TranslationRequest
+	for _, language := range opts.Languages {
+		var forLanguage []model.TranslationRequest
+		for _, req := range CreateTranslationRequestsForLanguage(xcstrings, language, RetranslatePolicy{All: true}) {
+			if selected[req.Key] {
+				forLanguage = append(forLanguage, req)
+			}
+		}
+		sort.Slice(forLanguage, func(i, j int) bool {
+			if forLanguage[i].Key != forLanguage[j].Key {
+				return forLanguage[i].Key < forLanguage[j].Key
+			}
+			return forLanguage[i].Path < forLanguage[j].Path
+		})
+		requests = append(requests, forLanguage...)
+	}
+	return requests, nil
+}
+
+// sampleKeys picks n of keys spread evenly over them, or all when n is 0 or
+// not smaller than len(keys).
+func sampleKeys(keys []string, n int) []string {
+	if n <= 0 || n >= len(keys) {
+		return keys
+	}
+	sample := make([]string, n)
+	for i := range sample {
+		sample[i] = keys[i*len(keys)/n]
+	}
+	return sample
+}
+
+// Compare sends every request to every service at once and collects their
+// translations side by side. Each service runs with its own concurrency,
+// retries, rate limit and QA rules, but bypasses its translation memory so
+// every candidate is fresh. Costs are estimated with prices.
+func Compare(ctx context.Context, requests []model.TranslationRequest, services []*TranslationService, prices PriceTable) *Comparison {
+	c := &Comparison{
+		GeneratedAt: time.Now().UTC(),
+		Rows:        make([]ComparisonRow, len(requests)),
+	}
+	for i, req := range requests {
+		c.SourceLanguage = req.SourceLanguage
+		c.Rows[i] = ComparisonRow{
+			Key:        req.Key,
+			Language:   req.TargetLanguage,
+			Path:       req.Path,
+			Source:     req.Text,
+			Comment:    req.Comment,
+			Candidates: make([]Candidate, len(services)),
+		}
+	}
+
+	var wg sync.WaitGroup
+	for j, s := range services {
+		c.Providers = append(c.Providers, s.Name)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.compare(ctx, requests, prices, func(i int, candidate Candidate) {
+				c.Rows[i].Candidates[j] = candidate
+			})
+		}()
+	}
+	wg.Wait()
+	return c
+}
+
+// compare translates requests for a comparison and hands each candidate to
+// record with the index of its request.
+func (s *TranslationService) compare(ctx context.Context, requests []model.TranslationRequest, prices PriceTable, record func(int, Candidate)) {
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	// Candidates must come from the provider, not the memory.
+	fresh := *s
+	fresh.Memory = nil
+	modelName := ModelName(s.Provider)
+	price, priced := prices.Lookup(s.Name, modelName)
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(s.Concurrency, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				req := fresh.annotate(requests[i : i+1])[0]
+				resp := fresh.translate(ctx, req)
+				candidate := Candidate{
+					Provider:  s.Name,
+					Model:     modelName,
+					LatencyMS: resp.Latency.Milliseconds(),
+					Warnings:  resp.Warnings,
+				}
+				if resp.Error != nil {
+					candidate.Error = resp.Error.Error()
+				} else {
+					candidate.Text = resp.TranslatedText
+				}
+				// Failed calls are not billed, but rejected translations are.
+				if priced && (resp.Error == nil || IsQAError(resp.Error)) {
+					cost := price.Cost(resp.Usage)
+					candidate.Cost = &cost
+				}
+				record(i, candidate)
+			}
+		}()
+	}
+	for i := range requests {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// PickBest makes the best candidate of every row its winner: one without an
+// error, then the one with the fewest QA warnings, the cheapest and the
+// fastest. Rows where every provider failed keep no winner.
+func (c *Comparison) PickBest() {
+	for i := range c.Rows {
+		row := &c.Rows[i]
+		row.Winner = ""
+		best := -1
+		for j, candidate := range row.Candidates {
+			if candidate.Error == "" && (best < 0 || candidate.beats(row.Candidates[best])) {
+				best = j
+			}
+		}
+		if best >= 0 {
+			row.Winner = row.Candidates[best].Provider
+		}
+	}
+}
+
+// beats reports whether c is a better pick than other; both succeeded.
+func (c Candidate) beats(other Candidate) bool {
+	if len(c.Warnings) != len(other.Warnings) {
+		return len(c.Warnings) < len(other.Warnings)
+	}
+	if c.Cost != nil && other.Cost != nil && *c.Cost != *other.Cost {
+		return *c.Cost < *other.Cost
+	}
+	return c.LatencyMS < other.LatencyMS
+}
+
+// Winners returns the picked candidates as responses for ApplyTranslations.
+func (c *Comparison) Winners() []model.TranslationResponse {
+	var responses []model.TranslationResponse
+	for _, row := range c.Rows {
+		for _, candidate := range row.Candidates {
+			if row.Winner == "" || candidate.Provider != row.Winner || candidate.Error != "" {
+				continue
+			}
+			responses = append(responses, model.TranslationResponse{
+				Key:            row.Key,
+				TargetLanguage: row.Language,
+				Path:           row.Path,
+				TranslatedText: candidate.Text,
+				Provider:       candidate.Provider,
+				Warnings:       candidate.Warnings,
+			})
+		}
+	}
+	return responses
+}
+
+// Summary totals the candidates of each provider.
+func (c *Comparison) Summary() []ProviderSummary {
+	summaries := make([]ProviderSummary, len(c.Providers))
+	latency := make([]int64, len(c.Providers))
+	for j, provider := range c.Providers {
+		summaries[j].Provider = provider
+	}
+	for _, row := range c.Rows {
+		for j, candidate := range row.Candidates {
+			summary := &summaries[j]
+			if candidate.Error != "" {
+				summary.Failed++
+			} else {
+				summary.Translated++
+			}
+			summary.Warnings += len(candidate.Warnings)
+			if row.Winner != "" && row.Winner == candidate.Provider {
+				summary.Wins++
+			}
+			latency[j] += candidate.LatencyMS
+			if candidate.Cost != nil {
+				if summary.Cost == nil {
+					summary.Cost = new(float64)
+				}
+				*summary.Cost += *candidate.Cost
+			}
+		}
+	}
+	if len(c.Rows) > 0 {
+		for j := range summaries {
+			summaries[j].AverageLatencyMS = latency[j] / int64(len(c.Rows))
+		}
+	}
+	return summaries
+}
+
+// WriteComparison writes c as "table" (plain text), "html" or "json".
+func WriteComparison(w io.Writer, c *Comparison, format string) error {
+	switch format {
+	case "", "table":
+		return writeComparisonTable(w, c)
+	case "html":
+		return comparisonTemplate.Execute(w, c)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			*Comparison
+			Summary []ProviderSummary `json:"summary"`
+		}{c, c.Summary()})
+	}
+	return fmt.Errorf("unknown comparison format %q (want table, html or json)", format)
+}
+
+func writeComparisonTable(w io.Writer, c *Comparison) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, row := range c.Rows {
+		fmt.Fprintf(tw, "%s [%s]: %s\n", comparisonUnit(row), row.Language, oneLine(row.Source))
+		for _, candidate := range row.Candidates {
+			mark := " "
+			if candidate.Provider == row.Winner {
+				mark = "*"
+			}
+			text := oneLine(candidate.Text)
+			if candidate.Error != "" {
+				text = "ERROR: " + candidate.Error
+			}
+			fmt.Fprintf(tw, "  %s %s\t%s\t%dms\t%s\n", mark, candidate.Provider, text, candidate.LatencyMS, formatCost(candidate.Cost))
+			for _, warning := range candidate.Warnings {
+				fmt.Fprintf(tw, "\t  QA %s\t\t\n", warning)
+			}
+		}
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "Provider\tTranslated\tFailed\tQA warnings\tPicked\tAvg latency\tEst. cost")
+	for _, summary := range c.Summary() {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%dms\t%s\n", summary.Provider, summary.Translated, summary.Failed,
+			summary.Warnings, summary.Wins, summary.AverageLatencyMS, formatCost(summary.Cost))
+	}
+	return tw.Flush()
+}
+
+// comparisonUnit names the unit of row, with its variation path if any.
+func comparisonUnit(row ComparisonRow) string {
+	if row.Path == "" {
+		return row.Key
+	}
+	return row.Key + " (" + row.Path + ")"
+}
+
+// oneLine keeps line breaks from breaking up table rows.
+func oneLine(text string) string {
+	return strings.ReplaceAll(text, "\n", `\n`)
+}
+
+func formatCost(cost *float64) string {
+	if cost == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.6f", *cost)
+}
+
+var comparisonTemplate = template.Must(template.New("comparison").Funcs(template.FuncMap{
+	"unit": comparisonUnit,
+	"cost": formatCost,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Provider comparison</title>
+<style>
+body { font-family: -apple-system, sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border: 1px solid #ddd; padding: 6px 8px; text-align: left; vertical-align: top; white-space: pre-wrap; }
+th { background: #f5f5f5; }
+.winner { background: #e8f5e9; }
+.error { color: #c62828; }
+.warning { color: #ef6c00; font-size: 0.9em; }
+.meta { color: #777; font-size: 0.85em; }
+</style>
+</head>
+<body>
+<h1>Provider comparison</h1>
+<p class="meta">Generated {{.GeneratedAt.Format "2006-01-02 15:04:05 MST"}} from {{.SourceLanguage}}.</p>
+<table>
+<tr><th>Provider</th><th>Translated</th><th>Failed</th><th>QA warnings</th><th>Picked</th><th>Avg latency</th><th>Est. cost</th></tr>
+{{range .Summary}}<tr><td>{{.Provider}}</td><td>{{.Translated}}</td><td>{{.Failed}}</td><td>{{.Warnings}}</td><td>{{.Wins}}</td><td>{{.AverageLatencyMS}}ms</td><td>{{cost .Cost}}</td></tr>
+{{end}}</table>
+<table>
+<tr><th>Key</th><th>Language</th><th>Source</th>{{range .Providers}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}{{$winner := .Winner}}<tr>
+<td>{{unit .}}{{if .Comment}}<div class="meta">{{.Comment}}</div>{{end}}</td>
+<td>{{.Language}}</td>
+<td>{{.Source}}</td>
+{{range .Candidates}}<td{{if and $winner (eq .Provider $winner)}} class="winner"{{end}}>
+{{if .Error}}<div class="error">{{.Error}}</div>{{else}}{{.Text}}{{end}}
+{{range .Warnings}}<div class="warning">{{.}}</div>{{end}}
+<div class="meta">{{.LatencyMS}}ms · {{cost .Cost}}</div>
+</td>{{end}}
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,49 @@
+package translator
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestComparisonRequestsSourceLanguage(t *testing.T) {
+	xcstrings := parseCatalog(t, `{
+  "settings.title": {"localizations": {
+    "en": {"stringUnit": {"state": "translated", "value": "Settings"}},
+    "fr": {"stringUnit": {"state": "translated", "value": "Réglages"}}
+  }},
+  "about.title": {"localizations": {
+    "en": {"stringUnit": {"state": "translated", "value": "About"}}
+  }}
+}`)
+
+	tests := []struct {
+		name   string
+		opts   CompareOptions
+		source string
+		want   []string
+	}{
+		{"catalog source", CompareOptions{Languages: []string{"de"}, KeyPatterns: []string{"settings.*"}}, "en", []string{"Settings"}},
+		{"overridden source", CompareOptions{SourceLanguage: "fr", Languages: []string{"de"}, KeyPatterns: []string{"settings.*"}}, "fr", []string{"Réglages"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, err := ComparisonRequests(xcstrings, tt.opts)
+			if err != nil {
+				t.Fatalf("ComparisonRequests: %v", err)
+			}
+			var texts []string
+			for _, req := range requests {
+				texts = append(texts, req.Text)
+				if req.SourceLanguage != tt.source {
+					t.Errorf("%s: source language %q, want %q", req.Key, req.SourceLanguage, tt.source)
+				}
+			}
+			if !slices.Equal(texts, tt.want) {
+				t.Errorf("texts = %q, want %q", texts, tt.want)
+			}
+		})
+	}
+	if xcstrings.SourceLanguage != "en" {
+		t.Errorf("catalog source language changed to %q", xcstrings.SourceLanguage)
+	}
+}
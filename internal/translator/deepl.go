@@ -17,6 +17,8 @@ type DeepLTranslator struct {
 	APIKey string
 	IsFree bool
 	Client *resty.Client
+	// Glossaries maps target languages to the DeepL glossary used for them.
+	Glossaries map[string]string
 }
 
 // DeepLTranslateRequest represents the request body for DeepL API
@@ -27,6 +29,9 @@ type DeepLTranslateRequest struct {
 	SplitSentences     string   `json:"split_sentences,omitempty"`
 	PreserveFormatting bool     `json:"preserve_formatting,omitempty"`
 	Formality          string   `json:"formality,omitempty"`
+	Context            string   `json:"context,omitempty"`
+	TagHandling        string   `json:"tag_handling,omitempty"`
+	GlossaryID         string   `json:"glossary_id,omitempty"`
 }
 
 // DeepLTranslateResponse represents the response from DeepL API
@@ -50,40 +55,110 @@ func NewDeepLTranslator(apiKey string, isFree bool) *DeepLTranslator {
 	}
 }
 
+// deeplBatchLimits follows the API's 50 texts per request and stays below
+// its 128 KiB request size.
+var deeplBatchLimits = model.BatchLimits{MaxItems: 50, MaxBytes: 120 * 1024, SharedContext: true}
+
 // Translate translates a string using DeepL API
 func (d *DeepLTranslator) Translate(ctx context.Context, req model.TranslationRequest) (model.TranslationResponse, error) {
+	texts, err := d.translateTexts(ctx, []string{req.Text}, req)
+	if err != nil {
+		return model.TranslationResponse{
+			Key:            req.Key,
+			TargetLanguage: req.TargetLanguage,
+			Error:          err,
+		}, nil
+	}
+
+	return model.TranslationResponse{
+		Key:            req.Key,
+		TargetLanguage: req.TargetLanguage,
+		TranslatedText: texts[0],
+	}, nil
+}
+
+// HandlesTags reports that DeepL keeps XML tags with tag_handling=xml.
+func (d *DeepLTranslator) HandlesTags() bool {
+	return true
+}
+
+// ModelName returns "": DeepL has no model choice.
+func (d *DeepLTranslator) ModelName() string {
+	return ""
+}
+
+// Fingerprint identifies the glossaries in use, if any.
+func (d *DeepLTranslator) Fingerprint() string {
+	if len(d.Glossaries) == 0 {
+		return ""
+	}
+	return hashFingerprint(sortedPairs(d.Glossaries)...)
+}
+
+// BatchLimits returns DeepL's limits per request.
+func (d *DeepLTranslator) BatchLimits() model.BatchLimits {
+	return deeplBatchLimits
+}
+
+// TranslateBatch translates several texts in one request. They share one
+// context, so the requests must have the same comment.
+func (d *DeepLTranslator) TranslateBatch(ctx context.Context, reqs []model.TranslationRequest) ([]model.TranslationResponse, error) {
+	texts := make([]string, len(reqs))
+	for i, req := range reqs {
+		texts[i] = req.Text
+	}
+
+	translated, err := d.translateTexts(ctx, texts, reqs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]model.TranslationResponse, len(reqs))
+	for i, req := range reqs {
+		responses[i] = model.TranslationResponse{
+			Key:            req.Key,
+			TargetLanguage: req.TargetLanguage,
+			TranslatedText: translated[i],
+		}
+	}
+	return responses, nil
+}
+
+// deeplLang converts language codes to DeepL format (e.g., zh-Hans -> ZH).
+func deeplLang(lang string) string {
+	lang = strings.ToUpper(lang)
+	switch lang {
+	case "ZH-HANS":
+		return "ZH"
+	case "ZH-HANT":
+		return "ZH-TW"
+	}
+	return lang
+}
+
+// translateTexts sends texts to DeepL in one request and returns their
+// translations in order. The languages, comment and masking come from req,
+// and the glossary from the target language.
+func (d *DeepLTranslator) translateTexts(ctx context.Context, texts []string, req model.TranslationRequest) ([]string, error) {
 	baseURL := "https://api.deepl.com"
 	if d.IsFree {
 		baseURL = "https://api-free.deepl.com"
 	}
 	apiURL := fmt.Sprintf("%s/v2/translate", baseURL)
 
-	// Convert language codes to DeepL format (e.g., zh-Hans -> ZH)
-	targetLang := strings.ToUpper(req.TargetLanguage)
-	if strings.HasPrefix(targetLang, "ZH-") {
-		if targetLang == "ZH-HANS" {
-			targetLang = "ZH"
-		} else if targetLang == "ZH-HANT" {
-			targetLang = "ZH-TW"
-		}
-	}
-
 	requestBody := DeepLTranslateRequest{
-		Text:       []string{req.Text},
-		TargetLang: targetLang,
+		Text:       texts,
+		TargetLang: deeplLang(req.TargetLanguage),
 		Formality:  "default",
+		Context:    req.Comment,
 	}
-
 	if req.SourceLanguage != "" {
-		sourceLang := strings.ToUpper(req.SourceLanguage)
-		if strings.HasPrefix(sourceLang, "ZH-") {
-			if sourceLang == "ZH-HANS" {
-				sourceLang = "ZH"
-			} else if sourceLang == "ZH-HANT" {
-				sourceLang = "ZH-TW"
-			}
-		}
-		requestBody.SourceLang = sourceLang
+		requestBody.SourceLang = deeplLang(req.SourceLanguage)
+		// DeepL only applies a glossary when the source language is given.
+		requestBody.GlossaryID = d.Glossaries[req.TargetLanguage]
+	}
+	if req.Masked {
+		requestBody.TagHandling = "xml"
 	}
 
 	resp, err := d.Client.R().
@@ -92,42 +167,28 @@ func (d *DeepLTranslator) Translate(ctx context.Context, req model.TranslationRe
 		Post(apiURL)
 
 	if err != nil {
-		return model.TranslationResponse{
-			Key:            req.Key,
-			TargetLanguage: req.TargetLanguage,
-			Error:          fmt.Errorf("request failed: %v", err),
-		}, nil
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 
 	if resp.StatusCode() != http.StatusOK {
-		return model.TranslationResponse{
-			Key:            req.Key,
-			TargetLanguage: req.TargetLanguage,
-			Error:          fmt.Errorf("API request failed with status code: %d, response: %s", resp.StatusCode(), resp.String()),
-		}, nil
+		return nil, newAPIError(resp)
 	}
 
 	var translationResponse DeepLTranslateResponse
-	err = json.Unmarshal(resp.Body(), &translationResponse)
-	if err != nil {
-		return model.TranslationResponse{
-			Key:            req.Key,
-			TargetLanguage: req.TargetLanguage,
-			Error:          fmt.Errorf("failed to parse response: %v", err),
-		}, nil
+	if err := json.Unmarshal(resp.Body(), &translationResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %v", err)
 	}
 
 	if len(translationResponse.Translations) == 0 {
-		return model.TranslationResponse{
-			Key:            req.Key,
-			TargetLanguage: req.TargetLanguage,
-			Error:          fmt.Errorf("no translation results"),
-		}, nil
+		return nil, fmt.Errorf("no translation results")
+	}
+	if len(translationResponse.Translations) != len(texts) {
+		return nil, fmt.Errorf("got %d translations for %d texts", len(translationResponse.Translations), len(texts))
 	}
 
-	return model.TranslationResponse{
-		Key:            req.Key,
-		TargetLanguage: req.TargetLanguage,
-		TranslatedText: translationResponse.Translations[0].Text,
-	}, nil
+	translated := make([]string, len(texts))
+	for i, t := range translationResponse.Translations {
+		translated[i] = t.Text
+	}
+	return translated, nil
 }
@@ -0,0 +1,239 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// APIError is returned when a provider answers with an error, either through the
+// HTTP status code or through an error code in an otherwise successful response.
+type APIError struct {
+	StatusCode int
+	// Code is the provider-specific error code, e.g. Baidu's "54003".
+	Code    string
+	Message string
+	// RetryAfter is the delay requested by the Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("API error: %s - %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("API request failed with status code: %d, response: %s", e.StatusCode, e.Message)
+}
+
+// newAPIError builds an APIError from a non-success HTTP response.
+func newAPIError(resp *resty.Response) *APIError {
+	return &APIError{
+		StatusCode: resp.StatusCode(),
+		Message:    resp.String(),
+		RetryAfter: parseRetryAfter(resp.Header().Get("Retry-After"), time.Now()),
+	}
+}
+
+// parseRetryAfter understands both forms of the Retry-After header: a number of
+// seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := at.Sub(now); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+// retryableStatusCodes are HTTP statuses that signal a transient condition.
+var retryableStatusCodes = map[int]bool{
+	http.StatusRequestTimeout:      true,
+	http.StatusTooEarly:            true,
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+	529:                            true, // overloaded, used by some LLM gateways
+}
+
+// retryableAPICodes are provider error codes that signal a transient condition:
+// Baidu's timeout, system error and access frequency limits.
+var retryableAPICodes = map[string]bool{
+	"52001": true,
+	"52002": true,
+	"54003": true,
+	"54005": true,
+}
+
+// IsRetryable reports whether a failed translation is worth attempting again.
+// Rate limits, server errors and network failures are retryable; authentication,
+// quota, validation errors and cancellation are not.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.Code != "" {
+			return retryableAPICodes[apiErr.Code]
+		}
+		// OpenAI reports an exhausted balance as 429 too; waiting will not help.
+		if strings.Contains(apiErr.Message, "insufficient_quota") {
+			return false
+		}
+		return retryableStatusCodes[apiErr.StatusCode]
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// retryAfter returns the server-requested delay carried by err, if any.
+func retryAfter(err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
+
+// Error classes reported by ClassifyError.
+const (
+	ErrorClassRateLimit   = "rate_limit"
+	ErrorClassQuota       = "quota"
+	ErrorClassAuth        = "auth"
+	ErrorClassClient      = "client"
+	ErrorClassServer      = "server"
+	ErrorClassNetwork     = "network"
+	ErrorClassTimeout     = "timeout"
+	ErrorClassCanceled    = "canceled"
+	ErrorClassValidation  = "validation"
+	ErrorClassUnsupported = "unsupported"
+	ErrorClassBudget      = "budget"
+	ErrorClassUnknown     = "unknown"
+)
+
+// ErrorClasses lists every class ClassifyError returns.
+var ErrorClasses = []string{
+	ErrorClassRateLimit, ErrorClassQuota, ErrorClassAuth, ErrorClassClient, ErrorClassServer, ErrorClassNetwork,
+	ErrorClassTimeout, ErrorClassCanceled, ErrorClassValidation, ErrorClassUnsupported, ErrorClassBudget, ErrorClassUnknown,
+}
+
+// apiCodeClasses maps provider error codes to error classes (Baidu's codes).
+var apiCodeClasses = map[string]string{
+	"52001": ErrorClassTimeout,
+	"52002": ErrorClassServer,
+	"52003": ErrorClassAuth,
+	"54000": ErrorClassClient,
+	"54001": ErrorClassAuth,
+	"54003": ErrorClassRateLimit,
+	"54004": ErrorClassQuota,
+	"54005": ErrorClassRateLimit,
+	"58000": ErrorClassAuth,
+	"58001": ErrorClassUnsupported,
+	"58002": ErrorClassAuth,
+	"90107": ErrorClassAuth,
+}
+
+// ClassifyError sorts a translation failure into one of the ErrorClass values
+// so reports can be grouped and filtered without parsing provider messages.
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, ErrDroppedPlaceholders) || errors.Is(err, ErrDuplicatedPlaceholders) || IsQAError(err) {
+		return ErrorClassValidation
+	}
+	if errors.Is(err, ErrBudgetExceeded) {
+		return ErrorClassBudget
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrorClassCanceled
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTimeout
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.Code != "" {
+			if class, ok := apiCodeClasses[apiErr.Code]; ok {
+				return class
+			}
+			return ErrorClassUnknown
+		}
+		switch {
+		case strings.Contains(apiErr.Message, "insufficient_quota"), apiErr.StatusCode == 456: // DeepL quota exceeded
+			return ErrorClassQuota
+		case apiErr.StatusCode == http.StatusTooManyRequests:
+			return ErrorClassRateLimit
+		case apiErr.StatusCode == http.StatusUnauthorized, apiErr.StatusCode == http.StatusForbidden:
+			return ErrorClassAuth
+		case apiErr.StatusCode == http.StatusRequestTimeout, apiErr.StatusCode == http.StatusGatewayTimeout:
+			return ErrorClassTimeout
+		case apiErr.StatusCode >= 500:
+			return ErrorClassServer
+		case apiErr.StatusCode == http.StatusBadRequest && unsupportedLanguage(apiErr.Message):
+			return ErrorClassUnsupported
+		case apiErr.StatusCode >= 400:
+			return ErrorClassClient
+		}
+		return ErrorClassUnknown
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorClassTimeout
+		}
+		return ErrorClassNetwork
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return ErrorClassNetwork
+	}
+	return ErrorClassUnknown
+}
+
+// unsupportedLanguageHints are how DeepL and Google word a language they
+// cannot translate, e.g. "Value for 'target_lang' not supported." or
+// "Target language is invalid".
+var unsupportedLanguageHints = []string{"not supported", "unsupported", "language is invalid", "bad language pair"}
+
+// unsupportedLanguage reports whether a 400 response body rejects the language.
+func unsupportedLanguage(message string) bool {
+	message = strings.ToLower(message)
+	if !strings.Contains(message, "lang") {
+		return false
+	}
+	for _, hint := range unsupportedLanguageHints {
+		if strings.Contains(message, hint) {
+			return true
+		}
+	}
+	return false
+}
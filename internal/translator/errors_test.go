@@ -0,0 +1,118 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"rate limited", &APIError{StatusCode: 429}, true},
+		{"server error", &APIError{StatusCode: 500}, true},
+		{"bad gateway", &APIError{StatusCode: 502}, true},
+		{"unavailable", &APIError{StatusCode: 503}, true},
+		{"gateway timeout", &APIError{StatusCode: 504}, true},
+		{"overloaded", &APIError{StatusCode: 529}, true},
+		{"request timeout", &APIError{StatusCode: 408}, true},
+		{"bad request", &APIError{StatusCode: 400}, false},
+		{"unauthorized", &APIError{StatusCode: 401}, false},
+		{"forbidden", &APIError{StatusCode: 403}, false},
+		{"deepl quota", &APIError{StatusCode: 456}, false},
+		{"openai quota", &APIError{StatusCode: 429, Message: `{"error":{"code":"insufficient_quota"}}`}, false},
+		{"baidu frequency limit", &APIError{Code: "54003"}, true},
+		{"baidu timeout", &APIError{Code: "52001"}, true},
+		{"baidu bad signature", &APIError{Code: "54001"}, false},
+		{"baidu unknown code", &APIError{StatusCode: 500, Code: "99999"}, false},
+		{"wrapped", fmt.Errorf("openai: %w", &APIError{StatusCode: 503}), true},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"unexpected eof", io.ErrUnexpectedEOF, true},
+		{"eof", fmt.Errorf("read body: %w", io.EOF), true},
+		{"canceled", context.Canceled, false},
+		{"deadline", fmt.Errorf("call: %w", context.DeadlineExceeded), false},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"   ", 0},
+		{"0", 0},
+		{"5", 5 * time.Second},
+		{" 120 ", 2 * time.Minute},
+		{"-3", 0},
+		{"1.5", 0},
+		{"soon", 0},
+		{"Sat, 01 Mar 2025 12:00:30 GMT", 30 * time.Second},
+		{"Saturday, 01-Mar-25 12:01:00 GMT", time.Minute},
+		{"Sat Mar  1 12:00:10 2025", 10 * time.Second},
+		{"Sat, 01 Mar 2025 11:59:00 GMT", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"rate limited", &APIError{StatusCode: 429}, ErrorClassRateLimit},
+		{"openai quota", &APIError{StatusCode: 429, Message: `{"error":{"code":"insufficient_quota"}}`}, ErrorClassQuota},
+		{"deepl quota", &APIError{StatusCode: 456}, ErrorClassQuota},
+		{"unauthorized", &APIError{StatusCode: 401}, ErrorClassAuth},
+		{"forbidden", &APIError{StatusCode: 403}, ErrorClassAuth},
+		{"request timeout", &APIError{StatusCode: 408}, ErrorClassTimeout},
+		{"server error", &APIError{StatusCode: 502}, ErrorClassServer},
+		{"bad request", &APIError{StatusCode: 400, Message: "missing text"}, ErrorClassClient},
+		{"not found", &APIError{StatusCode: 404}, ErrorClassClient},
+		{"deepl unsupported language", &APIError{StatusCode: 400, Message: `{"message":"Value for 'target_lang' not supported."}`}, ErrorClassUnsupported},
+		{"google unsupported language", &APIError{StatusCode: 400, Message: "Target language is invalid."}, ErrorClassUnsupported},
+		{"baidu frequency limit", &APIError{Code: "54003"}, ErrorClassRateLimit},
+		{"baidu balance", &APIError{Code: "54004"}, ErrorClassQuota},
+		{"baidu unsupported language", &APIError{Code: "58001"}, ErrorClassUnsupported},
+		{"baidu unknown code", &APIError{Code: "99999"}, ErrorClassUnknown},
+		{"wrapped", fmt.Errorf("deepl: %w", &APIError{StatusCode: 503}), ErrorClassServer},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, ErrorClassNetwork},
+		{"eof", io.ErrUnexpectedEOF, ErrorClassNetwork},
+		{"canceled", context.Canceled, ErrorClassCanceled},
+		{"deadline", fmt.Errorf("call: %w", context.DeadlineExceeded), ErrorClassTimeout},
+		{"dropped placeholders", fmt.Errorf("%w %q", ErrDroppedPlaceholders, "%@"), ErrorClassValidation},
+		{"qa", &QAError{}, ErrorClassValidation},
+		{"budget", fmt.Errorf("stopped: %w", ErrBudgetExceeded), ErrorClassBudget},
+		{"other", errors.New("boom"), ErrorClassUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
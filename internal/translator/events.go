@@ -0,0 +1,183 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// EventKind is a step in the life of a translation request.
+type EventKind string
+
+const (
+	// EventQueued: TranslateBatch accepted the request.
+	EventQueued EventKind = "queued"
+	// EventStarted: the request is being sent to a provider.
+	EventStarted EventKind = "started"
+	// EventRetrying: a call failed and is tried again after Delay.
+	EventRetrying EventKind = "retrying"
+	// EventSucceeded and EventFailed: the request is finished.
+	EventSucceeded EventKind = "succeeded"
+	EventFailed    EventKind = "failed"
+)
+
+// Event reports a step of one request, identified by its key, language and
+// path.
+type Event struct {
+	Kind     EventKind
+	Time     time.Time
+	Key      string
+	Language string
+	Path     string
+	// Provider is the provider the request was started, retried or finished on.
+	Provider string
+	// Attempt is the number of the provider call being made: 1 when started,
+	// and the next attempt when retrying.
+	Attempt int
+	// Delay is the backoff before the next attempt, and Err the error that
+	// caused it or that the request failed with.
+	Delay time.Duration
+	Err   error
+	// Response is the outcome of a finished request, carrying its usage and
+	// latency.
+	Response *model.TranslationResponse
+	// Done counts the requests of the batch finished so far, out of Total.
+	Done  int
+	Total int
+}
+
+// EventSubscriber receives the events of a TranslationService.
+type EventSubscriber interface {
+	HandleEvent(Event)
+}
+
+// EventFunc adapts a function to EventSubscriber.
+type EventFunc func(Event)
+
+// HandleEvent calls f.
+func (f EventFunc) HandleEvent(event Event) {
+	f(event)
+}
+
+// EventBus delivers events to its subscribers one at a time, in the order
+// they happen, so subscribers need no locking of their own; they must not
+// block. Share it across a fallback chain. A nil EventBus drops every event.
+type EventBus struct {
+	mu          sync.Mutex
+	subscribers []EventSubscriber
+}
+
+// NewEventBus returns a bus delivering to subscribers.
+func NewEventBus(subscribers ...EventSubscriber) *EventBus {
+	return &EventBus{subscribers: subscribers}
+}
+
+// Subscribe adds subscriber to the bus.
+func (b *EventBus) Subscribe(subscriber EventSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, subscriber)
+}
+
+// emit stamps event with the current time and delivers it.
+func (b *EventBus) emit(event Event) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	event.Time = time.Now()
+	for _, subscriber := range b.subscribers {
+		subscriber.HandleEvent(event)
+	}
+}
+
+// emitRequest emits an event of kind for req.
+func (b *EventBus) emitRequest(kind EventKind, req model.TranslationRequest, event Event) {
+	event.Kind = kind
+	event.Key = req.Key
+	event.Language = req.TargetLanguage
+	event.Path = req.Path
+	b.emit(event)
+}
+
+// NewProgressPrinter returns a subscriber that writes coarse progress per
+// language to w, roughly every 10% of a batch, and every retry.
+func NewProgressPrinter(w io.Writer) EventSubscriber {
+	return EventFunc(func(event Event) {
+		switch event.Kind {
+		case EventRetrying:
+			fmt.Fprintf(w, "  [%s] retrying %s on %s, attempt %d in %s: %v\n",
+				event.Language, event.Key, event.Provider, event.Attempt, event.Delay.Round(time.Millisecond), event.Err)
+		case EventSucceeded, EventFailed:
+			step := max(event.Total/10, 1)
+			if event.Done != event.Total && event.Done%step != 0 {
+				return
+			}
+			status := "ok"
+			if event.Kind == EventFailed {
+				status = "err"
+			}
+			fmt.Fprintf(w, "  [%s] %d/%d (%s)\n", event.Language, event.Done, event.Total, status)
+		}
+	})
+}
+
+// eventRecord is an Event as written to an event log.
+type eventRecord struct {
+	Kind             EventKind `json:"kind"`
+	Time             time.Time `json:"time"`
+	Key              string    `json:"key"`
+	Language         string    `json:"language"`
+	Path             string    `json:"path,omitempty"`
+	Provider         string    `json:"provider,omitempty"`
+	Model            string    `json:"model,omitempty"`
+	Attempt          int       `json:"attempt,omitempty"`
+	DelayMS          int64     `json:"delayMs,omitempty"`
+	Class            string    `json:"class,omitempty"`
+	Error            string    `json:"error,omitempty"`
+	Cached           bool      `json:"cached,omitempty"`
+	Characters       int       `json:"characters,omitempty"`
+	PromptTokens     int       `json:"promptTokens,omitempty"`
+	CompletionTokens int       `json:"completionTokens,omitempty"`
+	LatencyMS        int64     `json:"latencyMs,omitempty"`
+	Done             int       `json:"done,omitempty"`
+	Total            int       `json:"total,omitempty"`
+}
+
+// NewEventLog returns a subscriber that writes every event to w as a line
+// of JSON. Write errors are dropped; the log must not stop a run.
+func NewEventLog(w io.Writer) EventSubscriber {
+	encoder := json.NewEncoder(w)
+	return EventFunc(func(event Event) {
+		record := eventRecord{
+			Kind:     event.Kind,
+			Time:     event.Time.UTC(),
+			Key:      event.Key,
+			Language: event.Language,
+			Path:     event.Path,
+			Provider: event.Provider,
+			Attempt:  event.Attempt,
+			DelayMS:  event.Delay.Milliseconds(),
+			Done:     event.Done,
+			Total:    event.Total,
+		}
+		if event.Err != nil {
+			record.Class = ClassifyError(event.Err)
+			record.Error = event.Err.Error()
+		}
+		if resp := event.Response; resp != nil {
+			record.Model = resp.Model
+			record.Cached = resp.Cached
+			record.Characters = resp.Usage.Characters
+			record.PromptTokens = resp.Usage.PromptTokens
+			record.CompletionTokens = resp.Usage.CompletionTokens
+			record.LatencyMS = resp.Latency.Milliseconds()
+		}
+		encoder.Encode(record)
+	})
+}
@@ -0,0 +1,201 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// eventRecorder collects the events it receives.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *eventRecorder) HandleEvent(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// kinds lists the kinds of the events of key, in order.
+func (r *eventRecorder) kinds(key string) []EventKind {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var kinds []EventKind
+	for _, event := range r.events {
+		if event.Key == key {
+			kinds = append(kinds, event.Kind)
+		}
+	}
+	return kinds
+}
+
+func TestTranslateBatchEvents(t *testing.T) {
+	memory := openTestMemory(t)
+	quota := &APIError{StatusCode: 456, Message: "Quota exceeded"}
+
+	tests := []struct {
+		name  string
+		setup func(service *TranslationService, provider *fakeProvider)
+		want  []EventKind
+	}{
+		{"succeeded", nil, []EventKind{EventQueued, EventStarted, EventSucceeded}},
+		{"failed", func(service *TranslationService, provider *fakeProvider) {
+			provider.translate = func(req model.TranslationRequest) (string, error) {
+				return "", &APIError{StatusCode: 403}
+			}
+		}, []EventKind{EventQueued, EventStarted, EventFailed}},
+		{"retried", func(service *TranslationService, provider *fakeProvider) {
+			service.Retry = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+			provider.translate = func(req model.TranslationRequest) (string, error) {
+				if provider.calls() == 1 {
+					return "", &APIError{StatusCode: 503}
+				}
+				return "Hallo", nil
+			}
+		}, []EventKind{EventQueued, EventStarted, EventRetrying, EventSucceeded}},
+		{"from memory", func(service *TranslationService, provider *fakeProvider) {
+			service.Memory = memory.Scope("fake", provider)
+			service.Memory.Store(request("", "Hello", "de"), "Hallo")
+		}, []EventKind{EventQueued, EventSucceeded}},
+		{"fallen back", func(service *TranslationService, provider *fakeProvider) {
+			provider.translate = func(req model.TranslationRequest) (string, error) { return "", quota }
+			service.Fallback = newTestService(&fakeProvider{})
+			service.Fallback.Name = "secondary"
+			service.Fallback.Events = service.Events
+		}, []EventKind{EventQueued, EventStarted, EventStarted, EventSucceeded}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &eventRecorder{}
+			provider := &fakeProvider{}
+			service := newTestService(provider)
+			service.Events = NewEventBus(recorder)
+			if tt.setup != nil {
+				tt.setup(service, provider)
+			}
+			service.TranslateBatch(context.Background(), []model.TranslationRequest{request("hello", "Hello", "de")})
+
+			if got := recorder.kinds("hello"); !slices.Equal(got, tt.want) {
+				t.Errorf("events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslateBatchEventOrder(t *testing.T) {
+	recorder := &eventRecorder{}
+	service := newTestService(&fakeProvider{})
+	service.Concurrency = 4
+	service.Events = NewEventBus(recorder)
+	var requests []model.TranslationRequest
+	for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		requests = append(requests, request(key, "Text "+key, "de"))
+	}
+	// A duplicate is queued and finished, but never started.
+	requests = append(requests, request("dup", "Text a", "de"))
+
+	if _, err := service.TranslateBatch(context.Background(), requests); err != nil {
+		t.Fatalf("TranslateBatch: %v", err)
+	}
+
+	// Every request is queued before any is started.
+	firstStarted := slices.IndexFunc(recorder.events, func(e Event) bool { return e.Kind == EventStarted })
+	if queued := recorder.events[:firstStarted]; len(queued) != len(requests) {
+		t.Errorf("%d events before the first start, want %d queued", len(queued), len(requests))
+	}
+	for _, req := range requests {
+		want := []EventKind{EventQueued, EventStarted, EventSucceeded}
+		if req.Key == "dup" {
+			want = []EventKind{EventQueued, EventSucceeded}
+		}
+		if got := recorder.kinds(req.Key); !slices.Equal(got, want) {
+			t.Errorf("%s: events = %v, want %v", req.Key, got, want)
+		}
+	}
+
+	// Done counts up to Total one finished request at a time.
+	var done []int
+	for _, event := range recorder.events {
+		if event.Kind == EventSucceeded {
+			done = append(done, event.Done)
+			if event.Total != len(requests) {
+				t.Errorf("Total = %d, want %d", event.Total, len(requests))
+			}
+		}
+		if event.Time.IsZero() {
+			t.Errorf("%s event of %s has no time", event.Kind, event.Key)
+		}
+	}
+	if want := []int{1, 2, 3, 4, 5, 6, 7}; !slices.Equal(done, want) {
+		t.Errorf("Done = %v, want %v", done, want)
+	}
+}
+
+func TestEventBusFanOut(t *testing.T) {
+	first, second := &eventRecorder{}, &eventRecorder{}
+	bus := NewEventBus(first)
+	bus.Subscribe(second)
+	var third []EventKind
+	bus.Subscribe(EventFunc(func(event Event) { third = append(third, event.Kind) }))
+
+	bus.emitRequest(EventQueued, request("a", "A", "de"), Event{})
+	bus.emitRequest(EventStarted, request("a", "A", "de"), Event{Provider: "deepl", Attempt: 1})
+
+	want := []EventKind{EventQueued, EventStarted}
+	for i, got := range [][]EventKind{first.kinds("a"), second.kinds("a"), third} {
+		if !slices.Equal(got, want) {
+			t.Errorf("subscriber %d got %v, want %v", i+1, got, want)
+		}
+	}
+	if event := second.events[1]; event.Provider != "deepl" || event.Language != "de" || event.Attempt != 1 {
+		t.Errorf("second event = %+v", event)
+	}
+
+	var nilBus *EventBus
+	nilBus.emit(Event{Kind: EventQueued})
+}
+
+func TestNewEventLog(t *testing.T) {
+	var out bytes.Buffer
+	bus := NewEventBus(NewEventLog(&out))
+	resp := model.TranslationResponse{Key: "a", TargetLanguage: "de", Model: "gpt-4o", Usage: model.Usage{Characters: 4, PromptTokens: 101}, Latency: 20 * time.Millisecond}
+	bus.emit(Event{Kind: EventRetrying, Key: "a", Language: "de", Provider: "openai", Attempt: 2, Delay: time.Second, Err: &APIError{StatusCode: 429}})
+	bus.emit(Event{Kind: EventSucceeded, Key: "a", Language: "de", Provider: "openai", Response: &resp, Done: 1, Total: 1})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("event log has %d lines, want 2:\n%s", len(lines), out.String())
+	}
+	var retrying, succeeded eventRecord
+	json.Unmarshal([]byte(lines[0]), &retrying)
+	json.Unmarshal([]byte(lines[1]), &succeeded)
+	if retrying.Kind != EventRetrying || retrying.Attempt != 2 || retrying.DelayMS != 1000 || retrying.Class != ErrorClassRateLimit {
+		t.Errorf("retrying = %+v", retrying)
+	}
+	if succeeded.Model != "gpt-4o" || succeeded.Characters != 4 || succeeded.PromptTokens != 101 || succeeded.LatencyMS != 20 || succeeded.Done != 1 {
+		t.Errorf("succeeded = %+v", succeeded)
+	}
+}
+
+func TestNewProgressPrinter(t *testing.T) {
+	var out bytes.Buffer
+	printer := NewProgressPrinter(&out)
+	for done := 1; done <= 20; done++ {
+		printer.HandleEvent(Event{Kind: EventSucceeded, Language: "de", Done: done, Total: 20})
+	}
+	printer.HandleEvent(Event{Kind: EventStarted, Language: "de"})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 10 || lines[9] != "  [de] 20/20 (ok)" {
+		t.Errorf("progress =\n%s\nwant a line every 2 requests", out.String())
+	}
+}
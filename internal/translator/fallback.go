@@ -0,0 +1,61 @@
+package translator
+
+import (
+	"context"
+	"slices"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// DefaultFallbackOn lists the error classes a request falls back on unless
+// configured otherwise: the next provider cannot do worse when the first is
+// out of quota or does not support the language.
+var DefaultFallbackOn = []string{ErrorClassQuota, ErrorClassUnsupported}
+
+// fallsBack reports whether a request that failed with err should be passed
+// to the fallback service.
+func (s *TranslationService) fallsBack(err error) bool {
+	if s.Fallback == nil || err == nil {
+		return false
+	}
+	on := s.FallbackOn
+	if len(on) == 0 {
+		on = DefaultFallbackOn
+	}
+	return slices.Contains(on, ClassifyError(err))
+}
+
+// fallBack translates the requests of job whose responses failed with a
+// fallback error class again with the fallback service, packed to its own
+// batch limits, and puts its responses in their place.
+func (s *TranslationService) fallBack(ctx context.Context, job []model.TranslationRequest, responses []model.TranslationResponse) []model.TranslationResponse {
+	if s.Fallback == nil {
+		return responses
+	}
+
+	requests := make(map[unitID]model.TranslationRequest, len(job))
+	for _, req := range job {
+		requests[unitID{req.Key, req.TargetLanguage, req.Path}] = req
+	}
+	failed := map[unitID]int{}
+	var retry []model.TranslationRequest
+	for i, resp := range responses {
+		id := unitID{resp.Key, resp.TargetLanguage, resp.Path}
+		if req, ok := requests[id]; ok && s.fallsBack(resp.Error) {
+			failed[id] = i
+			retry = append(retry, req)
+		}
+	}
+	if len(retry) == 0 || ctx.Err() != nil {
+		return responses
+	}
+
+	for _, fallbackJob := range s.Fallback.packBatches(retry) {
+		for _, resp := range s.Fallback.translateJob(ctx, fallbackJob) {
+			if i, ok := failed[unitID{resp.Key, resp.TargetLanguage, resp.Path}]; ok {
+				responses[i] = resp
+			}
+		}
+	}
+	return responses
+}
@@ -0,0 +1,148 @@
+package translator
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// classifiedErrors fails each text with the error it maps to.
+func classifiedErrors(errs map[string]error) func(req model.TranslationRequest) (string, error) {
+	return func(req model.TranslationRequest) (string, error) {
+		if err, ok := errs[req.Text]; ok {
+			return "", err
+		}
+		return "[" + req.TargetLanguage + "] " + req.Text, nil
+	}
+}
+
+func TestFallback(t *testing.T) {
+	// Each text fails on the primary provider with an error of one class.
+	errs := map[string]error{
+		"Quota":       &APIError{StatusCode: 456, Message: "Quota exceeded"},
+		"Unsupported": &APIError{StatusCode: 400, Message: "Value for 'target_lang' not supported."},
+		"Rate limit":  &APIError{StatusCode: 429},
+		"Server":      &APIError{StatusCode: 503},
+		"Auth":        &APIError{StatusCode: 403},
+	}
+	texts := append(slices.Sorted(maps.Keys(errs)), "Fine")
+
+	tests := []struct {
+		name string
+		on   []string
+		// want maps each text to the provider that served it; missing texts failed.
+		want map[string]string
+	}{
+		{"default classes", nil, map[string]string{
+			"Fine": "primary", "Quota": "secondary", "Unsupported": "secondary",
+		}},
+		{"configured classes", []string{ErrorClassRateLimit, ErrorClassServer}, map[string]string{
+			"Fine": "primary", "Rate limit": "secondary", "Server": "secondary",
+		}},
+		{"auth", []string{ErrorClassAuth}, map[string]string{
+			"Fine": "primary", "Auth": "secondary",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secondary := &fakeProvider{}
+			service := newTestService(&fakeProvider{translate: classifiedErrors(errs)})
+			service.Name = "primary"
+			service.ContinueOnError = true
+			service.FallbackOn = tt.on
+			service.Fallback = newTestService(secondary)
+			service.Fallback.Name = "secondary"
+
+			var requests []model.TranslationRequest
+			for _, text := range texts {
+				requests = append(requests, request(text, text, "de"))
+			}
+			responses, err := service.TranslateBatch(context.Background(), requests)
+			if err != nil {
+				t.Fatalf("TranslateBatch: %v", err)
+			}
+
+			got := map[string]string{}
+			for _, resp := range responses {
+				if resp.Error == nil {
+					got[resp.Key] = resp.Provider
+				}
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("served by %v, want %v", got, tt.want)
+			}
+			if secondary.calls() != len(tt.want)-1 {
+				t.Errorf("secondary got %d calls, want %d", secondary.calls(), len(tt.want)-1)
+			}
+		})
+	}
+}
+
+func TestFallbackChain(t *testing.T) {
+	quota := &APIError{StatusCode: 456, Message: "Quota exceeded"}
+	first := newTestService(&fakeProvider{translate: classifiedErrors(map[string]error{"a": quota, "b": quota})})
+	first.Name = "first"
+	first.ContinueOnError = true
+	second := newTestService(&fakeProvider{translate: classifiedErrors(map[string]error{"b": quota})})
+	second.Name = "second"
+	first.Fallback = second
+	second.Fallback = newTestService(&fakeProvider{})
+	second.Fallback.Name = "third"
+
+	responses, err := first.TranslateBatch(context.Background(), []model.TranslationRequest{request("a", "a", "de"), request("b", "b", "de")})
+	if err != nil {
+		t.Fatalf("TranslateBatch: %v", err)
+	}
+	got := map[string]string{}
+	for _, resp := range responses {
+		got[resp.Key] = resp.Provider
+	}
+	if want := map[string]string{"a": "second", "b": "third"}; !maps.Equal(got, want) {
+		t.Errorf("served by %v, want %v", got, want)
+	}
+}
+
+func TestFallbackFailureKeepsLastError(t *testing.T) {
+	quota := &APIError{StatusCode: 456, Message: "Quota exceeded"}
+	service := newTestService(&fakeProvider{translate: classifiedErrors(map[string]error{"a": quota})})
+	service.ContinueOnError = true
+	service.Fallback = newTestService(&fakeProvider{translate: classifiedErrors(map[string]error{"a": &APIError{StatusCode: 403}})})
+	service.Fallback.Name = "secondary"
+
+	responses, err := service.TranslateBatch(context.Background(), []model.TranslationRequest{request("a", "a", "de")})
+	if err != nil {
+		t.Fatalf("TranslateBatch: %v", err)
+	}
+	if got := ClassifyError(responses[0].Error); got != ErrorClassAuth || responses[0].Provider != "secondary" {
+		t.Errorf("response = %s from %q, want the fallback's auth error", got, responses[0].Provider)
+	}
+}
+
+func TestFallbackBatches(t *testing.T) {
+	quota := &APIError{StatusCode: 456, Message: "Quota exceeded"}
+	primary := &fakeBatchProvider{limits: model.BatchLimits{MaxItems: 10}, respond: func(reqs []model.TranslationRequest) ([]model.TranslationResponse, error) {
+		return nil, quota
+	}}
+	secondary := &fakeBatchProvider{limits: model.BatchLimits{MaxItems: 2}}
+	service := newTestService(primary)
+	service.Fallback = newTestService(secondary)
+	service.Fallback.Name = "secondary"
+
+	requests := []model.TranslationRequest{request("a", "One", "de"), request("b", "Two", "de"), request("c", "Three", "de")}
+	responses, err := service.TranslateBatch(context.Background(), requests)
+	if err != nil {
+		t.Fatalf("TranslateBatch: %v", err)
+	}
+	if got := translations(responses); len(got) != 3 || got["c"] != "[de] Three" {
+		t.Errorf("translations = %v", got)
+	}
+	// The fallback packs the failed requests to its own limits: a batch of
+	// two, then the last one alone.
+	if len(primary.batches) != 1 || len(secondary.batches) != 1 || secondary.calls() != 1 {
+		t.Errorf("primary got %d batches, secondary %d batches and %d single calls; want 1, 1 and 1",
+			len(primary.batches), len(secondary.batches), secondary.calls())
+	}
+}
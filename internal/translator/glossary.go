@@ -0,0 +1,198 @@
+package translator
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Glossary is the project's terminology: the mandated translation of each
+// term per language, plus the provider glossaries that enforce it natively.
+type Glossary struct {
+	Entries []GlossaryEntry `yaml:"terms" json:"terms"`
+	// DeepL maps target languages to the ID of a DeepL glossary for the pair.
+	DeepL map[string]string `yaml:"deepl" json:"deepl"`
+	// Google is the resource name of a Google Cloud Translation glossary.
+	Google string `yaml:"google" json:"google"`
+
+	patterns []*regexp.Regexp
+}
+
+// GlossaryEntry is one term of a Glossary.
+type GlossaryEntry struct {
+	Term string `yaml:"term" json:"term"`
+	// CaseSensitive matches the term, and checks its translation, only in
+	// the exact case given.
+	CaseSensitive bool   `yaml:"case_sensitive" json:"case_sensitive"`
+	PartOfSpeech  string `yaml:"part_of_speech" json:"part_of_speech"`
+	Note          string `yaml:"note" json:"note"`
+	// Translations maps language codes to the term's mandated translation.
+	Translations map[string]string `yaml:"translations" json:"translations"`
+}
+
+// LoadGlossary reads a glossary file in YAML or JSON.
+func LoadGlossary(path string) (*Glossary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read glossary: %w", err)
+	}
+	var g Glossary
+	if err := yaml.Unmarshal(data, &g); err != nil {
+		return nil, fmt.Errorf("failed to parse glossary %s: %w", path, err)
+	}
+	for i, entry := range g.Entries {
+		if strings.TrimSpace(entry.Term) == "" {
+			return nil, fmt.Errorf("glossary %s: term %d is empty", path, i+1)
+		}
+		g.patterns = append(g.patterns, termPattern(entry.Term, entry.CaseSensitive))
+	}
+	return &g, nil
+}
+
+// Len returns the number of terms.
+func (g *Glossary) Len() int {
+	if g == nil {
+		return 0
+	}
+	return len(g.Entries)
+}
+
+// Lookup returns the terms used in text that have a translation into language.
+func (g *Glossary) Lookup(text, language string) []model.GlossaryTerm {
+	if g == nil {
+		return nil
+	}
+	var terms []model.GlossaryTerm
+	for i, entry := range g.Entries {
+		translation := entry.translation(language)
+		if translation == "" || !g.patterns[i].MatchString(text) {
+			continue
+		}
+		terms = append(terms, model.GlossaryTerm{
+			Term:          entry.Term,
+			Translation:   translation,
+			CaseSensitive: entry.CaseSensitive,
+			PartOfSpeech:  entry.PartOfSpeech,
+			Note:          entry.Note,
+		})
+	}
+	return terms
+}
+
+// annotate returns requests with the glossary terms each of them uses.
+func (g *Glossary) annotate(requests []model.TranslationRequest) []model.TranslationRequest {
+	if g.Len() == 0 {
+		return requests
+	}
+	annotated := make([]model.TranslationRequest, len(requests))
+	for i, req := range requests {
+		req.Glossary = g.Lookup(req.Text, req.TargetLanguage)
+		annotated[i] = req
+	}
+	return annotated
+}
+
+// translation returns the entry's translation into language, falling back
+// from a regional code such as pt-BR to its base language.
+func (e GlossaryEntry) translation(language string) string {
+	if translation, ok := e.Translations[language]; ok {
+		return translation
+	}
+	if base, _, ok := strings.Cut(language, "-"); ok {
+		return e.Translations[base]
+	}
+	return ""
+}
+
+// termPattern matches term as a whole word. Terms in scripts written without
+// spaces match anywhere.
+func termPattern(term string, caseSensitive bool) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(term)
+	if first, _ := utf8.DecodeRuneInString(term); isWordRune(first) {
+		pattern = `(?:^|[^\p{L}\p{N}_])` + pattern
+	}
+	if last, _ := utf8.DecodeLastRuneInString(term); isWordRune(last) {
+		pattern += `(?:$|[^\p{L}\p{N}_])`
+	}
+	if !caseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.MustCompile(pattern)
+}
+
+// isWordRune reports whether r belongs to a word in a script that separates
+// words with spaces. Korean counts as unspaced since particles attach to
+// the word before them.
+func isWordRune(r rune) bool {
+	if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+		return false
+	}
+	return !unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Thai)
+}
+
+// missingGlossaryTerms lists the glossary terms of req whose mandated
+// translation does not appear in translated.
+func missingGlossaryTerms(req model.TranslationRequest, translated string) []model.GlossaryTerm {
+	var missing []model.GlossaryTerm
+	for _, term := range req.Glossary {
+		found := strings.Contains(translated, term.Translation)
+		if !term.CaseSensitive {
+			found = strings.Contains(strings.ToLower(translated), strings.ToLower(term.Translation))
+		}
+		if !found {
+			missing = append(missing, term)
+		}
+	}
+	return missing
+}
+
+// glossaryFingerprint identifies the terms sent with a request, for memory keys.
+func glossaryFingerprint(terms []model.GlossaryTerm) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = strings.Join([]string{term.Term, term.Translation, term.PartOfSpeech, term.Note}, "\x01")
+	}
+	sort.Strings(parts)
+	return hashFingerprint(parts...)
+}
+
+// glossaryNote asks a language model to use the glossary terms of req.
+func glossaryNote(req model.TranslationRequest) string {
+	if len(req.Glossary) == 0 {
+		return ""
+	}
+	rules := make([]string, len(req.Glossary))
+	for i, term := range req.Glossary {
+		rule := fmt.Sprintf("%q as %q", term.Term, term.Translation)
+		var details []string
+		if term.PartOfSpeech != "" {
+			details = append(details, term.PartOfSpeech)
+		}
+		if term.Note != "" {
+			details = append(details, term.Note)
+		}
+		if len(details) > 0 {
+			rule += " (" + strings.Join(details, ": ") + ")"
+		}
+		rules[i] = rule
+	}
+	return "Translate these glossary terms exactly as given: " + strings.Join(rules, "; ") + "."
+}
+
+// sortedPairs flattens a map into sorted "key=value" strings, for fingerprints.
+func sortedPairs(m map[string]string) []string {
+	pairs := make([]string, 0, len(m))
+	for key, value := range m {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
@@ -0,0 +1,167 @@
+package translator
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+const testGlossary = `terms:
+  - term: Library
+    part_of_speech: noun
+    note: the photo library
+    translations:
+      de: Mediathek
+      pt: Biblioteca
+  - term: iCloud Drive
+    case_sensitive: true
+    translations:
+      de: iCloud Drive
+  - term: C++
+    translations:
+      de: C++
+  - term: 写真
+    translations:
+      en: Photos
+deepl:
+  de: glossary-id
+`
+
+// loadTestGlossary writes content to a glossary file and loads it.
+func loadTestGlossary(t *testing.T, content string) *Glossary {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "glossary.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	glossary, err := LoadGlossary(path)
+	if err != nil {
+		t.Fatalf("LoadGlossary: %v", err)
+	}
+	return glossary
+}
+
+func TestGlossaryLookup(t *testing.T) {
+	glossary := loadTestGlossary(t, testGlossary)
+	tests := []struct {
+		name     string
+		text     string
+		language string
+		want     []string
+	}{
+		{"whole word", "Open the Library", "de", []string{"Library"}},
+		{"any case", "library settings", "de", []string{"Library"}},
+		{"punctuation around", "“Library”: 3 items", "de", []string{"Library"}},
+		{"inside a word", "Libraryless mode", "de", nil},
+		{"after a word", "MyLibrary", "de", nil},
+		{"plural is another word", "Libraries", "de", nil},
+		{"case-sensitive", "Save to iCloud Drive", "de", []string{"iCloud Drive"}},
+		{"case-sensitive in other case", "Save to icloud drive", "de", nil},
+		{"symbols at the end", "Learn C++ today", "de", []string{"C++"}},
+		{"regional language", "Library", "pt-BR", []string{"Library"}},
+		{"no translation", "Library", "fr", nil},
+		{"unspaced script", "この写真を共有", "en", []string{"写真"}},
+		{"several terms", "Library in iCloud Drive", "de", []string{"Library", "iCloud Drive"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, term := range glossary.Lookup(tt.text, tt.language) {
+				got = append(got, term.Term)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Lookup(%q, %s) = %q, want %q", tt.text, tt.language, got, tt.want)
+			}
+		})
+	}
+
+	terms := glossary.Lookup("Library", "pt-BR")
+	want := model.GlossaryTerm{Term: "Library", Translation: "Biblioteca", PartOfSpeech: "noun", Note: "the photo library"}
+	if len(terms) != 1 || terms[0] != want {
+		t.Errorf("Lookup = %+v, want %+v", terms, want)
+	}
+	if glossary.DeepL["de"] != "glossary-id" {
+		t.Errorf("DeepL = %v", glossary.DeepL)
+	}
+}
+
+func TestLoadGlossaryErrors(t *testing.T) {
+	if _, err := LoadGlossary(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadGlossary accepted a missing file")
+	}
+	path := filepath.Join(t.TempDir(), "glossary.yaml")
+	os.WriteFile(path, []byte("terms:\n  - term: \" \"\n"), 0644)
+	if _, err := LoadGlossary(path); err == nil {
+		t.Error("LoadGlossary accepted an empty term")
+	}
+}
+
+func TestNilGlossary(t *testing.T) {
+	var glossary *Glossary
+	if glossary.Len() != 0 || glossary.Lookup("Library", "de") != nil {
+		t.Error("a nil glossary has terms")
+	}
+	requests := []model.TranslationRequest{request("a", "Library", "de")}
+	if got := glossary.annotate(requests); got[0].Glossary != nil {
+		t.Errorf("annotate = %+v", got)
+	}
+}
+
+func TestGlossaryQA(t *testing.T) {
+	library := model.GlossaryTerm{Term: "Library", Translation: "Mediathek"}
+	drive := model.GlossaryTerm{Term: "iCloud Drive", Translation: "iCloud Drive", CaseSensitive: true}
+	tests := []struct {
+		name       string
+		terms      []model.GlossaryTerm
+		translated string
+		want       []string
+	}{
+		{"term used", []model.GlossaryTerm{library}, "Mediathek öffnen", nil},
+		{"term in another case", []model.GlossaryTerm{library}, "MEDIATHEK öffnen", nil},
+		{"term missing", []model.GlossaryTerm{library}, "Bibliothek öffnen", []string{"Library"}},
+		{"case-sensitive term in another case", []model.GlossaryTerm{drive}, "In icloud drive sichern", []string{"iCloud Drive"}},
+		{"one of two missing", []model.GlossaryTerm{library, drive}, "Mediathek in der Cloud", []string{"iCloud Drive"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := model.TranslationRequest{Text: "Open Library", TargetLanguage: "de", Glossary: tt.terms}
+			var got []string
+			for _, term := range missingGlossaryTerms(req, tt.translated) {
+				got = append(got, term.Term)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("missing terms = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// The glossary rule reports the missing term, and rejects the
+	// translation when set to error.
+	req := model.TranslationRequest{Key: "open", Text: "Open Library", TargetLanguage: "de", Glossary: []model.GlossaryTerm{library}}
+	resp := model.TranslationResponse{Key: "open", TargetLanguage: "de", TranslatedText: "Bibliothek öffnen"}
+	validator := DefaultValidator()
+	if got := validator.apply(req, resp); got.Error != nil || len(got.Warnings) != 1 {
+		t.Errorf("default severity: error %v, warnings %q", got.Error, got.Warnings)
+	}
+	validator.Severities = map[string]string{RuleGlossary: SeverityError}
+	if got := validator.apply(req, resp); !IsQAError(got.Error) {
+		t.Errorf("error severity: error %v, want a QA error", got.Error)
+	}
+}
+
+func TestGlossaryNote(t *testing.T) {
+	req := model.TranslationRequest{Glossary: []model.GlossaryTerm{
+		{Term: "Library", Translation: "Mediathek", PartOfSpeech: "noun", Note: "the photo library"},
+		{Term: "Pin", Translation: "Anheften"},
+	}}
+	want := `Translate these glossary terms exactly as given: "Library" as "Mediathek" (noun: the photo library); "Pin" as "Anheften".`
+	if got := glossaryNote(req); got != want {
+		t.Errorf("glossaryNote =\n%s\nwant\n%s", got, want)
+	}
+	if got := glossaryNote(model.TranslationRequest{}); got != "" {
+		t.Errorf("glossaryNote without terms = %q", got)
+	}
+}
@@ -15,6 +15,8 @@ import (
 type GoogleTranslator struct {
 	APIKey string
 	Client *resty.Client
+	// Glossary is the resource name of a glossary to apply, if any.
+	Glossary string
 }
 
 // GoogleTranslateRequest represents the request body for Google Translate API
@@ -52,16 +54,92 @@ func NewGoogleTranslator(apiKey string) *GoogleTranslator {
 	}
 }
 
+// googleBatchLimits keeps requests within the API's 128 texts and its
+// recommended maximum of 30,000 characters.
+var googleBatchLimits = model.BatchLimits{MaxItems: 128, MaxBytes: 30000}
+
 // Translate translates a string using Google Translate API
 func (g *GoogleTranslator) Translate(ctx context.Context, req model.TranslationRequest) (model.TranslationResponse, error) {
+	texts, err := g.translateTexts(ctx, []string{req.Text}, req)
+	if err != nil {
+		return model.TranslationResponse{
+			Key:            req.Key,
+			TargetLanguage: req.TargetLanguage,
+			Error:          err,
+		}, nil
+	}
+
+	return model.TranslationResponse{
+		Key:            req.Key,
+		TargetLanguage: req.TargetLanguage,
+		TranslatedText: texts[0],
+	}, nil
+}
+
+// ModelName returns "": the model is not configurable yet.
+func (g *GoogleTranslator) ModelName() string {
+	return ""
+}
+
+// Fingerprint identifies the glossary in use, if any.
+func (g *GoogleTranslator) Fingerprint() string {
+	if g.Glossary == "" {
+		return ""
+	}
+	return hashFingerprint(g.Glossary)
+}
+
+// HandlesTags reports that Google keeps tags when translating HTML.
+func (g *GoogleTranslator) HandlesTags() bool {
+	return true
+}
+
+// BatchLimits returns Google Translate's limits per request.
+func (g *GoogleTranslator) BatchLimits() model.BatchLimits {
+	return googleBatchLimits
+}
+
+// TranslateBatch translates several texts in one request.
+func (g *GoogleTranslator) TranslateBatch(ctx context.Context, reqs []model.TranslationRequest) ([]model.TranslationResponse, error) {
+	texts := make([]string, len(reqs))
+	for i, req := range reqs {
+		texts[i] = req.Text
+	}
+
+	translated, err := g.translateTexts(ctx, texts, reqs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]model.TranslationResponse, len(reqs))
+	for i, req := range reqs {
+		responses[i] = model.TranslationResponse{
+			Key:            req.Key,
+			TargetLanguage: req.TargetLanguage,
+			TranslatedText: translated[i],
+		}
+	}
+	return responses, nil
+}
+
+// translateTexts sends texts to Google in one request and returns their
+// translations in order. The languages and masking come from req; masked
+// texts are sent as HTML so the placeholder tags survive.
+func (g *GoogleTranslator) translateTexts(ctx context.Context, texts []string, req model.TranslationRequest) ([]string, error) {
 	apiURL := "https://translation.googleapis.com/language/translate/v2"
 
 	requestBody := GoogleTranslateRequest{
-		Contents:       []string{req.Text},
+		Contents:       texts,
 		SourceLanguage: req.SourceLanguage,
 		TargetLanguage: req.TargetLanguage,
 		MimeType:       "text/plain",
 	}
+	if req.Masked {
+		requestBody.MimeType = "text/html"
+	}
+	if g.Glossary != "" {
+		requestBody.GlossaryConfig = &GlossaryConfig{Glossary: g.Glossary}
+	}
 
 	resp, err := g.Client.R().
 		SetContext(ctx).
@@ -69,42 +147,28 @@ func (g *GoogleTranslator) Translate(ctx context.Context, req model.TranslationR
 		Post(apiURL)
 
 	if err != nil {
-		return model.TranslationResponse{
-			Key:            req.Key,
-			TargetLanguage: req.TargetLanguage,
-			Error:          fmt.Errorf("request failed: %v", err),
-		}, nil
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 
 	if resp.StatusCode() != http.StatusOK {
-		return model.TranslationResponse{
-			Key:            req.Key,
-			TargetLanguage: req.TargetLanguage,
-			Error:          fmt.Errorf("API request failed with status code: %d, response: %s", resp.StatusCode(), resp.String()),
-		}, nil
+		return nil, newAPIError(resp)
 	}
 
 	var translationResponse GoogleTranslateResponse
-	err = json.Unmarshal(resp.Body(), &translationResponse)
-	if err != nil {
-		return model.TranslationResponse{
-			Key:            req.Key,
-			TargetLanguage: req.TargetLanguage,
-			Error:          fmt.Errorf("failed to parse response: %v", err),
-		}, nil
+	if err := json.Unmarshal(resp.Body(), &translationResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %v", err)
 	}
 
 	if len(translationResponse.Translations) == 0 {
-		return model.TranslationResponse{
-			Key:            req.Key,
-			TargetLanguage: req.TargetLanguage,
-			Error:          fmt.Errorf("no translation results"),
-		}, nil
+		return nil, fmt.Errorf("no translation results")
+	}
+	if len(translationResponse.Translations) != len(texts) {
+		return nil, fmt.Errorf("got %d translations for %d texts", len(translationResponse.Translations), len(texts))
 	}
 
-	return model.TranslationResponse{
-		Key:            req.Key,
-		TargetLanguage: req.TargetLanguage,
-		TranslatedText: translationResponse.Translations[0].TranslatedText,
-	}, nil
+	translated := make([]string, len(texts))
+	for i, t := range translationResponse.Translations {
+		translated[i] = t.TranslatedText
+	}
+	return translated, nil
 }
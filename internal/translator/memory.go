@@ -0,0 +1,392 @@
+package translator
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// memoryVersion is the on-disk format version of the translation memory file.
+const memoryVersion = 1
+
+// MemoryKey identifies one remembered translation. Fingerprint covers whatever
+// besides the text shapes the output: the provider's prompt and glossary, and
+// the request's comment, plural form and device.
+type MemoryKey struct {
+	Source         string `json:"source"`
+	SourceLanguage string `json:"sourceLanguage"`
+	TargetLanguage string `json:"targetLanguage"`
+	Provider       string `json:"provider"`
+	Model          string `json:"model,omitempty"`
+	Fingerprint    string `json:"fingerprint,omitempty"`
+}
+
+func (k MemoryKey) id() string {
+	return strings.Join([]string{k.Source, k.SourceLanguage, k.TargetLanguage, k.Provider, k.Model, k.Fingerprint}, "\x00")
+}
+
+// MemoryEntry is a remembered translation and its bookkeeping.
+type MemoryEntry struct {
+	MemoryKey
+	Translation string    `json:"translation"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UsedAt      time.Time `json:"usedAt"`
+	Hits        int       `json:"hits"`
+}
+
+// memoryFile is the JSON document the memory is stored in.
+type memoryFile struct {
+	Version int           `json:"version"`
+	Entries []MemoryEntry `json:"entries"`
+}
+
+// TranslationMemory is a local store of earlier provider translations, so the
+// same text is not paid for twice across runs, branches and files. It is read
+// once when opened and written back by Save.
+type TranslationMemory struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]*MemoryEntry
+	removed map[string]bool
+	dirty   bool
+}
+
+// OpenTranslationMemory loads the memory at path; a missing file is an empty memory.
+func OpenTranslationMemory(path string) (*TranslationMemory, error) {
+	entries, err := readMemoryFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &TranslationMemory{
+		path:    path,
+		entries: make(map[string]*MemoryEntry, len(entries)),
+		removed: map[string]bool{},
+	}
+	for i := range entries {
+		m.entries[entries[i].id()] = &entries[i]
+	}
+	return m, nil
+}
+
+func readMemoryFile(path string) ([]MemoryEntry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read translation memory: %w", err)
+	}
+
+	var file memoryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse translation memory %s: %w", path, err)
+	}
+	if file.Version > memoryVersion {
+		return nil, fmt.Errorf("translation memory %s has unsupported version %d", path, file.Version)
+	}
+	return file.Entries, nil
+}
+
+// Path returns the memory's file path.
+func (m *TranslationMemory) Path() string {
+	return m.path
+}
+
+// Len returns the number of remembered translations.
+func (m *TranslationMemory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
+
+// Lookup returns the remembered translation for key and counts the hit.
+func (m *TranslationMemory) Lookup(key MemoryKey) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key.id()]
+	if !ok {
+		return "", false
+	}
+	entry.Hits++
+	entry.UsedAt = time.Now().UTC()
+	m.dirty = true
+	return entry.Translation, true
+}
+
+// Contains reports whether key has a remembered translation, without
+// counting a hit.
+func (m *TranslationMemory) Contains(key MemoryKey) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.entries[key.id()]
+	return ok
+}
+
+// Store remembers translation for key, replacing an older translation.
+func (m *TranslationMemory) Store(key MemoryKey, translation string) {
+	now := time.Now().UTC()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := key.id()
+	m.entries[id] = &MemoryEntry{
+		MemoryKey:   key,
+		Translation: translation,
+		CreatedAt:   now,
+		UsedAt:      now,
+	}
+	delete(m.removed, id)
+	m.dirty = true
+}
+
+// Entries returns a copy of every entry, ordered by provider, languages and source.
+func (m *TranslationMemory) Entries() []MemoryEntry {
+	m.mu.Lock()
+	entries := make([]MemoryEntry, 0, len(m.entries))
+	for _, entry := range m.entries {
+		entries = append(entries, *entry)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		if a.Model != b.Model {
+			return a.Model < b.Model
+		}
+		if a.SourceLanguage != b.SourceLanguage {
+			return a.SourceLanguage < b.SourceLanguage
+		}
+		if a.TargetLanguage != b.TargetLanguage {
+			return a.TargetLanguage < b.TargetLanguage
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Fingerprint < b.Fingerprint
+	})
+	return entries
+}
+
+// MemoryFilter selects memory entries; zero fields match everything.
+type MemoryFilter struct {
+	Provider string
+	Model    string
+	Language string
+	// UnusedSince matches entries not used since this time.
+	UnusedSince time.Time
+}
+
+// Match reports whether entry passes every set criterion. Language matches
+// either side of the pair.
+func (f MemoryFilter) Match(entry MemoryEntry) bool {
+	if f.Provider != "" && entry.Provider != f.Provider {
+		return false
+	}
+	if f.Model != "" && entry.Model != f.Model {
+		return false
+	}
+	if f.Language != "" && entry.TargetLanguage != f.Language && entry.SourceLanguage != f.Language {
+		return false
+	}
+	if !f.UnusedSince.IsZero() && !entry.UsedAt.Before(f.UnusedSince) {
+		return false
+	}
+	return true
+}
+
+// Prune forgets the entries matching filter and returns how many were removed.
+func (m *TranslationMemory) Prune(filter MemoryFilter) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pruned := 0
+	for id, entry := range m.entries {
+		if filter.Match(*entry) {
+			delete(m.entries, id)
+			m.removed[id] = true
+			pruned++
+		}
+	}
+	if pruned > 0 {
+		m.dirty = true
+	}
+	return pruned
+}
+
+// Save writes the memory back to disk if it changed. Entries another run
+// added to the file in the meantime are kept, unless this one pruned them.
+func (m *TranslationMemory) Save() error {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.dirty {
+		return nil
+	}
+
+	onDisk, err := readMemoryFile(m.path)
+	if err != nil {
+		return err
+	}
+	for i := range onDisk {
+		id := onDisk[i].id()
+		if _, ok := m.entries[id]; !ok && !m.removed[id] {
+			m.entries[id] = &onDisk[i]
+		}
+	}
+
+	file := memoryFile{Version: memoryVersion, Entries: make([]MemoryEntry, 0, len(m.entries))}
+	for _, entry := range m.entries {
+		file.Entries = append(file.Entries, *entry)
+	}
+	sort.Slice(file.Entries, func(i, j int) bool {
+		return file.Entries[i].id() < file.Entries[j].id()
+	})
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal translation memory: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return fmt.Errorf("failed to write translation memory: %w", err)
+	}
+
+	// Write to a temporary file first so an interrupted save never leaves a
+	// truncated memory behind.
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write translation memory: %w", err)
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write translation memory: %w", err)
+	}
+	m.dirty = false
+	return nil
+}
+
+// Fingerprinted is implemented by providers whose output depends on more than
+// the text and languages, such as a model, a prompt or a glossary.
+type Fingerprinted interface {
+	// ModelName names the model that produces the translations.
+	ModelName() string
+	// Fingerprint changes whenever the prompt or glossary changes.
+	Fingerprint() string
+}
+
+// MemoryScope is the part of a TranslationMemory one provider reads and
+// writes. A nil MemoryScope remembers nothing.
+type MemoryScope struct {
+	memory      *TranslationMemory
+	provider    string
+	model       string
+	fingerprint string
+}
+
+// Scope binds the memory to provider, registered under name.
+func (m *TranslationMemory) Scope(name string, provider model.TranslationProvider) *MemoryScope {
+	if m == nil {
+		return nil
+	}
+	scope := &MemoryScope{memory: m, provider: name}
+	if fp, ok := provider.(Fingerprinted); ok {
+		scope.model = fp.ModelName()
+		scope.fingerprint = fp.Fingerprint()
+	}
+	return scope
+}
+
+// key builds the memory key for req. The request's context, glossary and
+// protected terms join the provider fingerprint, since the same text may need a
+// different plural form or wording depending on them.
+func (s *MemoryScope) key(req model.TranslationRequest) MemoryKey {
+	fingerprint := s.fingerprint
+	parts := []string{req.Comment, PluralCategoryOf(req.Path), DeviceOf(req.Path)}
+	if len(req.Glossary) > 0 {
+		parts = append(parts, glossaryFingerprint(req.Glossary))
+	}
+	if len(req.Protected) > 0 {
+		parts = append(parts, "protected:"+strings.Join(req.Protected, "\x01"))
+	}
+	if context := strings.Join(parts, "\x00"); strings.Trim(context, "\x00") != "" {
+		fingerprint = hashFingerprint(s.fingerprint, context)
+	}
+	return MemoryKey{
+		Source:         req.Text,
+		SourceLanguage: req.SourceLanguage,
+		TargetLanguage: req.TargetLanguage,
+		Provider:       s.provider,
+		Model:          s.model,
+		Fingerprint:    fingerprint,
+	}
+}
+
+// Lookup returns the remembered translation of req.
+func (s *MemoryScope) Lookup(req model.TranslationRequest) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	return s.memory.Lookup(s.key(req))
+}
+
+// Contains reports whether req has a remembered translation, without
+// counting a hit.
+func (s *MemoryScope) Contains(req model.TranslationRequest) bool {
+	return s != nil && s.memory.Contains(s.key(req))
+}
+
+// Store remembers a successful translation of req.
+func (s *MemoryScope) Store(req model.TranslationRequest, translation string) {
+	if s == nil {
+		return
+	}
+	s.memory.Store(s.key(req), translation)
+}
+
+// hashFingerprint returns a short stable digest of parts.
+func hashFingerprint(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// WriteMemoryEntries exports entries as "json" (an array) or "csv".
+func WriteMemoryEntries(w io.Writer, entries []MemoryEntry, format string) error {
+	switch format {
+	case "", "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if entries == nil {
+			entries = []MemoryEntry{}
+		}
+		return encoder.Encode(entries)
+	case "csv":
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"provider", "model", "source_language", "target_language", "source", "translation", "fingerprint", "hits", "created_at", "used_at"})
+		for _, e := range entries {
+			writer.Write([]string{
+				e.Provider, e.Model, e.SourceLanguage, e.TargetLanguage, e.Source, e.Translation, e.Fingerprint,
+				strconv.Itoa(e.Hits), e.CreatedAt.Format(time.RFC3339), e.UsedAt.Format(time.RFC3339),
+			})
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+	return fmt.Errorf("unknown export format %q (want json or csv)", format)
+}
@@ -0,0 +1,276 @@
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// fingerprintedProvider is a fakeProvider with a model and prompt fingerprint.
+type fingerprintedProvider struct {
+	fakeProvider
+	model, fingerprint string
+}
+
+func (p *fingerprintedProvider) ModelName() string   { return p.model }
+func (p *fingerprintedProvider) Fingerprint() string { return p.fingerprint }
+
+// openTestMemory opens an empty memory in a temporary directory.
+func openTestMemory(t *testing.T) *TranslationMemory {
+	t.Helper()
+	memory, err := OpenTranslationMemory(filepath.Join(t.TempDir(), "memory.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return memory
+}
+
+func TestMemoryScopeKey(t *testing.T) {
+	memory := openTestMemory(t)
+	openai := &fingerprintedProvider{model: "gpt-4o", fingerprint: "prompt-v1"}
+	stored := request("save", "Save", "de")
+	memory.Scope("openai", openai).Store(stored, "Speichern")
+
+	withComment := stored
+	withComment.Comment = "Button title"
+	plural := stored
+	plural.Path = "plural.one"
+	device := stored
+	device.Path = "device.mac"
+	withGlossary := stored
+	withGlossary.Glossary = []model.GlossaryTerm{{Term: "Save", Translation: "Sichern"}}
+	withProtected := stored
+	withProtected.Protected = []string{"Save"}
+	otherKey := stored
+	otherKey.Key = "save.again"
+	otherPath := stored
+	otherPath.Path = "substitutions.count.plural.one"
+
+	tests := []struct {
+		name     string
+		scope    *MemoryScope
+		req      model.TranslationRequest
+		wantFind bool
+	}{
+		{"same request", memory.Scope("openai", openai), stored, true},
+		{"another key with the same text", memory.Scope("openai", openai), otherKey, true},
+		{"other source text", memory.Scope("openai", openai), request("save", "Save all", "de"), false},
+		{"other source language", memory.Scope("openai", openai), model.TranslationRequest{Text: "Save", SourceLanguage: "fr", TargetLanguage: "de"}, false},
+		{"other target language", memory.Scope("openai", openai), request("save", "Save", "fr"), false},
+		{"other provider name", memory.Scope("deepl", openai), stored, false},
+		{"other model", memory.Scope("openai", &fingerprintedProvider{model: "gpt-4o-mini", fingerprint: "prompt-v1"}), stored, false},
+		{"other prompt fingerprint", memory.Scope("openai", &fingerprintedProvider{model: "gpt-4o", fingerprint: "prompt-v2"}), stored, false},
+		{"other comment", memory.Scope("openai", openai), withComment, false},
+		{"plural form", memory.Scope("openai", openai), plural, false},
+		{"device", memory.Scope("openai", openai), device, false},
+		{"glossary terms", memory.Scope("openai", openai), withGlossary, false},
+		{"protected terms", memory.Scope("openai", openai), withProtected, false},
+		{"substitution plural form", memory.Scope("openai", openai), otherPath, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.scope.Contains(tt.req); got != tt.wantFind {
+				t.Errorf("Contains = %v, want %v", got, tt.wantFind)
+			}
+			got, ok := tt.scope.Lookup(tt.req)
+			if ok != tt.wantFind || ok && got != "Speichern" {
+				t.Errorf("Lookup = %q, %v", got, ok)
+			}
+		})
+	}
+}
+
+// TestOpenAIPromptSample checks that the sample behind the OpenAI fingerprint
+// reaches every part of the user prompt, so rewording any of them changes it.
+func TestOpenAIPromptSample(t *testing.T) {
+	full := openAIUserPrompt(openAIPromptSample)
+	tests := []struct {
+		name  string
+		strip func(*model.TranslationRequest)
+	}{
+		{"comment", func(r *model.TranslationRequest) { r.Comment = "" }},
+		{"device", func(r *model.TranslationRequest) { r.Path = "plural.one" }},
+		{"plural form", func(r *model.TranslationRequest) { r.Path = "device.iphone" }},
+		{"substitution", func(r *model.TranslationRequest) { r.Text = strings.ReplaceAll(r.Text, "%#@count@", "count") }},
+		{"masked tokens", func(r *model.TranslationRequest) { r.Masked = false }},
+		{"glossary", func(r *model.TranslationRequest) { r.Glossary = nil }},
+		{"glossary details", func(r *model.TranslationRequest) {
+			r.Glossary = []model.GlossaryTerm{{Term: "term", Translation: "Begriff"}}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := openAIPromptSample
+			tt.strip(&req)
+			if openAIUserPrompt(req) == full {
+				t.Errorf("the sample prompt does not depend on the %s", tt.name)
+			}
+		})
+	}
+
+	openai := NewOpenAITranslator("key", "", "", 0, 0)
+	if openai.Fingerprint() != openai.Fingerprint() {
+		t.Error("fingerprint is not stable")
+	}
+	if openai.Fingerprint() == hashFingerprint(openAISystemPrompt) {
+		t.Error("fingerprint ignores the user prompt")
+	}
+}
+
+func TestMemoryHits(t *testing.T) {
+	memory := openTestMemory(t)
+	key := MemoryKey{Source: "Save", SourceLanguage: "en", TargetLanguage: "de", Provider: "deepl"}
+	memory.Store(key, "Speichern")
+	if !memory.Contains(key) {
+		t.Fatal("Contains = false after Store")
+	}
+	if memory.Entries()[0].Hits != 0 {
+		t.Error("Contains counted a hit")
+	}
+	memory.Lookup(key)
+	memory.Lookup(key)
+	if got := memory.Entries()[0].Hits; got != 2 {
+		t.Errorf("Hits = %d, want 2", got)
+	}
+
+	memory.Store(key, "Sichern")
+	if got, _ := memory.Lookup(key); got != "Sichern" {
+		t.Errorf("Lookup after a second Store = %q", got)
+	}
+	if memory.Len() != 1 {
+		t.Errorf("Len = %d, want 1", memory.Len())
+	}
+}
+
+func TestNilMemoryScope(t *testing.T) {
+	var memory *TranslationMemory
+	scope := memory.Scope("deepl", &fakeProvider{})
+	if scope != nil {
+		t.Fatal("a nil memory returned a scope")
+	}
+	scope.Store(request("save", "Save", "de"), "Speichern")
+	if _, ok := scope.Lookup(request("save", "Save", "de")); ok {
+		t.Error("a nil scope remembered a translation")
+	}
+	if err := memory.Save(); err != nil {
+		t.Errorf("Save = %v", err)
+	}
+}
+
+func TestMemorySave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "memory.json")
+	first, err := OpenTranslationMemory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := OpenTranslationMemory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keep := MemoryKey{Source: "Save", SourceLanguage: "en", TargetLanguage: "de", Provider: "deepl"}
+	gone := MemoryKey{Source: "Old", SourceLanguage: "en", TargetLanguage: "de", Provider: "deepl"}
+	first.Store(keep, "Speichern")
+	first.Store(gone, "Alt")
+	if err := first.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// A concurrent run adds an entry; the first run then forgets one of its own.
+	added := MemoryKey{Source: "Open", SourceLanguage: "en", TargetLanguage: "de", Provider: "google"}
+	second.Store(added, "Öffnen")
+	if err := second.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if n := first.Prune(MemoryFilter{Provider: "deepl", Language: "de", UnusedSince: time.Now().Add(time.Hour)}); n != 2 {
+		t.Fatalf("Prune = %d, want 2", n)
+	}
+	first.Store(keep, "Speichern")
+	if err := first.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reopened, err := OpenTranslationMemory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sources []string
+	for _, entry := range reopened.Entries() {
+		sources = append(sources, entry.Provider+":"+entry.Source)
+	}
+	if got := strings.Join(sources, ","); got != "deepl:Save,google:Open" {
+		t.Errorf("entries on disk = %s, want deepl:Save,google:Open", got)
+	}
+}
+
+func TestMemoryFilter(t *testing.T) {
+	now := time.Now()
+	entry := MemoryEntry{
+		MemoryKey: MemoryKey{Source: "Save", SourceLanguage: "en", TargetLanguage: "de", Provider: "openai", Model: "gpt-4o"},
+		UsedAt:    now.Add(-48 * time.Hour),
+	}
+	tests := []struct {
+		name   string
+		filter MemoryFilter
+		want   bool
+	}{
+		{"empty", MemoryFilter{}, true},
+		{"provider", MemoryFilter{Provider: "openai"}, true},
+		{"other provider", MemoryFilter{Provider: "deepl"}, false},
+		{"model", MemoryFilter{Model: "gpt-4o"}, true},
+		{"other model", MemoryFilter{Model: "gpt-4o-mini"}, false},
+		{"target language", MemoryFilter{Language: "de"}, true},
+		{"source language", MemoryFilter{Language: "en"}, true},
+		{"other language", MemoryFilter{Language: "ja"}, false},
+		{"unused since", MemoryFilter{UnusedSince: now.Add(-24 * time.Hour)}, true},
+		{"used since", MemoryFilter{UnusedSince: now.Add(-72 * time.Hour)}, false},
+		{"every criterion", MemoryFilter{Provider: "openai", Language: "de", UnusedSince: now}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Match(entry); got != tt.want {
+			t.Errorf("%s: Match = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWriteMemoryEntries(t *testing.T) {
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	entries := []MemoryEntry{{
+		MemoryKey:   MemoryKey{Source: "Save, all", SourceLanguage: "en", TargetLanguage: "de", Provider: "deepl"},
+		Translation: "Alle speichern",
+		CreatedAt:   at,
+		UsedAt:      at,
+		Hits:        3,
+	}}
+
+	var csv bytes.Buffer
+	if err := WriteMemoryEntries(&csv, entries, "csv"); err != nil {
+		t.Fatal(err)
+	}
+	want := "provider,model,source_language,target_language,source,translation,fingerprint,hits,created_at,used_at\n" +
+		"deepl,,en,de,\"Save, all\",Alle speichern,,3,2025-03-01T12:00:00Z,2025-03-01T12:00:00Z\n"
+	if csv.String() != want {
+		t.Errorf("csv =\n%s\nwant\n%s", csv.String(), want)
+	}
+
+	var out bytes.Buffer
+	if err := WriteMemoryEntries(&out, entries, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []MemoryEntry
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || len(decoded) != 1 || decoded[0].Translation != "Alle speichern" {
+		t.Errorf("json = %s (%v)", out.String(), err)
+	}
+
+	out.Reset()
+	if err := WriteMemoryEntries(&out, nil, "json"); err != nil || strings.TrimSpace(out.String()) != "[]" {
+		t.Errorf("empty json = %q (%v)", out.String(), err)
+	}
+	if err := WriteMemoryEntries(&out, entries, "xml"); err == nil {
+		t.Error("WriteMemoryEntries accepted xml")
+	}
+}
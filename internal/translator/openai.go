@@ -247,6 +247,21 @@ func looksLikeZlib(data []byte) bool {
 	return (uint16(cmf)<<8|uint16(flg))%31 == 0 && cmf&0x0F == 8
 }
 
+// openAISystemPrompt is the system message sent with every translation.
+const openAISystemPrompt = "You are a professional translator. Translate the text accurately without adding extra information."
+
+// openAIPromptSample is a request that takes every branch of openAIUserPrompt,
+// so its rendered prompt changes whenever any of the prompt wording does.
+var openAIPromptSample = model.TranslationRequest{
+	Text:           "%#@count@ ⟦0⟧",
+	SourceLanguage: "en",
+	TargetLanguage: "de",
+	Path:           "device.iphone.plural.one",
+	Comment:        "comment",
+	Masked:         true,
+	Glossary:       []model.GlossaryTerm{{Term: "term", Translation: "Begriff", PartOfSpeech: "noun", Note: "note"}},
+}
+
 // NewOpenAITranslator creates a new OpenAI Translator instance
 func NewOpenAITranslator(apiKey, apiBaseURL, model string, temperature float64, maxTokens int) *OpenAITranslator {
 	if apiBaseURL == "" {
@@ -270,12 +285,26 @@ func NewOpenAITranslator(apiKey, apiBaseURL, model string, temperature float64,
 	}
 }
 
-func (o *OpenAITranslator) translateOnce(ctx context.Context, req model.TranslationRequest, stream bool) (string, error) {
+// ModelName returns the chat model in use.
+func (o *OpenAITranslator) ModelName() string {
+	return o.Model
+}
+
+// Fingerprint identifies the prompts sent to the model, so translations
+// remembered under an earlier wording are not reused.
+func (o *OpenAITranslator) Fingerprint() string {
+	return hashFingerprint(openAISystemPrompt, openAIUserPrompt(openAIPromptSample))
+}
+
+// MetersTokens reports that the API bills tokens.
+func (o *OpenAITranslator) MetersTokens() bool {
+	return true
+}
+
+func (o *OpenAITranslator) translateOnce(ctx context.Context, req model.TranslationRequest, stream bool) (string, model.Usage, error) {
 	apiURL := fmt.Sprintf("%s/v1/chat/completions", o.APIBaseURL)
 
-	// Create translation prompt
-	prompt := fmt.Sprintf("Translate the following text from %s to %s:\n\n%s",
-		req.SourceLanguage, req.TargetLanguage, req.Text)
+	prompt := openAIUserPrompt(req)
 
 	temperature := o.Temperature
 	if temperature == 0 {
@@ -294,7 +323,7 @@ func (o *OpenAITranslator) translateOnce(ctx context.Context, req model.Translat
 		}{
 			{
 				Role:    "system",
-				Content: "You are a professional translator. Translate the text accurately without adding extra information.",
+				Content: openAISystemPrompt,
 			},
 			{
 				Role:    "user",
@@ -307,7 +336,7 @@ func (o *OpenAITranslator) translateOnce(ctx context.Context, req model.Translat
 	}
 
 	// Some providers require stream_options to be present whenever stream is set (even false).
-	requestBody.StreamOptions = &OpenAIStreamOptions{IncludeUsage: false}
+	requestBody.StreamOptions = &OpenAIStreamOptions{IncludeUsage: stream}
 
 	resp, err := o.Client.R().
 		SetContext(ctx).
@@ -315,28 +344,28 @@ func (o *OpenAITranslator) translateOnce(ctx context.Context, req model.Translat
 		Post(apiURL)
 
 	if err != nil {
-		return "", fmt.Errorf("request failed: %v", err)
+		return "", model.Usage{}, fmt.Errorf("request failed: %w", err)
 	}
 
 	if resp.StatusCode() != http.StatusOK {
-		return "", fmt.Errorf("API request failed with status code: %d, response: %s", resp.StatusCode(), resp.String())
+		return "", model.Usage{}, newAPIError(resp)
 	}
 
 	body, err := decodeResponseBody(resp)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %v", err)
+		return "", model.Usage{}, fmt.Errorf("failed to read response: %v", err)
 	}
 
 	// If we requested streaming, try to parse SSE chunks first.
 	if stream {
 		if isStreamContent(resp.Header().Get("Content-Type"), body) {
-			content, streamErr := parseStreamedContent(body)
+			content, usage, streamErr := parseStreamedContent(body)
 			if streamErr == nil {
-				return content, nil
+				return content, usage, nil
 			}
 			// If the payload clearly looks like stream data, return the parsing error directly.
 			if bytes.HasPrefix(bytes.TrimSpace(body), []byte("data:")) {
-				return "", streamErr
+				return "", model.Usage{}, streamErr
 			}
 			// otherwise fall through to JSON parsing for robustness
 		}
@@ -346,26 +375,67 @@ func (o *OpenAITranslator) translateOnce(ctx context.Context, req model.Translat
 	fmt.Printf("OpenAI Translation Response status: %d\n", resp.StatusCode())
 	err = json.Unmarshal(body, &translationResponse)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse response: %v", err)
+		return "", model.Usage{}, fmt.Errorf("failed to parse response: %v", err)
 	}
 
 	if translationResponse.Error != nil {
-		return "", fmt.Errorf("API error: %s", translationResponse.Error.Message)
+		return "", model.Usage{}, fmt.Errorf("API error: %s", translationResponse.Error.Message)
 	}
 
 	if len(translationResponse.Choices) == 0 {
-		return "", fmt.Errorf("no translation results: %s", body)
+		return "", model.Usage{}, fmt.Errorf("no translation results: %s", body)
 	}
 
 	content, err := extractMessageText(translationResponse.Choices[0].Message.Content)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse message content: %v, resp: %s", err, body)
+		return "", model.Usage{}, fmt.Errorf("failed to parse message content: %v, resp: %s", err, body)
 	}
 	if content == "" {
-		return "", fmt.Errorf("no translation results: %s", body)
+		return "", model.Usage{}, fmt.Errorf("no translation results: %s", body)
 	}
 
-	return content, nil
+	usage := model.Usage{
+		PromptTokens:     translationResponse.Usage.PromptTokens,
+		CompletionTokens: translationResponse.Usage.CompletionTokens,
+	}
+	return content, usage, nil
+}
+
+// openAIUserPrompt builds the user message asking for the translation of req.
+func openAIUserPrompt(req model.TranslationRequest) string {
+	prompt := fmt.Sprintf("Translate the following text from %s to %s:\n\n%s",
+		req.SourceLanguage, req.TargetLanguage, req.Text)
+	if notes := variantNotes(req); notes != "" {
+		prompt = fmt.Sprintf("Translate the following text from %s to %s. %s\n\n%s",
+			req.SourceLanguage, req.TargetLanguage, notes, req.Text)
+	}
+	if req.Comment != "" {
+		prompt = fmt.Sprintf("Developer comment describing where the text is used (context only, do not translate it): %s\n\n%s", req.Comment, prompt)
+	}
+	return prompt
+}
+
+// variantNotes describes which plural form or device variant a request is for,
+// which substitution placeholders must survive translation and which glossary
+// terms it must follow.
+func variantNotes(req model.TranslationRequest) string {
+	var notes []string
+	if device := DeviceOf(req.Path); device != "" {
+		notes = append(notes, fmt.Sprintf("It is the variant shown on %s devices.", device))
+	}
+	if category := PluralCategoryOf(req.Path); category != "" {
+		notes = append(notes, fmt.Sprintf("It is the %q plural form, so use the grammatical form %s requires for that category.", category, req.TargetLanguage))
+	}
+	if tokens := substitutionToken.FindAllString(req.Text, -1); len(tokens) > 0 {
+		notes = append(notes, fmt.Sprintf("Keep the placeholders %s exactly as written.", strings.Join(tokens, " ")))
+	}
+	if tokens := maskedTokens(req.Text); req.Masked && len(tokens) > 0 {
+		notes = append(notes, fmt.Sprintf("The tokens %s stand for values inserted by the app or names that stay untranslated; keep each exactly once, moving it where the grammar needs it.", strings.Join(tokens, " ")))
+	}
+	if note := glossaryNote(req); note != "" {
+		notes = append(notes, note)
+	}
+	return strings.Join(notes, " ")
 }
 
 func isStreamContent(contentType string, body []byte) bool {
@@ -376,9 +446,12 @@ func isStreamContent(contentType string, body []byte) bool {
 	return bytes.HasPrefix(trimmed, []byte("data:"))
 }
 
-func parseStreamedContent(body []byte) (string, error) {
+// parseStreamedContent joins the content of streamed chunks and picks up the
+// usage the last chunk reports when it was asked for.
+func parseStreamedContent(body []byte) (string, model.Usage, error) {
 	scanner := bufio.NewScanner(bytes.NewReader(body))
 	var builder strings.Builder
+	var usage model.Usage
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -397,31 +470,38 @@ func parseStreamedContent(body []byte) (string, error) {
 					Content string `json:"content"`
 				} `json:"delta"`
 			} `json:"choices"`
+			Usage *struct {
+				PromptTokens     int `json:"prompt_tokens"`
+				CompletionTokens int `json:"completion_tokens"`
+			} `json:"usage"`
 		}
 		if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
-			return "", fmt.Errorf("failed to parse streamed chunk: %v", err)
+			return "", model.Usage{}, fmt.Errorf("failed to parse streamed chunk: %v", err)
 		}
 		if len(chunk.Choices) > 0 {
 			builder.WriteString(chunk.Choices[0].Delta.Content)
 		}
+		if chunk.Usage != nil {
+			usage = model.Usage{PromptTokens: chunk.Usage.PromptTokens, CompletionTokens: chunk.Usage.CompletionTokens}
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return "", fmt.Errorf("failed to read streamed response: %v", err)
+		return "", model.Usage{}, fmt.Errorf("failed to read streamed response: %v", err)
 	}
 
 	if builder.Len() == 0 {
-		return "", fmt.Errorf("no streamed content")
+		return "", model.Usage{}, fmt.Errorf("no streamed content")
 	}
 
-	return builder.String(), nil
+	return builder.String(), usage, nil
 }
 
 // Translate translates a string using OpenAI Chat API
 func (o *OpenAITranslator) Translate(ctx context.Context, req model.TranslationRequest) (model.TranslationResponse, error) {
-	translatedText, err := o.translateOnce(ctx, req, false)
+	translatedText, usage, err := o.translateOnce(ctx, req, false)
 	if err != nil && strings.Contains(err.Error(), "'stream' and 'stream_options' must be set together") {
-		translatedText, err = o.translateOnce(ctx, req, true)
+		translatedText, usage, err = o.translateOnce(ctx, req, true)
 		if err != nil {
 			err = fmt.Errorf("streaming retry failed: %w", err)
 		}
@@ -439,5 +519,6 @@ func (o *OpenAITranslator) Translate(ctx context.Context, req model.TranslationR
 		Key:            req.Key,
 		TargetLanguage: req.TargetLanguage,
 		TranslatedText: translatedText,
+		Usage:          usage,
 	}, nil
 }
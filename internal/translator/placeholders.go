@@ -0,0 +1,197 @@
+package translator
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// ErrDuplicatedPlaceholders marks a translation that repeats or invents placeholders.
+var ErrDuplicatedPlaceholders = errors.New("translation duplicated placeholders")
+
+// placeholderPattern matches what must reach the catalog unchanged: substitution
+// references (%#@count@, %1$#@count@), %%, %arg, printf-style specifiers
+// (%@, %lld, %1$@, %.2f, ...) and line breaks, escaped or not. A space is not
+// accepted as a flag so that "50% off" is left alone.
+var placeholderPattern = regexp.MustCompile(`%(?:\d+\$)?#@[A-Za-z0-9_]+@|%%|%arg|%(?:\d+\$)?[-+#0']*(?:\d+|\*)?(?:\.(?:\d+|\*))?(?:hh|h|ll|l|q|L|z|t|j)?[@dDiuUxXoOfFeEgGcCsSpaA]|\\n|\n`)
+
+// Mask tokens as providers are expected to return them. The patterns tolerate
+// the spacing and tag forms providers tend to produce.
+var (
+	sentinelToken = regexp.MustCompile(`⟦\s*(\d+)\s*⟧`)
+	xmlToken      = regexp.MustCompile(`<x\s+id\s*=\s*["'](\d+)["']\s*/?>(?:\s*</x>)?`)
+	braceToken    = regexp.MustCompile(`[{｛]\s*(\d+)\s*[}｝]`)
+)
+
+// maskStyle is the token form placeholders are masked as.
+type maskStyle int
+
+const (
+	maskSentinel maskStyle = iota // ⟦n⟧
+	maskXML                       // <x id="n"/> in XML-escaped text
+	maskBraces                    // {n}
+)
+
+func (s maskStyle) token() *regexp.Regexp {
+	switch s {
+	case maskXML:
+		return xmlToken
+	case maskBraces:
+		return braceToken
+	}
+	return sentinelToken
+}
+
+// TagAware is implemented by providers that keep XML tags intact. Their
+// placeholders are masked as <x id="n"/> tags and the text is XML-escaped;
+// other providers get ⟦n⟧ sentinel tokens.
+type TagAware interface {
+	HandlesTags() bool
+}
+
+// BraceMasked is implemented by machine translation providers that mangle the
+// ⟦n⟧ sentinel. Their placeholders are masked as {n}, which such engines pass
+// through, and restored even when they come back spaced or with full-width
+// braces. Texts that already contain braces keep the sentinel.
+type BraceMasked interface {
+	MasksWithBraces() bool
+}
+
+// placeholderMask remembers the placeholders and protected terms replaced in
+// one text. protected marks the ids that stand for protected terms.
+type placeholderMask struct {
+	style        maskStyle
+	placeholders []string
+	protected    map[int]bool
+}
+
+// maskPlaceholders replaces the placeholders and protected terms in req.Text
+// with tokens of the given style and returns the request to send along with the
+// mask that undoes it. Tag-aware providers always get escaped text, so a batch
+// is uniformly XML.
+func maskPlaceholders(req model.TranslationRequest, style maskStyle) (model.TranslationRequest, *placeholderMask) {
+	if style == maskBraces && strings.ContainsAny(req.Text, "{｛") {
+		style = maskSentinel
+	}
+	mask := &placeholderMask{style: style, protected: map[int]bool{}}
+	var out strings.Builder
+	last := 0
+	for _, loc := range maskSpans(req) {
+		mask.write(&out, req.Text[last:loc[0]])
+		id := len(mask.placeholders)
+		mask.placeholders = append(mask.placeholders, req.Text[loc[0]:loc[1]])
+		mask.protected[id] = loc[2] == 1
+		switch style {
+		case maskXML:
+			fmt.Fprintf(&out, `<x id="%d"/>`, id)
+		case maskBraces:
+			fmt.Fprintf(&out, "{%d}", id)
+		default:
+			fmt.Fprintf(&out, "⟦%d⟧", id)
+		}
+		last = loc[1]
+	}
+	mask.write(&out, req.Text[last:])
+
+	if style != maskXML && len(mask.placeholders) == 0 {
+		return req, nil
+	}
+	req.Text = out.String()
+	req.Masked = true
+	return req, mask
+}
+
+// maskSpans returns the spans of req.Text to mask, in order, each with a
+// third element of 1 for protected terms. Placeholders win where they overlap.
+func maskSpans(req model.TranslationRequest) [][]int {
+	var spans [][]int
+	for _, loc := range placeholderPattern.FindAllStringIndex(req.Text, -1) {
+		spans = append(spans, []int{loc[0], loc[1], 0})
+	}
+	for _, loc := range NewProtectedTerms(req.Protected).spans(req.Text) {
+		spans = append(spans, []int{loc[0], loc[1], 1})
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+
+	var kept [][]int
+	end := 0
+	for _, span := range spans {
+		if span[0] >= end {
+			kept = append(kept, span)
+			end = span[1]
+		}
+	}
+	return kept
+}
+
+func (m *placeholderMask) write(out *strings.Builder, text string) {
+	if m.style == maskXML {
+		text = html.EscapeString(text)
+	}
+	out.WriteString(text)
+}
+
+// restore puts the placeholders and protected terms back into a translation.
+// Every placeholder must come back exactly once; otherwise the translation is
+// rejected. A protected term may be dropped or repeated, which QA reports.
+func (m *placeholderMask) restore(translated string) (string, error) {
+	if m == nil {
+		return translated, nil
+	}
+
+	token := m.style.token()
+	seen := make([]int, len(m.placeholders))
+	var extra []string
+	restored := token.ReplaceAllStringFunc(translated, func(match string) string {
+		id, err := strconv.Atoi(token.FindStringSubmatch(match)[1])
+		if err != nil || id >= len(seen) {
+			extra = append(extra, match)
+			return match
+		}
+		seen[id]++
+		return m.placeholders[id]
+	})
+
+	var missing, duplicated []string
+	for id, count := range seen {
+		switch {
+		case m.protected[id]:
+		case count == 0:
+			missing = append(missing, strconv.Quote(m.placeholders[id]))
+		case count > 1:
+			duplicated = append(duplicated, strconv.Quote(m.placeholders[id]))
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%w %s", ErrDroppedPlaceholders, strings.Join(missing, ", "))
+	}
+	if len(duplicated) > 0 || len(extra) > 0 {
+		sort.Strings(extra)
+		return "", fmt.Errorf("%w %s", ErrDuplicatedPlaceholders, strings.Join(append(duplicated, extra...), ", "))
+	}
+
+	if m.style == maskXML {
+		// Unescape only the text between placeholders, which may contain "&".
+		parts := token.Split(translated, -1)
+		var out strings.Builder
+		for i, match := range token.FindAllStringSubmatch(translated, -1) {
+			out.WriteString(html.UnescapeString(parts[i]))
+			id, _ := strconv.Atoi(match[1])
+			out.WriteString(m.placeholders[id])
+		}
+		out.WriteString(html.UnescapeString(parts[len(parts)-1]))
+		return out.String(), nil
+	}
+	return restored, nil
+}
+
+// maskedTokens lists the mask tokens in a masked text, for prompt notes.
+func maskedTokens(text string) []string {
+	return sentinelToken.FindAllString(text, -1)
+}
@@ -0,0 +1,111 @@
+package translator
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+func TestMaskPlaceholders(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		protected    []string
+		style        maskStyle
+		want         string
+		placeholders []string
+	}{
+		{"object", "Hello, %@!", nil, maskSentinel, "Hello, ⟦0⟧!", []string{"%@"}},
+		{"positional", "%2$@ sent %1$lld files", nil, maskSentinel, "⟦0⟧ sent ⟦1⟧ files", []string{"%2$@", "%1$lld"}},
+		{"adjacent", "%@%@", nil, maskSentinel, "⟦0⟧⟦1⟧", []string{"%@", "%@"}},
+		{"adjacent positional", "%1$@%2$@", nil, maskSentinel, "⟦0⟧⟦1⟧", []string{"%1$@", "%2$@"}},
+		{"literal percent", "100%% sure", nil, maskSentinel, "100⟦0⟧ sure", []string{"%%"}},
+		{"percent after specifier", "%lld%%", nil, maskSentinel, "⟦0⟧⟦1⟧", []string{"%lld", "%%"}},
+		{"percent before specifier", "%%%d", nil, maskSentinel, "⟦0⟧⟦1⟧", []string{"%%", "%d"}},
+		{"substitution", "You have %#@photos@ in %1$#@albums@", nil, maskSentinel, "You have ⟦0⟧ in ⟦1⟧", []string{"%#@photos@", "%1$#@albums@"}},
+		{"precision and width", "%.2f of %5d", nil, maskSentinel, "⟦0⟧ of ⟦1⟧", []string{"%.2f", "%5d"}},
+		{"arg", "%arg photos", nil, maskSentinel, "⟦0⟧ photos", []string{"%arg"}},
+		{"line breaks", "One\nTwo\\nThree", nil, maskSentinel, "One⟦0⟧Two⟦1⟧Three", []string{"\n", `\n`}},
+		{"bare percent", "50% off", nil, maskSentinel, "50% off", nil},
+		{"protected term", "Open iCloud Drive for %@", []string{"iCloud Drive"}, maskSentinel, "Open ⟦0⟧ for ⟦1⟧", []string{"iCloud Drive", "%@"}},
+		{"xml", "Tom & %1$@ <b>", nil, maskXML, `Tom &amp; <x id="0"/> &lt;b&gt;`, []string{"%1$@"}},
+		{"xml without placeholders", "A & B", nil, maskXML, "A &amp; B", nil},
+		{"braces", "%1$@ sent %2$lld files", nil, maskBraces, "{0} sent {1} files", []string{"%1$@", "%2$lld"}},
+		{"braces in the text", "{name} sent %lld files", nil, maskBraces, "{name} sent ⟦0⟧ files", []string{"%lld"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := model.TranslationRequest{Text: tt.text, Protected: tt.protected}
+			sent, mask := maskPlaceholders(req, tt.style)
+			if sent.Text != tt.want {
+				t.Errorf("masked text = %q, want %q", sent.Text, tt.want)
+			}
+			var placeholders []string
+			if mask != nil {
+				placeholders = mask.placeholders
+			}
+			if !slices.Equal(placeholders, tt.placeholders) {
+				t.Errorf("placeholders = %q, want %q", placeholders, tt.placeholders)
+			}
+			if sent.Masked != (mask != nil) {
+				t.Errorf("Masked = %v with mask %v", sent.Masked, mask)
+			}
+		})
+	}
+}
+
+func TestRestorePlaceholders(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		protected  []string
+		style      maskStyle
+		translated string
+		want       string
+		err        error
+	}{
+		{"in order", "Hello, %@!", nil, maskSentinel, "Hallo, ⟦0⟧!", "Hallo, %@!", nil},
+		{"reordered", "%1$@ sent %2$lld files", nil, maskSentinel, "⟦1⟧ Dateien von ⟦0⟧", "%2$lld Dateien von %1$@", nil},
+		{"adjacent", "%@%@", nil, maskSentinel, "⟦1⟧⟦0⟧", "%@%@", nil},
+		{"adjacent percent", "%lld%%", nil, maskSentinel, "⟦0⟧ ⟦1⟧", "%lld %%", nil},
+		{"spaced token", "%d items", nil, maskSentinel, "⟦ 0 ⟧ Elemente", "%d Elemente", nil},
+		{"dropped", "%1$@ and %2$@", nil, maskSentinel, "⟦0⟧ und", "", ErrDroppedPlaceholders},
+		{"dropped percent", "100%% sure", nil, maskSentinel, "100 sicher", "", ErrDroppedPlaceholders},
+		{"duplicated", "%@", nil, maskSentinel, "⟦0⟧ ⟦0⟧", "", ErrDuplicatedPlaceholders},
+		{"invented", "%@", nil, maskSentinel, "⟦0⟧ ⟦1⟧", "", ErrDuplicatedPlaceholders},
+		{"protected term dropped", "Open iCloud for %@", []string{"iCloud"}, maskSentinel, "Öffne ⟦1⟧", "Öffne %@", nil},
+		{"protected term repeated", "iCloud %@", []string{"iCloud"}, maskSentinel, "⟦0⟧ ⟦1⟧ ⟦0⟧", "iCloud %@ iCloud", nil},
+		{"xml", "Tom & %1$@", nil, maskXML, `Tom &amp; <x id="0"></x>`, "Tom & %1$@", nil},
+		{"xml keeps escapes in placeholders", "%1$@ & %%", nil, maskXML, `<x id='1'/> &amp; <x id="0"/>`, "%% & %1$@", nil},
+		{"xml dropped", "%@ & %@", nil, maskXML, `<x id="0"/> &amp;`, "", ErrDroppedPlaceholders},
+		{"braces", "%1$@ sent %2$lld files", nil, maskBraces, "{1}个文件由{0}发送", "%2$lld个文件由%1$@发送", nil},
+		{"spaced braces", "%d items", nil, maskBraces, "{ 0 } Elemente", "%d Elemente", nil},
+		{"full-width braces", "%d items", nil, maskBraces, "｛0｝个项目", "%d个项目", nil},
+		{"braces dropped", "%1$@ and %2$@", nil, maskBraces, "{0} und", "", ErrDroppedPlaceholders},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := model.TranslationRequest{Text: tt.text, Protected: tt.protected}
+			_, mask := maskPlaceholders(req, tt.style)
+			got, err := mask.restore(tt.translated)
+			if !errors.Is(err, tt.err) || (err != nil && tt.err == nil) {
+				t.Fatalf("restore(%q) error = %v, want %v", tt.translated, err, tt.err)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("restore(%q) = %q, want %q", tt.translated, got, tt.want)
+			}
+			if err != nil && !IsRejected(err) {
+				t.Errorf("restore error %v is not a rejection", err)
+			}
+		})
+	}
+}
+
+func TestRestoreWithoutMask(t *testing.T) {
+	var mask *placeholderMask
+	if got, err := mask.restore("⟦0⟧ as is"); got != "⟦0⟧ as is" || err != nil {
+		t.Errorf("nil mask restore = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,92 @@
+package translator
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// LanguagePlan estimates what translating one language would send to the
+// provider, worked out without calling it.
+type LanguagePlan struct {
+	Language string
+	// Strings counts the units that need translating.
+	Strings int
+	// Duplicates reuse the translation of another unit in the same run, and
+	// Cached come from the translation memory; neither is sent.
+	Duplicates int
+	Cached     int
+	// Sent counts the units sent, in Calls provider calls.
+	Sent  int
+	Calls int
+	// Usage is the estimated billable usage of the units sent.
+	Usage model.Usage
+	// Cost is priced from Usage; nil when the provider has no price.
+	Cost *float64
+}
+
+// Plan works out what TranslateBatch would send for requests, all in
+// language: duplicates and memory hits are left out and the rest is packed
+// into calls as for a real run. Costs are estimated with prices.
+func (s *TranslationService) Plan(language string, requests []model.TranslationRequest, prices PriceTable) LanguagePlan {
+	plan := LanguagePlan{Language: language, Strings: len(requests)}
+	unique, _ := groupDuplicates(s.annotate(requests))
+	plan.Duplicates = len(requests) - len(unique)
+
+	var pending []model.TranslationRequest
+	for _, req := range unique {
+		if req.Current == "" && s.Memory.Contains(req) {
+			plan.Cached++
+			continue
+		}
+		pending = append(pending, req)
+		usage := EstimateUsage(req)
+		plan.Usage.Characters += usage.Characters
+		plan.Usage.PromptTokens += usage.PromptTokens
+		plan.Usage.CompletionTokens += usage.CompletionTokens
+	}
+	plan.Sent = len(pending)
+	plan.Calls = len(s.packBatches(pending))
+
+	if price, ok := prices.Lookup(s.Name, ModelName(s.Provider)); ok {
+		cost := price.Cost(plan.Usage)
+		plan.Cost = &cost
+	}
+	return plan
+}
+
+// WritePlan writes plans as a table with a total row.
+func WritePlan(w io.Writer, plans []LanguagePlan) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Language\tStrings\tDuplicates\tIn memory\tTo send\tCalls\tCharacters\tEst. tokens\tEst. cost\t")
+
+	total := LanguagePlan{Language: "Total"}
+	for _, plan := range plans {
+		writePlanRow(tw, plan)
+		total.Strings += plan.Strings
+		total.Duplicates += plan.Duplicates
+		total.Cached += plan.Cached
+		total.Sent += plan.Sent
+		total.Calls += plan.Calls
+		total.Usage.Characters += plan.Usage.Characters
+		total.Usage.PromptTokens += plan.Usage.PromptTokens
+		total.Usage.CompletionTokens += plan.Usage.CompletionTokens
+		if plan.Cost != nil {
+			if total.Cost == nil {
+				total.Cost = new(float64)
+			}
+			*total.Cost += *plan.Cost
+		}
+	}
+	if len(plans) > 1 {
+		writePlanRow(tw, total)
+	}
+	return tw.Flush()
+}
+
+func writePlanRow(w io.Writer, plan LanguagePlan) {
+	fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t\n", plan.Language, plan.Strings, plan.Duplicates, plan.Cached,
+		plan.Sent, plan.Calls, plan.Usage.Characters, plan.Usage.PromptTokens+plan.Usage.CompletionTokens, formatCost(plan.Cost))
+}
@@ -0,0 +1,125 @@
+package translator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+func TestPlan(t *testing.T) {
+	provider := &fakeBatchProvider{limits: model.BatchLimits{MaxItems: 2}}
+	service := newTestService(provider)
+	service.Memory = openTestMemory(t).Scope("fake", provider)
+	service.Memory.Store(request("", "Cancel", "de"), "Abbrechen")
+
+	retranslated := request("save.again", "Save", "de")
+	retranslated.Current = "Sichern"
+	requests := []model.TranslationRequest{
+		request("save", "Save", "de"),
+		request("save.toolbar", "Save", "de"), // a duplicate of save
+		request("cancel", "Cancel", "de"),     // in memory
+		request("open", "Open", "de"),
+		request("delete", "Delete", "de"),
+		retranslated, // never answered from memory, nor a duplicate of save
+	}
+
+	plan := service.Plan("de", requests, PriceTable{"fake": {PerMillionCharacters: 1e6}})
+	want := LanguagePlan{Language: "de", Strings: 6, Duplicates: 1, Cached: 1, Sent: 4, Calls: 2,
+		Usage: model.Usage{Characters: 18}}
+	got := plan
+	got.Cost = nil
+	got.Usage.PromptTokens, got.Usage.CompletionTokens = 0, 0
+	if got != want {
+		t.Errorf("Plan = %+v, want %+v", got, want)
+	}
+	if plan.Cost == nil || *plan.Cost != 18 {
+		t.Errorf("Cost = %v, want 18", plan.Cost)
+	}
+	if plan.Usage.CompletionTokens != 5 || plan.Usage.PromptTokens != 4*promptOverheadTokens+5 {
+		t.Errorf("tokens = %d prompt, %d completion", plan.Usage.PromptTokens, plan.Usage.CompletionTokens)
+	}
+	if provider.calls() != 0 || len(provider.batches) != 0 {
+		t.Error("Plan called the provider")
+	}
+
+	if plan := service.Plan("de", requests, PriceTable{}); plan.Cost != nil {
+		t.Errorf("Cost without a price = %v, want nil", *plan.Cost)
+	}
+}
+
+func TestWritePlan(t *testing.T) {
+	cost := 0.5
+	plans := []LanguagePlan{
+		{Language: "de", Strings: 4, Duplicates: 1, Sent: 3, Calls: 1, Usage: model.Usage{Characters: 30}, Cost: &cost},
+		{Language: "ja", Strings: 4, Cached: 2, Sent: 2, Calls: 2, Usage: model.Usage{Characters: 20}},
+	}
+	var out bytes.Buffer
+	if err := WritePlan(&out, plans); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("plan has %d lines, want a header, two languages and a total:\n%s", len(lines), out.String())
+	}
+	total := strings.Fields(lines[3])
+	if want := []string{"Total", "8", "1", "2", "5", "3", "50", "0"}; strings.Join(total[:8], " ") != strings.Join(want, " ") {
+		t.Errorf("total row = %q, want it to start with %q", total, want)
+	}
+
+	out.Reset()
+	WritePlan(&out, plans[:1])
+	if strings.Contains(out.String(), "Total") {
+		t.Errorf("a single language has a total row:\n%s", out.String())
+	}
+}
+
+func TestPriceTable(t *testing.T) {
+	prices := PriceTable{
+		"deepl":         {PerMillionCharacters: 20},
+		"openai":        {PerMillionInputTokens: 0.5, PerMillionOutputTokens: 1.5},
+		"openai/gpt-4o": {PerMillionInputTokens: 2.5, PerMillionOutputTokens: 10},
+	}
+	tests := []struct {
+		provider, model string
+		want            Price
+		wantOK          bool
+	}{
+		{"deepl", "", prices["deepl"], true},
+		{"openai", "gpt-4o", prices["openai/gpt-4o"], true},
+		{"openai", "GPT-4o", prices["openai/gpt-4o"], true},
+		{"openai", "gpt-3.5-turbo", prices["openai"], true},
+		{"google", "", Price{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := prices.Lookup(tt.provider, tt.model)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Lookup(%s, %s) = %+v, %v", tt.provider, tt.model, got, ok)
+		}
+	}
+
+	usage := model.Usage{Characters: 1000, PromptTokens: 2_000_000, CompletionTokens: 1_000_000}
+	if got := prices["openai/gpt-4o"].Cost(usage); got != 15 {
+		t.Errorf("token Cost = %v, want 15", got)
+	}
+	if got := prices["deepl"].Cost(usage); got != 0.02 {
+		t.Errorf("character Cost = %v, want 0.02", got)
+	}
+}
+
+func TestEstimateUsage(t *testing.T) {
+	tests := []struct {
+		req  model.TranslationRequest
+		want model.Usage
+	}{
+		{model.TranslationRequest{Text: "Save"}, model.Usage{Characters: 4, PromptTokens: promptOverheadTokens + 1, CompletionTokens: 1}},
+		{model.TranslationRequest{Text: "Save all", Comment: "Toolbar button"}, model.Usage{Characters: 8, PromptTokens: promptOverheadTokens + 4 + 2, CompletionTokens: 2}},
+		{model.TranslationRequest{Text: "保存"}, model.Usage{Characters: 2, PromptTokens: promptOverheadTokens + 2, CompletionTokens: 2}},
+	}
+	for _, tt := range tests {
+		if got := EstimateUsage(tt.req); got != tt.want {
+			t.Errorf("EstimateUsage(%q) = %+v, want %+v", tt.req.Text, got, tt.want)
+		}
+	}
+}
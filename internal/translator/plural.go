@@ -0,0 +1,78 @@
+package translator
+
+import (
+	"sort"
+	"strings"
+)
+
+// pluralCategoryOrder is the canonical CLDR ordering of plural categories.
+var pluralCategoryOrder = []string{"zero", "one", "two", "few", "many", "other"}
+
+// cldrPluralCategories maps a base language code to the cardinal plural
+// categories CLDR defines for it. Languages not listed use one/other.
+var cldrPluralCategories = map[string][]string{}
+
+func init() {
+	groups := []struct {
+		categories []string
+		languages  string
+	}{
+		{[]string{"other"}, "bo dz id ig ii in ja jbo jv jw kde kea km ko lkt lo ms my nqo osa sah ses sg su th to tpi vi wo yo yue zh"},
+		{[]string{"one", "many", "other"}, "ca es fr it pt vec"},
+		{[]string{"one", "few", "other"}, "bs hr mo ro sh sr"},
+		{[]string{"one", "few", "many", "other"}, "be cs lt pl ru sk uk"},
+		{[]string{"one", "two", "other"}, "he iu naq sat se sma smi smj smn sms"},
+		{[]string{"one", "two", "few", "other"}, "dsb gd hsb sl"},
+		{[]string{"one", "two", "few", "many", "other"}, "br ga gv mt"},
+		{[]string{"zero", "one", "other"}, "ksh lag lv prg"},
+		{[]string{"zero", "one", "two", "few", "many", "other"}, "ar ars cy kw"},
+	}
+	for _, group := range groups {
+		for _, lang := range strings.Fields(group.languages) {
+			cldrPluralCategories[lang] = group.categories
+		}
+	}
+}
+
+// PluralCategories returns the CLDR cardinal plural categories required by a
+// language, e.g. one/few/many/other for "ru" and only other for "ja".
+func PluralCategories(language string) []string {
+	base := strings.ToLower(language)
+	if i := strings.IndexAny(base, "-_"); i >= 0 {
+		base = base[:i]
+	}
+	if categories, ok := cldrPluralCategories[base]; ok {
+		return categories
+	}
+	return []string{"one", "other"}
+}
+
+// pluralSourceFor picks the source form to translate for a target category:
+// the same category when the source has it, otherwise "other".
+func pluralSourceFor(forms map[string]string, category string) string {
+	if text, ok := forms[category]; ok {
+		return text
+	}
+	if text, ok := forms["other"]; ok {
+		return text
+	}
+	// Fall back to the first form in canonical order.
+	names := make([]string, 0, len(forms))
+	for name := range forms {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return pluralRank(names[i]) < pluralRank(names[j]) })
+	if len(names) > 0 {
+		return forms[names[0]]
+	}
+	return ""
+}
+
+func pluralRank(category string) int {
+	for i, name := range pluralCategoryOrder {
+		if name == category {
+			return i
+		}
+	}
+	return len(pluralCategoryOrder)
+}
@@ -0,0 +1,57 @@
+package translator
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPluralCategories(t *testing.T) {
+	tests := []struct {
+		language string
+		want     []string
+	}{
+		{"en", []string{"one", "other"}},
+		{"de", []string{"one", "other"}},
+		{"ja", []string{"other"}},
+		{"ko", []string{"other"}},
+		{"zh-Hans", []string{"other"}},
+		{"zh_Hant_TW", []string{"other"}},
+		{"fr", []string{"one", "many", "other"}},
+		{"pt-BR", []string{"one", "many", "other"}},
+		{"hr", []string{"one", "few", "other"}},
+		{"ru", []string{"one", "few", "many", "other"}},
+		{"pl", []string{"one", "few", "many", "other"}},
+		{"he", []string{"one", "two", "other"}},
+		{"sl", []string{"one", "two", "few", "other"}},
+		{"ga", []string{"one", "two", "few", "many", "other"}},
+		{"lv", []string{"zero", "one", "other"}},
+		{"ar", []string{"zero", "one", "two", "few", "many", "other"}},
+		{"CY", []string{"zero", "one", "two", "few", "many", "other"}},
+		// Languages CLDR does not list here fall back to one/other.
+		{"xx", []string{"one", "other"}},
+		{"", []string{"one", "other"}},
+	}
+	for _, tt := range tests {
+		if got := PluralCategories(tt.language); !slices.Equal(got, tt.want) {
+			t.Errorf("PluralCategories(%q) = %v, want %v", tt.language, got, tt.want)
+		}
+	}
+}
+
+func TestPluralSourceFor(t *testing.T) {
+	tests := []struct {
+		forms    map[string]string
+		category string
+		want     string
+	}{
+		{map[string]string{"one": "%d file", "other": "%d files"}, "one", "%d file"},
+		{map[string]string{"one": "%d file", "other": "%d files"}, "few", "%d files"},
+		{map[string]string{"few": "few", "one": "one"}, "many", "one"},
+		{map[string]string{}, "other", ""},
+	}
+	for _, tt := range tests {
+		if got := pluralSourceFor(tt.forms, tt.category); got != tt.want {
+			t.Errorf("pluralSourceFor(%v, %q) = %q, want %q", tt.forms, tt.category, got, tt.want)
+		}
+	}
+}
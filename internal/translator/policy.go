@@ -0,0 +1,99 @@
+package translator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// Translation states Xcode writes into a stringUnit.
+const (
+	StateNew         = "new"
+	StateNeedsReview = "needs_review"
+	StateStale       = "stale"
+	StateTranslated  = "translated"
+)
+
+// DefaultOutputState marks machine translations as needing review, so reviewers
+// can tell them apart from human-approved strings.
+const DefaultOutputState = StateNeedsReview
+
+// ValidateOutputState checks that state is one Xcode understands for a written unit.
+func ValidateOutputState(state string) error {
+	switch state {
+	case "", StateNew, StateNeedsReview, StateTranslated:
+		return nil
+	default:
+		return fmt.Errorf("invalid output state %q (use needs_review, translated or new)", state)
+	}
+}
+
+// RetranslatePolicy decides which existing target units are translated again.
+// Missing units and units in the "new" state are always translated; the zero
+// value leaves every other unit alone.
+type RetranslatePolicy struct {
+	All    bool
+	States map[string]bool
+}
+
+// ParseRetranslatePolicy parses values such as "needs_review,stale" or "all".
+// "none" (or no value) selects the default policy.
+func ParseRetranslatePolicy(values []string) (RetranslatePolicy, error) {
+	policy := RetranslatePolicy{}
+	for _, value := range values {
+		for _, state := range strings.Split(value, ",") {
+			state = strings.ToLower(strings.TrimSpace(state))
+			switch state {
+			case "", "none":
+			case "all":
+				policy.All = true
+			case StateNew, StateNeedsReview, StateStale, StateTranslated:
+				if policy.States == nil {
+					policy.States = make(map[string]bool)
+				}
+				policy.States[state] = true
+			default:
+				return RetranslatePolicy{}, fmt.Errorf("unknown retranslate state %q (use new, needs_review, stale, translated or all)", state)
+			}
+		}
+	}
+	return policy, nil
+}
+
+// String lists the states the policy translates, for verbose output.
+func (p RetranslatePolicy) String() string {
+	if p.All {
+		return "all"
+	}
+	states := []string{"missing", StateNew}
+	var extra []string
+	for state := range p.States {
+		if state != StateNew {
+			extra = append(extra, state)
+		}
+	}
+	sort.Strings(extra)
+	return strings.Join(append(states, extra...), ",")
+}
+
+// NeedsTranslation reports whether a target unit of entry should be (re)translated.
+// A nil unit means the target has no translation at that path yet.
+func (p RetranslatePolicy) NeedsTranslation(entry model.StringEntry, unit *model.StringUnit) bool {
+	if unit == nil || unit.Value == "" {
+		return true
+	}
+	if p.All {
+		return true
+	}
+	state := unit.State
+	if state == "" || state == StateNew {
+		return true
+	}
+	if p.States[state] {
+		return true
+	}
+	// Xcode marks keys that disappeared from source code as stale at the entry level.
+	return p.States[StateStale] && entry.ExtractionState == StateStale
+}
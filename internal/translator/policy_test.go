@@ -0,0 +1,140 @@
+package translator
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+func TestParseRetranslatePolicy(t *testing.T) {
+	tests := []struct {
+		values  []string
+		want    string
+		wantErr bool
+	}{
+		{nil, "missing,new", false},
+		{[]string{"none"}, "missing,new", false},
+		{[]string{"needs_review,stale"}, "missing,new,needs_review,stale", false},
+		{[]string{"Translated", " stale "}, "missing,new,stale,translated", false},
+		{[]string{"all"}, "all", false},
+		{[]string{"reviewed"}, "", true},
+	}
+	for _, tt := range tests {
+		policy, err := ParseRetranslatePolicy(tt.values)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRetranslatePolicy(%q) error = %v", tt.values, err)
+			continue
+		}
+		if err == nil && policy.String() != tt.want {
+			t.Errorf("ParseRetranslatePolicy(%q) = %s, want %s", tt.values, policy, tt.want)
+		}
+	}
+}
+
+func TestNeedsTranslation(t *testing.T) {
+	unit := func(state, value string) *model.StringUnit {
+		return &model.StringUnit{State: state, Value: value}
+	}
+	stale := model.StringEntry{ExtractionState: StateStale}
+	tests := []struct {
+		name   string
+		policy []string
+		entry  model.StringEntry
+		unit   *model.StringUnit
+		want   bool
+	}{
+		{"missing", nil, model.StringEntry{}, nil, true},
+		{"empty value", nil, model.StringEntry{}, unit(StateTranslated, ""), true},
+		{"new", nil, model.StringEntry{}, unit(StateNew, "Hallo"), true},
+		{"no state", nil, model.StringEntry{}, unit("", "Hallo"), true},
+		{"needs review kept", nil, model.StringEntry{}, unit(StateNeedsReview, "Hallo"), false},
+		{"translated kept", nil, model.StringEntry{}, unit(StateTranslated, "Hallo"), false},
+		{"needs review retranslated", []string{"needs_review"}, model.StringEntry{}, unit(StateNeedsReview, "Hallo"), true},
+		{"translated not in policy", []string{"needs_review"}, model.StringEntry{}, unit(StateTranslated, "Hallo"), false},
+		{"stale unit", []string{"stale"}, model.StringEntry{}, unit(StateStale, "Hallo"), true},
+		{"stale entry", []string{"stale"}, stale, unit(StateTranslated, "Hallo"), true},
+		{"stale entry kept", nil, stale, unit(StateTranslated, "Hallo"), false},
+		{"all", []string{"all"}, model.StringEntry{}, unit(StateTranslated, "Hallo"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParseRetranslatePolicy(tt.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := policy.NeedsTranslation(tt.entry, tt.unit); got != tt.want {
+				t.Errorf("NeedsTranslation = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateTranslationRequestsPolicy(t *testing.T) {
+	xcstrings := parseCatalog(t, `{
+  "Missing": {},
+  "New": {"localizations": {"de": {"stringUnit": {"state": "new", "value": "Neu"}}}},
+  "Review": {"localizations": {"de": {"stringUnit": {"state": "needs_review", "value": "Prüfen"}}}},
+  "Done": {"localizations": {"de": {"stringUnit": {"state": "translated", "value": "Fertig"}}}},
+  "Gone": {"extractionState": "stale", "localizations": {"de": {"stringUnit": {"state": "translated", "value": "Weg"}}}},
+  "%lld files": {"localizations": {
+    "en": {"variations": {"plural": {
+      "one": {"stringUnit": {"state": "translated", "value": "%lld file"}},
+      "other": {"stringUnit": {"state": "translated", "value": "%lld files"}}
+    }}},
+    "de": {"variations": {"plural": {
+      "one": {"stringUnit": {"state": "translated", "value": "%lld Datei"}}
+    }}}
+  }}
+}`)
+	tests := []struct {
+		policy []string
+		want   []string
+	}{
+		{nil, []string{"%lld files|plural.other|", "Missing||", "New||Neu"}},
+		{[]string{"needs_review"}, []string{"%lld files|plural.other|", "Missing||", "New||Neu", "Review||Prüfen"}},
+		{[]string{"stale"}, []string{"%lld files|plural.other|", "Gone||Weg", "Missing||", "New||Neu"}},
+		{[]string{"all"}, []string{"%lld files|plural.one|%lld Datei", "%lld files|plural.other|", "Done||Fertig", "Gone||Weg", "Missing||", "New||Neu", "Review||Prüfen"}},
+	}
+	for _, tt := range tests {
+		policy, err := ParseRetranslatePolicy(tt.policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, req := range CreateTranslationRequestsForLanguage(xcstrings, "de", policy) {
+			got = append(got, req.Key+"|"+req.Path+"|"+req.Current)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("policy %q: requests (key|path|current) = %q, want %q", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestApplyTranslationsOutputState(t *testing.T) {
+	for _, state := range []string{"", StateTranslated, StateNew} {
+		xcstrings := parseCatalog(t, `{"Hello": {}}`)
+		ApplyTranslations(xcstrings, []model.TranslationResponse{{Key: "Hello", TargetLanguage: "de", TranslatedText: "Hallo"}}, state)
+		want := state
+		if want == "" {
+			want = StateNeedsReview
+		}
+		if got := xcstrings.Strings["Hello"].Localizations["de"].State(); got != want {
+			t.Errorf("output state %q: unit state = %q, want %q", state, got, want)
+		}
+	}
+}
+
+func TestValidateOutputState(t *testing.T) {
+	for _, state := range []string{"", StateNew, StateNeedsReview, StateTranslated} {
+		if err := ValidateOutputState(state); err != nil {
+			t.Errorf("ValidateOutputState(%q) = %v", state, err)
+		}
+	}
+	for _, state := range []string{StateStale, "reviewed"} {
+		if err := ValidateOutputState(state); err == nil {
+			t.Errorf("ValidateOutputState(%q) accepted it", state)
+		}
+	}
+}
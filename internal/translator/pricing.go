@@ -0,0 +1,58 @@
+package translator
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// Price is what a provider charges, in whatever currency the table is kept in.
+type Price struct {
+	PerMillionCharacters   float64 `json:"perMillionCharacters,omitempty"`
+	PerMillionInputTokens  float64 `json:"perMillionInputTokens,omitempty"`
+	PerMillionOutputTokens float64 `json:"perMillionOutputTokens,omitempty"`
+}
+
+// Cost returns the price of usage.
+func (p Price) Cost(usage model.Usage) float64 {
+	return (float64(usage.Characters)*p.PerMillionCharacters +
+		float64(usage.PromptTokens)*p.PerMillionInputTokens +
+		float64(usage.CompletionTokens)*p.PerMillionOutputTokens) / 1e6
+}
+
+// PriceTable maps provider names to their price. A model priced differently
+// from the rest of its provider is listed as "provider/model".
+type PriceTable map[string]Price
+
+// Lookup returns the price of provider's model, falling back to the
+// provider's own price, or false when neither is listed.
+func (t PriceTable) Lookup(provider, modelName string) (Price, bool) {
+	if modelName != "" {
+		if price, ok := t[provider+"/"+strings.ToLower(modelName)]; ok {
+			return price, true
+		}
+	}
+	price, ok := t[provider]
+	return price, ok
+}
+
+// EstimateUsage sizes req before it is sent: its characters, and for language
+// models a prompt of the instructions, comment and text with a completion as
+// long as the text.
+func EstimateUsage(req model.TranslationRequest) model.Usage {
+	text := countTokens(req.Text)
+	return model.Usage{
+		Characters:       utf8.RuneCountInString(req.Text),
+		PromptTokens:     promptOverheadTokens + countTokens(req.Comment) + text,
+		CompletionTokens: text,
+	}
+}
+
+// ModelName names the model behind provider, or "" when it has none.
+func ModelName(provider model.TranslationProvider) string {
+	if fp, ok := provider.(Fingerprinted); ok {
+		return fp.ModelName()
+	}
+	return ""
+}
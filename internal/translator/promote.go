@@ -0,0 +1,101 @@
+package translator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// PromoteOptions selects which units PromoteStates moves between states.
+type PromoteOptions struct {
+	// Languages limits promotion to these languages; empty means every
+	// language except the source language.
+	Languages []string
+	// KeyPatterns are glob patterns ("*" and "?") matched against string keys;
+	// empty matches every key.
+	KeyPatterns []string
+	From        string
+	To          string
+}
+
+// PromoteStates moves units in opts.From to opts.To and returns how many units changed.
+func PromoteStates(xcstrings *model.XCStrings, opts PromoteOptions) (int, error) {
+	if opts.From == "" {
+		opts.From = StateNeedsReview
+	}
+	if opts.To == "" {
+		opts.To = StateTranslated
+	}
+	if err := ValidateOutputState(opts.To); err != nil {
+		return 0, err
+	}
+
+	var patterns []*regexp.Regexp
+	for _, pattern := range opts.KeyPatterns {
+		re, err := globToRegexp(pattern)
+		if err != nil {
+			return 0, fmt.Errorf("invalid key pattern %q: %v", pattern, err)
+		}
+		patterns = append(patterns, re)
+	}
+
+	languages := map[string]bool{}
+	for _, lang := range opts.Languages {
+		languages[lang] = true
+	}
+
+	promoted := 0
+	for key, entry := range xcstrings.Strings {
+		if !matchesAny(patterns, key) {
+			continue
+		}
+		for lang, loc := range entry.Localizations {
+			if len(languages) > 0 && !languages[lang] {
+				continue
+			}
+			if len(languages) == 0 && lang == xcstrings.SourceLanguage {
+				continue
+			}
+
+			changed := false
+			for _, ref := range loc.Units() {
+				if ref.Unit.State != opts.From {
+					continue
+				}
+				unit := ref.Unit
+				unit.State = opts.To
+				loc.SetUnit(ref.Path, unit)
+				changed = true
+				promoted++
+			}
+			if changed {
+				entry.Localizations[lang] = loc
+			}
+		}
+	}
+
+	return promoted, nil
+}
+
+func matchesAny(patterns []*regexp.Regexp, key string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, re := range patterns {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// globToRegexp converts a shell-style glob into an anchored regular expression.
+// Unlike path.Match, "*" also matches "/" and ".", which are common in keys.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	quoted := regexp.QuoteMeta(pattern)
+	quoted = strings.ReplaceAll(quoted, `\*`, ".*")
+	quoted = strings.ReplaceAll(quoted, `\?`, ".")
+	return regexp.Compile("^" + quoted + "$")
+}
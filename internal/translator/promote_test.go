@@ -0,0 +1,123 @@
+package translator
+
+import (
+	"slices"
+	"testing"
+)
+
+const promoteCatalog = `{
+  "Hello": {"localizations": {
+    "en": {"stringUnit": {"state": "needs_review", "value": "Hello"}},
+    "de": {"stringUnit": {"state": "needs_review", "value": "Hallo"}},
+    "fr": {"stringUnit": {"state": "needs_review", "value": "Bonjour"}}
+  }},
+  "settings.title": {"localizations": {
+    "de": {"stringUnit": {"state": "translated", "value": "Einstellungen"}},
+    "fr": {"stringUnit": {"state": "new", "value": "Réglages"}}
+  }},
+  "%lld files": {"localizations": {
+    "de": {"variations": {"plural": {
+      "one": {"stringUnit": {"state": "needs_review", "value": "%lld Datei"}},
+      "other": {"stringUnit": {"state": "needs_review", "value": "%lld Dateien"}}
+    }}},
+    "fr": {"variations": {"device": {
+      "mac": {"stringUnit": {"state": "needs_review", "value": "Cliquer"}}
+    }}}
+  }}
+}`
+
+func TestPromoteStates(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     PromoteOptions
+		promoted int
+		// want lists the units in the target state afterwards, as "key|language|path".
+		want []string
+	}{
+		{"defaults skip the source language", PromoteOptions{}, 5, []string{
+			"%lld files|de|plural.one", "%lld files|de|plural.other", "%lld files|fr|device.mac",
+			"Hello|de|", "Hello|fr|", "settings.title|de|",
+		}},
+		{"one language", PromoteOptions{Languages: []string{"de"}}, 3, []string{
+			"%lld files|de|plural.one", "%lld files|de|plural.other", "Hello|de|", "settings.title|de|",
+		}},
+		{"source language when asked", PromoteOptions{Languages: []string{"en"}}, 1, []string{
+			"Hello|en|", "settings.title|de|",
+		}},
+		{"key pattern", PromoteOptions{KeyPatterns: []string{"%lld *"}}, 3, []string{
+			"%lld files|de|plural.one", "%lld files|de|plural.other", "%lld files|fr|device.mac", "settings.title|de|",
+		}},
+		{"from new", PromoteOptions{From: StateNew}, 1, []string{
+			"settings.title|de|", "settings.title|fr|",
+		}},
+		{"to new", PromoteOptions{Languages: []string{"fr"}, KeyPatterns: []string{"Hel?o"}, To: StateNew}, 1, []string{
+			"Hello|fr|", "settings.title|fr|",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xcstrings := parseCatalog(t, promoteCatalog)
+			promoted, err := PromoteStates(xcstrings, tt.opts)
+			if err != nil {
+				t.Fatalf("PromoteStates: %v", err)
+			}
+			if promoted != tt.promoted {
+				t.Errorf("promoted %d units, want %d", promoted, tt.promoted)
+			}
+
+			to := tt.opts.To
+			if to == "" {
+				to = StateTranslated
+			}
+			var got []string
+			for key, entry := range xcstrings.Strings {
+				for language, loc := range entry.Localizations {
+					for _, ref := range loc.Units() {
+						if ref.Unit.State == to {
+							got = append(got, key+"|"+language+"|"+ref.Path)
+						}
+					}
+				}
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("units in %s = %q, want %q", to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromoteStatesInvalid(t *testing.T) {
+	xcstrings := parseCatalog(t, promoteCatalog)
+	if _, err := PromoteStates(xcstrings, PromoteOptions{To: StateStale}); err == nil {
+		t.Error("PromoteStates accepted stale as the target state")
+	}
+	if _, err := PromoteStates(xcstrings, PromoteOptions{KeyPatterns: []string{"[a-"}}); err != nil {
+		t.Errorf("PromoteStates rejected a pattern with regexp syntax: %v", err)
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		pattern, key string
+		want         bool
+	}{
+		{"settings.*", "settings.title", true},
+		{"settings.*", "settingsXtitle", false},
+		{"*", "a/b.c", true},
+		{"item?", "item1", true},
+		{"item?", "item10", false},
+		{"%lld *", "%lld files", true},
+		{"(a)", "(a)", true},
+		{"Hello", "hello", false},
+	}
+	for _, tt := range tests {
+		re, err := globToRegexp(tt.pattern)
+		if err != nil {
+			t.Fatalf("globToRegexp(%q): %v", tt.pattern, err)
+		}
+		if got := re.MatchString(tt.key); got != tt.want {
+			t.Errorf("%q matches %q = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package translator
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// ProtectedTerms are names that must reach the catalog verbatim, such as the
+// app name, feature names and SKUs. They are masked like placeholders, so the
+// provider never sees them, and matched case-sensitively as whole words.
+type ProtectedTerms struct {
+	terms   []string
+	pattern *regexp.Regexp
+}
+
+// NewProtectedTerms builds the list from terms, ignoring blanks and repeats.
+// It returns nil when no term is left.
+func NewProtectedTerms(terms []string) *ProtectedTerms {
+	var kept []string
+	for _, term := range terms {
+		if term = strings.TrimSpace(term); term != "" && !slices.Contains(kept, term) {
+			kept = append(kept, term)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+
+	// Longer terms first, so "Live Activities" wins over "Live".
+	sorted := slices.Clone(kept)
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	quoted := make([]string, len(sorted))
+	for i, term := range sorted {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	return &ProtectedTerms{terms: kept, pattern: regexp.MustCompile(strings.Join(quoted, "|"))}
+}
+
+// LoadProtectedTerms reads a do-not-translate file with one term per line;
+// blank lines and lines starting with # are skipped. A missing file is empty.
+func LoadProtectedTerms(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open do-not-translate list: %w", err)
+	}
+	defer file.Close()
+
+	var terms []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			terms = append(terms, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read do-not-translate list %s: %w", path, err)
+	}
+	return terms, nil
+}
+
+// Len returns the number of terms.
+func (p *ProtectedTerms) Len() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.terms)
+}
+
+// Find returns the protected terms used in text, in order of appearance.
+func (p *ProtectedTerms) Find(text string) []string {
+	var found []string
+	for _, span := range p.spans(text) {
+		if term := text[span[0]:span[1]]; !slices.Contains(found, term) {
+			found = append(found, term)
+		}
+	}
+	return found
+}
+
+// spans returns where the protected terms occur in text as whole words.
+func (p *ProtectedTerms) spans(text string) [][]int {
+	if p == nil {
+		return nil
+	}
+	var spans [][]int
+	for _, span := range p.pattern.FindAllStringIndex(text, -1) {
+		if wordEdge(text, span[0], span[1]) {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// annotate returns requests with the protected terms each of them uses.
+func (p *ProtectedTerms) annotate(requests []model.TranslationRequest) []model.TranslationRequest {
+	if p.Len() == 0 {
+		return requests
+	}
+	annotated := make([]model.TranslationRequest, len(requests))
+	for i, req := range requests {
+		req.Protected = p.Find(req.Text)
+		annotated[i] = req
+	}
+	return annotated
+}
+
+// wordEdge reports whether text[start:end] is not glued to the word before
+// or after it, in scripts that separate words with spaces.
+func wordEdge(text string, start, end int) bool {
+	first, _ := utf8.DecodeRuneInString(text[start:end])
+	if before, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(first) && isWordRune(before) {
+		return false
+	}
+	last, _ := utf8.DecodeLastRuneInString(text[start:end])
+	if after, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isWordRune(last) && isWordRune(after) {
+		return false
+	}
+	return true
+}
+
+// missingProtectedTerms lists the protected terms of req that translated
+// does not contain verbatim, as whole words.
+func missingProtectedTerms(req model.TranslationRequest, translated string) []string {
+	found := NewProtectedTerms(req.Protected).Find(translated)
+	var missing []string
+	for _, term := range req.Protected {
+		if !slices.Contains(found, term) {
+			missing = append(missing, term)
+		}
+	}
+	return missing
+}
+
+// withoutProtectedTerms removes the protected terms of req from text.
+func withoutProtectedTerms(req model.TranslationRequest, text string) string {
+	for _, term := range req.Protected {
+		text = strings.ReplaceAll(text, term, "")
+	}
+	return text
+}
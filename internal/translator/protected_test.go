@@ -0,0 +1,99 @@
+package translator
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+func TestProtectedTermsFind(t *testing.T) {
+	protected := NewProtectedTerms([]string{"Pro", "Live", "Live Activities", "iCloud+"})
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"whole word", "Upgrade to Pro", []string{"Pro"}},
+		{"case-sensitive", "upgrade to pro", nil},
+		{"inside a word", "Professional tools", nil},
+		{"after a word", "GoPro", nil},
+		{"punctuation around", "“Pro”, now 50% off!", []string{"Pro"}},
+		{"longer term wins", "Live Activities are on", []string{"Live Activities"}},
+		{"shorter term alone", "Go Live", []string{"Live"}},
+		{"symbol at the end", "Get iCloud+ today", []string{"iCloud+"}},
+		{"order of appearance, once each", "Live and Pro, Pro and Live", []string{"Live", "Pro"}},
+		{"unspaced script", "Proにアップグレード", []string{"Pro"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := protected.Find(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("Find(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewProtectedTerms(t *testing.T) {
+	if p := NewProtectedTerms([]string{" ", ""}); p != nil {
+		t.Errorf("NewProtectedTerms of blanks = %v, want nil", p)
+	}
+	if got := NewProtectedTerms([]string{"Pro", " Pro ", "Max"}).Len(); got != 2 {
+		t.Errorf("Len = %d, want 2", got)
+	}
+	var protected *ProtectedTerms
+	if protected.Len() != 0 || protected.Find("Pro") != nil {
+		t.Error("a nil list has terms")
+	}
+}
+
+func TestLoadProtectedTerms(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Localizable.do-not-translate.txt")
+	os.WriteFile(path, []byte("# Product names\nPro\n\n  Live Activities  \n#Max\n"), 0644)
+	terms, err := LoadProtectedTerms(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Pro", "Live Activities"}; !slices.Equal(terms, want) {
+		t.Errorf("terms = %q, want %q", terms, want)
+	}
+
+	terms, err = LoadProtectedTerms(filepath.Join(t.TempDir(), "missing.txt"))
+	if err != nil || terms != nil {
+		t.Errorf("missing file = %q, %v; want no terms", terms, err)
+	}
+}
+
+func TestProtectedQA(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		translated string
+		want       []string
+	}{
+		{"term kept", "Upgrade to Pro", "Auf Pro upgraden", nil},
+		{"term translated", "Upgrade to Pro", "Auf Profi upgraden", []string{RuleProtected}},
+		{"term in another case", "Upgrade to Pro", "Auf PRO upgraden", []string{RuleProtected}},
+		{"only protected terms", "Pro", "Pro", nil},
+		{"protected terms and words", "Pro Mode", "Pro Mode", []string{RuleUntranslated}},
+	}
+	protected := NewProtectedTerms([]string{"Pro"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := model.TranslationRequest{Text: tt.source, TargetLanguage: "de", Protected: protected.Find(tt.source)}
+			if got := issueRules(DefaultValidator().Check(req, tt.translated)); !slices.Equal(got, tt.want) {
+				t.Errorf("rules = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProtectedTermsAnnotate(t *testing.T) {
+	protected := NewProtectedTerms([]string{"Pro"})
+	requests := protected.annotate([]model.TranslationRequest{request("a", "Upgrade to Pro", "de"), request("b", "Hello", "de")})
+	if !slices.Equal(requests[0].Protected, []string{"Pro"}) || requests[1].Protected != nil {
+		t.Errorf("annotate = %+v", requests)
+	}
+}
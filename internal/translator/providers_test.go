@@ -0,0 +1,169 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// stubTransport answers every request with body and records what was sent.
+type stubTransport struct {
+	body string
+	sent []string
+}
+
+func (s *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	sent, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	s.sent = append(s.sent, string(sent))
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(s.body)),
+		Request:    req,
+	}, nil
+}
+
+// stubClient returns a client whose requests are answered by transport.
+func stubClient(transport *stubTransport) *resty.Client {
+	return resty.New().SetTransport(transport)
+}
+
+// sentTexts extracts the texts of a provider request body.
+func sentTexts(t *testing.T, provider, body string) []string {
+	t.Helper()
+	switch provider {
+	case "deepl":
+		var sent DeepLTranslateRequest
+		if err := json.Unmarshal([]byte(body), &sent); err != nil {
+			t.Fatal(err)
+		}
+		return sent.Text
+	case "google":
+		var sent GoogleTranslateRequest
+		if err := json.Unmarshal([]byte(body), &sent); err != nil {
+			t.Fatal(err)
+		}
+		return sent.Contents
+	default:
+		form, err := url.ParseQuery(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Split(form.Get("q"), "\n")
+	}
+}
+
+func TestProviderTranslateBatch(t *testing.T) {
+	tests := []struct {
+		provider string
+		new      func(client *resty.Client) model.BatchTranslationProvider
+		// reply answers texts with translations, in the provider's format.
+		reply func(translations ...string) string
+	}{
+		{"deepl", func(client *resty.Client) model.BatchTranslationProvider {
+			return &DeepLTranslator{APIKey: "key", Client: client}
+		}, func(translations ...string) string {
+			var items []string
+			for _, text := range translations {
+				items = append(items, `{"detected_source_language":"EN","text":"`+text+`"}`)
+			}
+			return `{"translations":[` + strings.Join(items, ",") + `]}`
+		}},
+		{"google", func(client *resty.Client) model.BatchTranslationProvider {
+			return &GoogleTranslator{APIKey: "key", Client: client}
+		}, func(translations ...string) string {
+			var items []string
+			for _, text := range translations {
+				items = append(items, `{"translatedText":"`+text+`"}`)
+			}
+			return `{"translations":[` + strings.Join(items, ",") + `]}`
+		}},
+		{"baidu", func(client *resty.Client) model.BatchTranslationProvider {
+			return &BaiduTranslator{AppID: "app", AppSecret: "secret", Client: client}
+		}, func(translations ...string) string {
+			var items []string
+			for _, text := range translations {
+				items = append(items, `{"src":"x","dst":"`+text+`"}`)
+			}
+			return `{"from":"en","to":"de","trans_result":[` + strings.Join(items, ",") + `]}`
+		}},
+	}
+	requests := []model.TranslationRequest{request("a", "One", "de"), request("b", "Two", "de"), request("c", "Three", "de")}
+
+	for _, tt := range tests {
+		t.Run(tt.provider+" keeps the order", func(t *testing.T) {
+			transport := &stubTransport{body: tt.reply("Eins", "Zwei", "Drei")}
+			responses, err := tt.new(stubClient(transport)).TranslateBatch(context.Background(), requests)
+			if err != nil {
+				t.Fatalf("TranslateBatch: %v", err)
+			}
+			if len(transport.sent) != 1 {
+				t.Fatalf("sent %d requests, want 1", len(transport.sent))
+			}
+			if got := sentTexts(t, tt.provider, transport.sent[0]); !slices.Equal(got, []string{"One", "Two", "Three"}) {
+				t.Errorf("sent %q", got)
+			}
+			var got []string
+			for _, resp := range responses {
+				got = append(got, resp.Key+"="+resp.TranslatedText)
+			}
+			if want := []string{"a=Eins", "b=Zwei", "c=Drei"}; !slices.Equal(got, want) {
+				t.Errorf("responses %q, want %q", got, want)
+			}
+		})
+
+		t.Run(tt.provider+" count mismatch", func(t *testing.T) {
+			transport := &stubTransport{body: tt.reply("Eins", "Zwei")}
+			responses, err := tt.new(stubClient(transport)).TranslateBatch(context.Background(), requests)
+			if err == nil || !strings.Contains(err.Error(), "got 2 translations for 3 texts") {
+				t.Errorf("TranslateBatch = %v, %v; want a count mismatch", responses, err)
+			}
+		})
+	}
+}
+
+func TestBaiduTranslateBatchErrors(t *testing.T) {
+	transport := &stubTransport{body: `{"error_code":"54003","error_msg":"Invalid Access Limit"}`}
+	baidu := &BaiduTranslator{AppID: "app", AppSecret: "secret", Client: stubClient(transport)}
+
+	_, err := baidu.TranslateBatch(context.Background(), []model.TranslationRequest{request("a", "One", "de"), request("b", "Two", "de")})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "54003" {
+		t.Errorf("TranslateBatch = %v, want the API error 54003", err)
+	}
+
+	_, err = baidu.TranslateBatch(context.Background(), []model.TranslationRequest{request("a", "One", "de"), request("b", "Line one\nLine two", "de")})
+	if err == nil || len(transport.sent) != 1 {
+		t.Errorf("TranslateBatch sent a multi-line text: %v", err)
+	}
+}
+
+func TestBaiduPlaceholderMasking(t *testing.T) {
+	// Baidu answers Chinese with full-width braces around the tokens.
+	transport := &stubTransport{body: `{"from":"en","to":"zh","trans_result":[{"src":"x","dst":"｛1｝个文件由｛0｝发送"}]}`}
+	service := newTestService(&BaiduTranslator{AppID: "app", AppSecret: "secret", Client: stubClient(transport)})
+
+	responses, err := service.TranslateBatch(context.Background(), []model.TranslationRequest{request("sent", "%1$@ sent %2$lld files", "zh-Hans")})
+	if err != nil {
+		t.Fatalf("TranslateBatch: %v", err)
+	}
+	if got := sentTexts(t, "baidu", transport.sent[0]); !slices.Equal(got, []string{"{0} sent {1} files"}) {
+		t.Errorf("sent %q", got)
+	}
+	if resp := responses[0]; resp.Error != nil || resp.TranslatedText != "%2$lld个文件由%1$@发送" {
+		t.Errorf("response = %q, %v", resp.TranslatedText, resp.Error)
+	}
+}
@@ -0,0 +1,281 @@
+package translator
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// QA rules checked on every translation before it is written.
+const (
+	// RulePlaceholders compares the format specifiers of source and translation.
+	RulePlaceholders = "placeholders"
+	// RuleWhitespace compares leading and trailing whitespace and line breaks.
+	RuleWhitespace = "whitespace"
+	// RulePunctuation compares terminal punctuation, ellipses included.
+	RulePunctuation = "punctuation"
+	// RuleLength flags translations much longer or shorter than the source.
+	RuleLength = "length"
+	// RuleUntranslated flags translations identical to the source.
+	RuleUntranslated = "untranslated"
+	// RuleGlossary flags translations that do not use a glossary term's
+	// mandated translation.
+	RuleGlossary = "glossary"
+	// RuleProtected flags translations that lost a do-not-translate term.
+	RuleProtected = "protected"
+)
+
+// QA severities. An error rejects the translation, a warning only reports it.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityOff     = "off"
+)
+
+// QARules lists every rule in the order they are checked.
+var QARules = []string{RulePlaceholders, RuleWhitespace, RulePunctuation, RuleLength, RuleUntranslated, RuleGlossary, RuleProtected}
+
+// Validator checks translations against the QA rules.
+type Validator struct {
+	// Severities maps rule names to a severity; missing rules use the default.
+	Severities map[string]string
+	// MinLengthRatio and MaxLengthRatio bound the translation's length relative
+	// to the source, in characters.
+	MinLengthRatio float64
+	MaxLengthRatio float64
+	// MinLengthSource is the shortest source the length rule applies to;
+	// short strings vary too much to judge.
+	MinLengthSource int
+}
+
+// defaultSeverities keeps only broken placeholders out of the catalog.
+var defaultSeverities = map[string]string{
+	RulePlaceholders: SeverityError,
+	RuleWhitespace:   SeverityWarning,
+	RulePunctuation:  SeverityWarning,
+	RuleLength:       SeverityWarning,
+	RuleUntranslated: SeverityWarning,
+	RuleGlossary:     SeverityWarning,
+	RuleProtected:    SeverityWarning,
+}
+
+// DefaultValidator returns the validator used when nothing is configured.
+func DefaultValidator() *Validator {
+	return &Validator{
+		Severities:      map[string]string{},
+		MinLengthRatio:  0.25,
+		MaxLengthRatio:  3,
+		MinLengthSource: 10,
+	}
+}
+
+// ValidateSeverities rejects unknown rules and severities.
+func ValidateSeverities(severities map[string]string) error {
+	for rule, severity := range severities {
+		if _, ok := defaultSeverities[rule]; !ok {
+			return fmt.Errorf("unknown QA rule %q (want one of %s)", rule, strings.Join(QARules, ", "))
+		}
+		switch severity {
+		case SeverityError, SeverityWarning, SeverityOff:
+		default:
+			return fmt.Errorf("invalid severity %q for QA rule %s (want error, warning or off)", severity, rule)
+		}
+	}
+	return nil
+}
+
+// severity returns the configured severity of rule.
+func (v *Validator) severity(rule string) string {
+	if severity, ok := v.Severities[rule]; ok {
+		return severity
+	}
+	return defaultSeverities[rule]
+}
+
+// QAIssue is a rule a translation broke.
+type QAIssue struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (i QAIssue) String() string {
+	return i.Rule + ": " + i.Message
+}
+
+// QAError rejects a translation that broke rules with error severity.
+type QAError struct {
+	Issues []QAIssue
+}
+
+func (e *QAError) Error() string {
+	messages := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		messages[i] = issue.String()
+	}
+	return "translation failed QA: " + strings.Join(messages, "; ")
+}
+
+// IsQAError reports whether err rejected a translation for QA reasons.
+func IsQAError(err error) bool {
+	var qaErr *QAError
+	return errors.As(err, &qaErr)
+}
+
+// IsRejected reports whether err excludes a single translation on its merits,
+// as opposed to a failed provider call: QA rejected it or its placeholders
+// could not be restored. The unit is left untranslated while the rest of the
+// run carries on.
+func IsRejected(err error) bool {
+	return IsQAError(err) || errors.Is(err, ErrDroppedPlaceholders) || errors.Is(err, ErrDuplicatedPlaceholders)
+}
+
+// Check runs every enabled rule on a translation of req.
+func (v *Validator) Check(req model.TranslationRequest, translated string) []QAIssue {
+	if v == nil {
+		return nil
+	}
+	source := req.Text
+
+	var issues []QAIssue
+	report := func(rule, format string, args ...any) {
+		if severity := v.severity(rule); severity != SeverityOff {
+			issues = append(issues, QAIssue{Rule: rule, Severity: severity, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+
+	if want, got := formatSpecifiers(source), formatSpecifiers(translated); !slices.Equal(want, got) {
+		report(RulePlaceholders, "expected placeholders [%s], got [%s]", strings.Join(want, " "), strings.Join(got, " "))
+	}
+
+	if want, got := leadingSpace(source), leadingSpace(translated); want != got {
+		report(RuleWhitespace, "leading whitespace %q became %q", want, got)
+	}
+	if want, got := trailingSpace(source), trailingSpace(translated); want != got {
+		report(RuleWhitespace, "trailing whitespace %q became %q", want, got)
+	}
+	if want, got := strings.Count(source, "\n"), strings.Count(translated, "\n"); want != got {
+		report(RuleWhitespace, "%d line breaks became %d", want, got)
+	}
+
+	if want, got := terminalPunctuation(source), terminalPunctuation(translated); want != got {
+		report(RulePunctuation, "ends with %s instead of %s", describePunctuation(got), describePunctuation(want))
+	}
+
+	sourceLen := utf8.RuneCountInString(strings.TrimSpace(source))
+	if sourceLen >= v.MinLengthSource && sourceLen > 0 {
+		ratio := float64(utf8.RuneCountInString(strings.TrimSpace(translated))) / float64(sourceLen)
+		if (v.MaxLengthRatio > 0 && ratio > v.MaxLengthRatio) || ratio < v.MinLengthRatio {
+			report(RuleLength, "translation is %.1fx the length of the source", ratio)
+		}
+	}
+
+	// Text made only of protected terms is meant to stay as it is.
+	if strings.TrimSpace(source) == strings.TrimSpace(translated) && hasLetters(withoutProtectedTerms(req, source)) {
+		report(RuleUntranslated, "translation is identical to the source")
+	}
+
+	for _, term := range missingGlossaryTerms(req, translated) {
+		report(RuleGlossary, "%q should be translated as %q", term.Term, term.Translation)
+	}
+
+	for _, term := range missingProtectedTerms(req, translated) {
+		report(RuleProtected, "protected term %q is missing", term)
+	}
+
+	return issues
+}
+
+// apply checks resp, a successful translation of req: errors reject it and
+// warnings are attached to it.
+func (v *Validator) apply(req model.TranslationRequest, resp model.TranslationResponse) model.TranslationResponse {
+	var rejected []QAIssue
+	for _, issue := range v.Check(req, resp.TranslatedText) {
+		if issue.Severity == SeverityError {
+			rejected = append(rejected, issue)
+		} else {
+			resp.Warnings = append(resp.Warnings, issue.String())
+		}
+	}
+	if len(rejected) > 0 {
+		resp.Error = &QAError{Issues: rejected}
+	}
+	return resp
+}
+
+// formatSpecifiers returns the placeholders of text other than line breaks, sorted.
+func formatSpecifiers(text string) []string {
+	var specifiers []string
+	for _, match := range placeholderPattern.FindAllString(text, -1) {
+		if match != "\n" && match != `\n` {
+			specifiers = append(specifiers, match)
+		}
+	}
+	sort.Strings(specifiers)
+	return specifiers
+}
+
+func leadingSpace(text string) string {
+	return text[:len(text)-len(strings.TrimLeftFunc(text, unicode.IsSpace))]
+}
+
+func trailingSpace(text string) string {
+	return text[len(strings.TrimRightFunc(text, unicode.IsSpace)):]
+}
+
+// Terminal punctuation classes; full-width and script-specific marks count
+// as their Latin equivalent.
+const (
+	punctNone        = ""
+	punctPeriod      = "."
+	punctExclamation = "!"
+	punctQuestion    = "?"
+	punctColon       = ":"
+	punctEllipsis    = "…"
+)
+
+// terminalPunctuation classifies how text ends, ignoring trailing whitespace
+// and closing quotes or brackets.
+func terminalPunctuation(text string) string {
+	text = strings.TrimRightFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(`"')]}»”’」』）`, r)
+	})
+	switch {
+	case strings.HasSuffix(text, "..."), strings.HasSuffix(text, "…"), strings.HasSuffix(text, "⋯"):
+		return punctEllipsis
+	}
+	r, _ := utf8.DecodeLastRuneInString(text)
+	switch r {
+	case '.', '。', '।', '։', '۔':
+		return punctPeriod
+	case '!', '！':
+		return punctExclamation
+	case '?', '？', '؟', '\u037e': // U+037E is the Greek question mark
+		return punctQuestion
+	case ':', '：':
+		return punctColon
+	}
+	return punctNone
+}
+
+func describePunctuation(class string) string {
+	if class == punctNone {
+		return "no punctuation"
+	}
+	return fmt.Sprintf("%q", class)
+}
+
+func hasLetters(text string) bool {
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
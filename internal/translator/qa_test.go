@@ -0,0 +1,174 @@
+package translator
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// issueRules lists the rules of issues, in order.
+func issueRules(issues []QAIssue) []string {
+	var rules []string
+	for _, issue := range issues {
+		rules = append(rules, issue.Rule)
+	}
+	return rules
+}
+
+func TestValidatorCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		translated string
+		want       []string
+	}{
+		{"clean", "Delete all files?", "Alle Dateien löschen?", nil},
+		{"placeholders kept", "%@ of %lld", "%lld von %@", nil},
+		{"placeholder dropped", "%@ of %lld", "%@ von", []string{RulePlaceholders}},
+		{"placeholder changed", "%lld files", "%d Dateien", []string{RulePlaceholders}},
+		{"positional placeholders", "%1$@ sent %2$@", "%2$@ von %1$@", nil},
+		{"line break specifier ignored", `First\nSecond`, "Erste Zweite", nil},
+		{"leading whitespace", "  Indented", "Eingerückt", []string{RuleWhitespace}},
+		{"trailing whitespace", "Name: ", "Nom:", []string{RuleWhitespace}},
+		{"line breaks", "First line\nSecond line", "Erste Zeile Zweite Zeile", []string{RuleWhitespace}},
+		{"period dropped", "Saved.", "Gespeichert", []string{RulePunctuation}},
+		{"full-width period", "Saved.", "保存しました。", nil},
+		{"full-width question mark", "Continue?", "继续？", nil},
+		{"ellipsis spelled differently", "Loading...", "Wird geladen…", nil},
+		{"ellipsis dropped", "Loading…", "Wird geladen.", []string{RulePunctuation}},
+		{"closing quote ignored", `Tap "Done."`, `Tippe auf "Fertig."`, nil},
+		{"colon", "Name:", "Nom :", nil},
+		{"too long", "Save all files", "Alle Dateien auf diesem Gerät und in der Cloud dauerhaft speichern", []string{RuleLength}},
+		{"too short", "Save all your documents now", "Ok", []string{RuleLength}},
+		{"short source not judged", "Save", "Speichern unter einem neuen Namen", nil},
+		{"untranslated", "Settings", "Settings", []string{RuleUntranslated}},
+		{"untranslated without letters", "%@ – %@", "%@ – %@", nil},
+		{"only the period differs", "Saved.", "Saved", []string{RulePunctuation}},
+		{"rules in order", " %@ files.", "Dateien", []string{RulePlaceholders, RuleWhitespace, RulePunctuation}},
+	}
+	validator := DefaultValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := model.TranslationRequest{Text: tt.source, TargetLanguage: "de"}
+			issues := validator.Check(req, tt.translated)
+			if got := issueRules(issues); !slices.Equal(got, tt.want) {
+				t.Errorf("Check(%q, %q) = %v, want rules %v", tt.source, tt.translated, issues, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatorLengthBounds(t *testing.T) {
+	req := model.TranslationRequest{Text: "Twenty characters!!!", TargetLanguage: "de"}
+	tests := []struct {
+		name       string
+		validator  Validator
+		translated string
+		want       bool
+	}{
+		{"within the ratios", Validator{MinLengthRatio: 0.5, MaxLengthRatio: 2}, "Thirty characters of text!!!!!", false},
+		{"above the maximum", Validator{MinLengthRatio: 0.5, MaxLengthRatio: 1.2}, "Thirty characters of text!!!!!", true},
+		{"below the minimum", Validator{MinLengthRatio: 0.8, MaxLengthRatio: 2}, "Ten chars!", true},
+		{"no maximum", Validator{MinLengthRatio: 0.5}, "Far more than twice the twenty characters of the source!", false},
+		{"source too short", Validator{MinLengthRatio: 0.8, MaxLengthRatio: 2, MinLengthSource: 21}, "Ten chars!", false},
+		{"source just long enough", Validator{MinLengthRatio: 0.8, MaxLengthRatio: 2, MinLengthSource: 20}, "Ten chars!", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := slices.Contains(issueRules(tt.validator.Check(req, tt.translated)), RuleLength)
+			if got != tt.want {
+				t.Errorf("length issue = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatorSeverities(t *testing.T) {
+	// The translation drops a placeholder and its period.
+	req := model.TranslationRequest{Key: "files", Text: "%lld files.", TargetLanguage: "de"}
+	resp := model.TranslationResponse{Key: "files", TargetLanguage: "de", TranslatedText: "Dateien"}
+
+	tests := []struct {
+		name         string
+		severities   map[string]string
+		wantRejected []string
+		wantWarnings int
+	}{
+		{"defaults", nil, []string{RulePlaceholders}, 1},
+		{"both errors", map[string]string{RulePunctuation: SeverityError}, []string{RulePlaceholders, RulePunctuation}, 0},
+		{"both warnings", map[string]string{RulePlaceholders: SeverityWarning}, nil, 2},
+		{"placeholders off", map[string]string{RulePlaceholders: SeverityOff}, nil, 1},
+		{"everything off", map[string]string{RulePlaceholders: SeverityOff, RulePunctuation: SeverityOff}, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := DefaultValidator()
+			validator.Severities = tt.severities
+			got := validator.apply(req, resp)
+
+			var rejected []string
+			var qaErr *QAError
+			if errors.As(got.Error, &qaErr) {
+				rejected = issueRules(qaErr.Issues)
+			} else if got.Error != nil {
+				t.Fatalf("Error = %v, want a QAError", got.Error)
+			}
+			if !slices.Equal(rejected, tt.wantRejected) {
+				t.Errorf("rejected for %v, want %v", rejected, tt.wantRejected)
+			}
+			if len(got.Warnings) != tt.wantWarnings {
+				t.Errorf("warnings = %q, want %d", got.Warnings, tt.wantWarnings)
+			}
+			if got.TranslatedText != "Dateien" {
+				t.Errorf("TranslatedText = %q", got.TranslatedText)
+			}
+		})
+	}
+}
+
+func TestNilValidator(t *testing.T) {
+	var validator *Validator
+	if issues := validator.Check(model.TranslationRequest{Text: "%@"}, ""); issues != nil {
+		t.Errorf("Check = %v", issues)
+	}
+}
+
+func TestValidateSeverities(t *testing.T) {
+	tests := []struct {
+		severities map[string]string
+		wantErr    bool
+	}{
+		{nil, false},
+		{map[string]string{RulePlaceholders: SeverityError, RuleLength: SeverityOff, RuleWhitespace: SeverityWarning}, false},
+		{map[string]string{"spelling": SeverityError}, true},
+		{map[string]string{RulePunctuation: "fatal"}, true},
+		{map[string]string{RulePunctuation: ""}, true},
+	}
+	for _, tt := range tests {
+		if err := ValidateSeverities(tt.severities); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateSeverities(%v) = %v, want error %v", tt.severities, err, tt.wantErr)
+		}
+	}
+}
+
+func TestIsRejected(t *testing.T) {
+	qaErr := &QAError{Issues: []QAIssue{{Rule: RulePlaceholders, Severity: SeverityError, Message: "lost %@"}}}
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{qaErr, true},
+		{fmt.Errorf("key a: %w", qaErr), true},
+		{&APIError{StatusCode: 500}, false},
+		{errors.New("request failed"), false},
+	}
+	for _, tt := range tests {
+		if got := IsRejected(tt.err); got != tt.want {
+			t.Errorf("IsRejected(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
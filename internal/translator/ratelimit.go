@@ -0,0 +1,172 @@
+package translator
+
+import (
+	"context"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// RateLimit is a provider's throughput budget. Zero fields are unlimited.
+type RateLimit struct {
+	RequestsPerSecond   float64
+	CharactersPerMinute int
+	// TokensPerMinute is checked against an estimate of prompt plus completion
+	// tokens, since the real count is only known after the request.
+	TokensPerMinute int
+}
+
+// Enabled reports whether any budget is set.
+func (l RateLimit) Enabled() bool {
+	return l.RequestsPerSecond > 0 || l.CharactersPerMinute > 0 || l.TokensPerMinute > 0
+}
+
+// MinDuration is the shortest time n requests can take under the
+// requests-per-second budget.
+func (l RateLimit) MinDuration(n int) time.Duration {
+	if l.RequestsPerSecond <= 0 {
+		return 0
+	}
+	return time.Duration(float64(n) / l.RequestsPerSecond * float64(time.Second))
+}
+
+// RateLimiter spaces out requests so that every budget of a RateLimit holds.
+// It is shared by all workers of a TranslationService; a nil RateLimiter
+// never waits.
+type RateLimiter struct {
+	mu         sync.Mutex
+	requests   *bucket
+	characters *bucket
+	tokens     *bucket
+}
+
+// NewRateLimiter returns a limiter for limit, or nil when no budget is set.
+func NewRateLimiter(limit RateLimit) *RateLimiter {
+	if !limit.Enabled() {
+		return nil
+	}
+
+	now := time.Now()
+	l := &RateLimiter{}
+	if limit.RequestsPerSecond > 0 {
+		// Allow no burst beyond one second's worth of requests.
+		capacity := limit.RequestsPerSecond
+		if capacity < 1 {
+			capacity = 1
+		}
+		l.requests = newBucket(limit.RequestsPerSecond, capacity, now)
+	}
+	if limit.CharactersPerMinute > 0 {
+		perMinute := float64(limit.CharactersPerMinute)
+		l.characters = newBucket(perMinute/60, perMinute, now)
+	}
+	if limit.TokensPerMinute > 0 {
+		perMinute := float64(limit.TokensPerMinute)
+		l.tokens = newBucket(perMinute/60, perMinute, now)
+	}
+	return l
+}
+
+// Wait blocks until req fits every budget or ctx ends.
+func (l *RateLimiter) Wait(ctx context.Context, req model.TranslationRequest) error {
+	return l.WaitBatch(ctx, []model.TranslationRequest{req})
+}
+
+// WaitBatch blocks until a single call carrying reqs fits every budget or ctx ends.
+func (l *RateLimiter) WaitBatch(ctx context.Context, reqs []model.TranslationRequest) error {
+	if l == nil {
+		return nil
+	}
+
+	characters, tokens := 0, 0
+	for _, req := range reqs {
+		characters += utf8.RuneCountInString(req.Text)
+		tokens += estimateTokens(req)
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	var wait time.Duration
+	if l.requests != nil {
+		wait = max(wait, l.requests.reserve(1, now))
+	}
+	if l.characters != nil {
+		wait = max(wait, l.characters.reserve(float64(characters), now))
+	}
+	if l.tokens != nil {
+		wait = max(wait, l.tokens.reserve(float64(tokens), now))
+	}
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// promptOverheadTokens approximates the instructions an LLM provider wraps
+// around each text.
+const promptOverheadTokens = 100
+
+// estimateTokens roughly sizes an LLM request: about four ASCII characters
+// per token, one token per other character, and a completion as long as the
+// input.
+func estimateTokens(req model.TranslationRequest) int {
+	usage := EstimateUsage(req)
+	return usage.PromptTokens + usage.CompletionTokens
+}
+
+// countTokens estimates the tokens of s, as estimateTokens does.
+func countTokens(s string) int {
+	ascii, other := 0, 0
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// bucket is a token bucket that lets reservations overdraw it; the overdraft
+// is the time the caller has to wait.
+type bucket struct {
+	rate     float64 // units per second
+	capacity float64
+	level    float64
+	last     time.Time
+}
+
+func newBucket(rate, capacity float64, now time.Time) *bucket {
+	return &bucket{rate: rate, capacity: capacity, level: capacity, last: now}
+}
+
+// reserve takes n units and returns how long to wait until they are covered.
+// Requests larger than the whole bucket are clamped to it so they wait for a
+// full bucket instead of forever.
+func (b *bucket) reserve(n float64, now time.Time) time.Duration {
+	b.level += now.Sub(b.last).Seconds() * b.rate
+	if b.level > b.capacity {
+		b.level = b.capacity
+	}
+	b.last = now
+
+	if n > b.capacity {
+		n = b.capacity
+	}
+	b.level -= n
+	if b.level >= 0 {
+		return 0
+	}
+	return time.Duration(-b.level / b.rate * float64(time.Second))
+}
@@ -0,0 +1,132 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+func TestBucketReserve(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	// Two units per second, holding at most four.
+	type step struct {
+		at   time.Duration
+		n    float64
+		want time.Duration
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"burst within capacity", []step{
+			{0, 1, 0}, {0, 1, 0}, {0, 1, 0}, {0, 1, 0},
+		}},
+		{"overdraw waits for the deficit", []step{
+			{0, 4, 0}, {0, 1, 500 * time.Millisecond}, {0, 1, time.Second},
+		}},
+		{"refills over time", []step{
+			{0, 4, 0}, {time.Second, 2, 0}, {time.Second, 1, 500 * time.Millisecond},
+		}},
+		{"refill is capped at capacity", []step{
+			{0, 4, 0}, {time.Hour, 4, 0}, {time.Hour, 1, 500 * time.Millisecond},
+		}},
+		{"oversized request waits for a full bucket", []step{
+			{0, 1, 0}, {0, 100, 500 * time.Millisecond},
+		}},
+		{"exactly empty does not wait", []step{
+			{0, 3, 0}, {0, 1, 0}, {500 * time.Millisecond, 1, 0},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBucket(2, 4, start)
+			for i, s := range tt.steps {
+				if got := b.reserve(s.n, at(s.at)); got != s.want {
+					t.Fatalf("step %d: reserve(%v) at %s = %s, want %s", i, s.n, s.at, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewRateLimiter(t *testing.T) {
+	if l := NewRateLimiter(RateLimit{}); l != nil {
+		t.Errorf("NewRateLimiter(RateLimit{}) = %v, want nil", l)
+	}
+	var l *RateLimiter
+	if err := l.Wait(context.Background(), model.TranslationRequest{Text: "hi"}); err != nil {
+		t.Errorf("nil limiter Wait = %v", err)
+	}
+
+	l = NewRateLimiter(RateLimit{RequestsPerSecond: 0.5})
+	if l.requests.capacity != 1 {
+		t.Errorf("capacity below one request per second = %v, want 1", l.requests.capacity)
+	}
+	l = NewRateLimiter(RateLimit{CharactersPerMinute: 600, TokensPerMinute: 60})
+	if l.requests != nil || l.characters.rate != 10 || l.tokens.capacity != 60 {
+		t.Errorf("buckets = %+v %+v %+v", l.requests, l.characters, l.tokens)
+	}
+}
+
+func TestRateLimiterWaitBatch(t *testing.T) {
+	l := NewRateLimiter(RateLimit{CharactersPerMinute: 60})
+	reqs := []model.TranslationRequest{{Text: strings.Repeat("a", 40)}, {Text: strings.Repeat("é", 20)}}
+	if err := l.WaitBatch(context.Background(), reqs); err != nil {
+		t.Fatalf("first batch within budget: %v", err)
+	}
+
+	// The bucket is empty now, so the next call has to wait about a second
+	// per character; a cancelled context must not.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	started := time.Now()
+	err := l.WaitBatch(ctx, []model.TranslationRequest{{Text: "abc"}})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("WaitBatch on a cancelled context = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("WaitBatch blocked for %s after cancellation", elapsed)
+	}
+}
+
+func TestRateLimitMinDuration(t *testing.T) {
+	tests := []struct {
+		limit RateLimit
+		n     int
+		want  time.Duration
+	}{
+		{RateLimit{}, 10, 0},
+		{RateLimit{RequestsPerSecond: 2}, 10, 5 * time.Second},
+		{RateLimit{RequestsPerSecond: 0.5}, 3, 6 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := tt.limit.MinDuration(tt.n); got != tt.want {
+			t.Errorf("%+v.MinDuration(%d) = %s, want %s", tt.limit, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestCountTokens(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"a", 1},
+		{"abcd", 1},
+		{"abcde", 2},
+		{"日本語", 3},
+		{"ab日本", 3},
+	}
+	for _, tt := range tests {
+		if got := countTokens(tt.s); got != tt.want {
+			t.Errorf("countTokens(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
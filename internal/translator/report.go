@@ -0,0 +1,119 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// FailureReport lists the units a run could not translate. It is written as
+// JSON so that a follow-up run can retry exactly these units.
+type FailureReport struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	InputFile   string    `json:"inputFile,omitempty"`
+	Provider    string    `json:"provider,omitempty"`
+	Failures    []Failure `json:"failures"`
+}
+
+// Failure is a single unit that failed to translate.
+type Failure struct {
+	Key      string `json:"key"`
+	Language string `json:"language"`
+	Path     string `json:"path,omitempty"`
+	Class    string `json:"class"`
+	Message  string `json:"message"`
+	// Provider is the provider that tried the unit last.
+	Provider string `json:"provider,omitempty"`
+}
+
+// NewFailureReport collects the failed responses, ordered by language, key and path.
+func NewFailureReport(responses []model.TranslationResponse) FailureReport {
+	report := FailureReport{
+		GeneratedAt: time.Now().UTC(),
+		Failures:    []Failure{},
+	}
+	for _, resp := range responses {
+		if resp.Error == nil {
+			continue
+		}
+		report.Failures = append(report.Failures, Failure{
+			Key:      resp.Key,
+			Language: resp.TargetLanguage,
+			Path:     resp.Path,
+			Class:    ClassifyError(resp.Error),
+			Message:  resp.Error.Error(),
+			Provider: resp.Provider,
+		})
+	}
+
+	sort.Slice(report.Failures, func(i, j int) bool {
+		a, b := report.Failures[i], report.Failures[j]
+		if a.Language != b.Language {
+			return a.Language < b.Language
+		}
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.Path < b.Path
+	})
+	return report
+}
+
+// Languages returns the distinct languages in the report, sorted.
+func (r FailureReport) Languages() []string {
+	seen := map[string]bool{}
+	var languages []string
+	for _, f := range r.Failures {
+		if !seen[f.Language] {
+			seen[f.Language] = true
+			languages = append(languages, f.Language)
+		}
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Filter keeps only the requests for units listed in the report.
+func (r FailureReport) Filter(requests []model.TranslationRequest) []model.TranslationRequest {
+	failed := make(map[unitID]bool, len(r.Failures))
+	for _, f := range r.Failures {
+		failed[unitID{f.Key, f.Language, f.Path}] = true
+	}
+
+	var filtered []model.TranslationRequest
+	for _, req := range requests {
+		if failed[unitID{req.Key, req.TargetLanguage, req.Path}] {
+			filtered = append(filtered, req)
+		}
+	}
+	return filtered
+}
+
+// WriteFailureReport saves report as indented JSON.
+func WriteFailureReport(path string, report FailureReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal failure report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write failure report: %w", err)
+	}
+	return nil
+}
+
+// LoadFailureReport reads a report written by WriteFailureReport.
+func LoadFailureReport(path string) (FailureReport, error) {
+	var report FailureReport
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return report, fmt.Errorf("failed to read failure report: %w", err)
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return report, fmt.Errorf("failed to parse failure report: %w", err)
+	}
+	return report, nil
+}
@@ -0,0 +1,83 @@
+package translator
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+func TestNewFailureReport(t *testing.T) {
+	responses := []model.TranslationResponse{
+		{Key: "b", TargetLanguage: "ja", Provider: "deepl", Error: &APIError{StatusCode: 429}},
+		{Key: "a", TargetLanguage: "ja", TranslatedText: "ok"},
+		{Key: "%lld files", TargetLanguage: "de", Path: "plural.other", Provider: "deepl", Error: &APIError{StatusCode: 456}},
+		{Key: "%lld files", TargetLanguage: "de", Path: "plural.one", Provider: "google", Error: errors.New("boom")},
+		{Key: "c", TargetLanguage: "de", Provider: "deepl", Error: &QAError{Issues: []QAIssue{{Rule: RulePlaceholders, Message: "lost %@"}}}},
+	}
+	report := NewFailureReport(responses)
+
+	want := []Failure{
+		{Key: "%lld files", Language: "de", Path: "plural.one", Class: ErrorClassUnknown, Message: "boom", Provider: "google"},
+		{Key: "%lld files", Language: "de", Path: "plural.other", Class: ErrorClassQuota, Message: responses[2].Error.Error(), Provider: "deepl"},
+		{Key: "c", Language: "de", Class: ErrorClassValidation, Message: responses[4].Error.Error(), Provider: "deepl"},
+		{Key: "b", Language: "ja", Class: ErrorClassRateLimit, Message: responses[0].Error.Error(), Provider: "deepl"},
+	}
+	if !reflect.DeepEqual(report.Failures, want) {
+		t.Errorf("failures =\n%+v\nwant\n%+v", report.Failures, want)
+	}
+	if got := report.Languages(); !slices.Equal(got, []string{"de", "ja"}) {
+		t.Errorf("Languages = %v", got)
+	}
+}
+
+func TestFailureReportRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "failures.json")
+	report := NewFailureReport([]model.TranslationResponse{
+		{Key: "a", TargetLanguage: "de", Path: "plural.one", Error: &APIError{StatusCode: 500, Message: "oops"}},
+	})
+	report.InputFile = "Localizable.xcstrings"
+	report.Provider = "deepl"
+	if err := WriteFailureReport(path, report); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadFailureReport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.GeneratedAt.Equal(report.GeneratedAt) {
+		t.Errorf("GeneratedAt = %v, want %v", loaded.GeneratedAt, report.GeneratedAt)
+	}
+	loaded.GeneratedAt = report.GeneratedAt
+	if !reflect.DeepEqual(loaded, report) {
+		t.Errorf("loaded %+v, want %+v", loaded, report)
+	}
+
+	if _, err := LoadFailureReport(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadFailureReport accepted a missing file")
+	}
+}
+
+func TestFailureReportFilter(t *testing.T) {
+	xcstrings := parseCatalog(t, pluralCatalog)
+	requests := CreateTranslationRequests(xcstrings, []string{"de", "ja"}, RetranslatePolicy{})
+	report := FailureReport{Failures: []Failure{
+		{Key: "%lld files", Language: "de", Path: "plural.other"},
+		{Key: "Hello", Language: "ja"},
+		{Key: "Gone", Language: "de"},
+	}}
+
+	var got []string
+	for _, req := range report.Filter(requests) {
+		got = append(got, req.Key+"|"+req.TargetLanguage+"|"+req.Path)
+	}
+	slices.Sort(got)
+	want := []string{"%lld files|de|plural.other", "Hello|ja|"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Filter = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,93 @@
+package translator
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// RetryPolicy controls how often and how patiently a failed request is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one;
+	// values below 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the second attempt; it doubles on
+	// every further attempt up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter randomises each delay by up to this fraction (0.2 = ±20%).
+	Jitter float64
+}
+
+// DefaultRetryPolicy returns the policy used when nothing is configured.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		Jitter:         0.2,
+	}
+}
+
+// Backoff returns the delay before the attempt following a failed attempt
+// (1-based). A Retry-After delay requested by the server takes precedence.
+func (p RetryPolicy) Backoff(attempt int, err error) time.Duration {
+	if wait := retryAfter(err); wait > 0 {
+		return wait
+	}
+
+	delay := p.InitialBackoff
+	if delay <= 0 {
+		delay = time.Second
+	}
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 {
+		spread := float64(delay) * p.Jitter
+		delay += time.Duration((rand.Float64()*2 - 1) * spread)
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return delay
+}
+
+// translateWithRetry calls the provider until it succeeds, the error is not
+// retryable, the attempt budget is spent or the context ends. Every attempt
+// waits for the rate limiter first.
+func (s *TranslationService) translateWithRetry(ctx context.Context, req model.TranslationRequest) model.TranslationResponse {
+	for attempt := 1; ; attempt++ {
+		if err := s.Limiter.Wait(ctx, req); err != nil {
+			return model.TranslationResponse{
+				Key:            req.Key,
+				TargetLanguage: req.TargetLanguage,
+				Error:          err,
+			}
+		}
+
+		resp, err := s.Provider.Translate(ctx, req)
+		if err != nil {
+			resp.Error = err
+		}
+		if resp.Error == nil || attempt >= s.Retry.MaxAttempts || !IsRetryable(resp.Error) {
+			return resp
+		}
+
+		delay := s.Retry.Backoff(attempt, resp.Error)
+		s.Events.emitRequest(EventRetrying, req, Event{Provider: s.Name, Attempt: attempt + 1, Delay: delay, Err: resp.Error})
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package translator
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		err     error
+		want    time.Duration
+	}{
+		{"first retry", policy, 1, nil, time.Second},
+		{"doubles", policy, 2, nil, 2 * time.Second},
+		{"doubles again", policy, 3, nil, 4 * time.Second},
+		{"capped", policy, 4, nil, 5 * time.Second},
+		{"stays capped", policy, 30, nil, 5 * time.Second},
+		{"no cap", RetryPolicy{InitialBackoff: time.Second}, 6, nil, 32 * time.Second},
+		{"default initial", RetryPolicy{}, 1, nil, time.Second},
+		{"retry-after wins", policy, 1, &APIError{StatusCode: 429, RetryAfter: 42 * time.Second}, 42 * time.Second},
+		{"wrapped retry-after", policy, 3, fmt.Errorf("call: %w", &APIError{StatusCode: 503, RetryAfter: 7 * time.Second}), 7 * time.Second},
+		{"no retry-after", policy, 2, &APIError{StatusCode: 503}, 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Backoff(tt.attempt, tt.err); got != tt.want {
+				t.Errorf("Backoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 30 * time.Second, Jitter: 0.2}
+	for i := 0; i < 100; i++ {
+		got := policy.Backoff(2, nil)
+		if got < 1600*time.Millisecond || got > 2400*time.Millisecond {
+			t.Fatalf("Backoff(2) = %s, want within 20%% of 2s", got)
+		}
+	}
+	// The server's Retry-After is not jittered.
+	err := &APIError{StatusCode: 429, RetryAfter: 3 * time.Second}
+	if got := policy.Backoff(1, err); got != 3*time.Second {
+		t.Errorf("Backoff with Retry-After = %s, want 3s", got)
+	}
+}
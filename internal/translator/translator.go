@@ -2,7 +2,9 @@ package translator
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,15 +13,60 @@ import (
 
 // TranslationService manages the translation process with concurrency
 type TranslationService struct {
-	Provider    model.TranslationProvider
+	Provider model.TranslationProvider
+	// Name identifies Provider in responses, e.g. "deepl".
+	Name        string
 	Concurrency int
 	Timeout     time.Duration
+	Retry       RetryPolicy
+	// Limiter throttles calls to Provider, retries included; nil means unlimited.
+	Limiter *RateLimiter
+	// ContinueOnError keeps a batch going after a failed request instead of
+	// cancelling it. MaxErrors then caps the failures tolerated across every
+	// batch the service runs; 0 means no cap.
+	ContinueOnError bool
+	MaxErrors       int
+	// Checkpoint, when set, journals every successful response as it arrives.
+	Checkpoint *Checkpoint
+	// Memory, when set, answers requests translated before without calling
+	// Provider and remembers every new successful translation.
+	Memory *MemoryScope
+	// Validator checks every translation; rules with error severity reject it.
+	// Nil skips the checks.
+	Validator *Validator
+	// BatchLimits overrides the non-zero limits of a BatchTranslationProvider;
+	// MaxItems 1 turns batching off.
+	BatchLimits model.BatchLimits
+	// Glossary, when set, attaches the terms each request uses so prompts
+	// can mention them and QA can check they were followed.
+	Glossary *Glossary
+	// Protected, when set, lists the terms masked in every request and
+	// expected back verbatim.
+	Protected *ProtectedTerms
+	// Fallback, when set, translates again the requests that failed with
+	// one of the FallbackOn error classes, quota and unsupported when empty.
+	// It may have a Fallback of its own, forming a chain.
+	Fallback   *TranslationService
+	FallbackOn []string
+	// Budget, when set, refuses calls that would take the run over its
+	// limit; TranslateBatch then stops dispatching. Share it across a
+	// fallback chain.
+	Budget *Budget
+	// Events, when set, receives the lifecycle events of every request.
+	// Share it across a fallback chain.
+	Events *EventBus
+
+	failures   int
+	duplicates int
+	savedCalls int
 }
 
-// ProgressReporter reports translation progress as responses are produced.
-// done is the number of completed requests, total is the total number of requests,
-// and last is the most recent response.
-type ProgressReporter func(done, total int, last model.TranslationResponse)
+// ErrTooManyFailures is returned by TranslateBatch when a ContinueOnError
+// service exceeds MaxErrors.
+var ErrTooManyFailures = errors.New("too many failed translations")
+
+// ErrDroppedPlaceholders marks a translation that lost substitution tokens.
+var ErrDroppedPlaceholders = errors.New("translation dropped placeholders")
 
 // NewTranslationService creates a new TranslationService instance
 func NewTranslationService(provider model.TranslationProvider, concurrency int, timeout time.Duration) *TranslationService {
@@ -27,22 +74,44 @@ func NewTranslationService(provider model.TranslationProvider, concurrency int,
 		Provider:    provider,
 		Concurrency: concurrency,
 		Timeout:     timeout,
+		Retry:       DefaultRetryPolicy(),
 	}
 }
 
-// TranslateBatch translates multiple strings concurrently with optional progress reporting.
-func (s *TranslationService) TranslateBatch(ctx context.Context, requests []model.TranslationRequest, progress ProgressReporter) ([]model.TranslationResponse, error) {
+// TranslateBatch translates multiple strings concurrently, reporting their
+// progress to Events. Requests with the same text and context are translated
+// once and the result is fanned out to each of them. Failed requests are
+// returned as responses with Error set; unless ContinueOnError is set, the
+// first failed call also cancels the rest of the batch. Rejected translations
+// (see IsRejected) are only left out and never cancel anything. When the
+// Budget refuses a call, the batch stops dispatching and returns an error
+// wrapping ErrBudgetExceeded; requests that were already queued come back with
+// that error and the rest are left out.
+func (s *TranslationService) TranslateBatch(ctx context.Context, requests []model.TranslationRequest) ([]model.TranslationResponse, error) {
 	if len(requests) == 0 {
 		return nil, nil
 	}
+	for _, req := range requests {
+		s.Events.emitRequest(EventQueued, req, Event{Total: len(requests)})
+	}
+
+	annotated := s.annotate(requests)
+	unique, duplicates := groupDuplicates(annotated)
+	sources := make(map[unitID]string, len(requests))
+	for _, req := range requests {
+		sources[unitID{req.Key, req.TargetLanguage, req.Path}] = req.Text
+	}
 
 	// Create a context with timeout
 	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
 	defer cancel()
 
 	// Create channels
-	bufferSize := s.bufferSize(len(requests))
-	reqChan := make(chan model.TranslationRequest, bufferSize)
+	jobs := s.packBatches(unique)
+	s.duplicates += len(requests) - len(unique)
+	s.savedCalls += len(s.packBatches(annotated)) - len(jobs)
+	bufferSize := s.bufferSize(len(jobs))
+	reqChan := make(chan []model.TranslationRequest, bufferSize)
 	respChan := make(chan model.TranslationResponse, bufferSize)
 
 	// Start worker goroutines
@@ -55,16 +124,21 @@ func (s *TranslationService) TranslateBatch(ctx context.Context, requests []mode
 		}(i)
 	}
 
-	// Send requests to the channel
+	// Send requests to the channel until the budget runs out
+	budgetSpent := make(chan struct{})
 	go func() {
-		for _, req := range requests {
+		// Closing on every way out lets idle workers finish, even when the
+		// context is cancelled.
+		defer close(reqChan)
+		for _, job := range jobs {
 			select {
-			case reqChan <- req:
+			case reqChan <- job:
+			case <-budgetSpent:
+				return
 			case <-ctx.Done():
 				return
 			}
 		}
-		close(reqChan)
 	}()
 
 	// Collect responses
@@ -77,15 +151,58 @@ func (s *TranslationService) TranslateBatch(ctx context.Context, requests []mode
 	var responses []model.TranslationResponse
 	completed := 0
 	var firstErr error
-	for resp := range respChan {
+	handle := func(resp model.TranslationResponse) {
 		responses = append(responses, resp)
-		if resp.Error != nil && firstErr == nil {
-			firstErr = fmt.Errorf("translation failed for key %s to %s: %w", resp.Key, resp.TargetLanguage, resp.Error)
+		if err := s.Checkpoint.Record(resp, sources[unitID{resp.Key, resp.TargetLanguage, resp.Path}]); err != nil && firstErr == nil {
+			firstErr = err
 			cancel()
 		}
+		if errors.Is(resp.Error, ErrBudgetExceeded) {
+			// Not a failure: calls in flight finish and nothing else is sent.
+			if firstErr == nil {
+				firstErr = fmt.Errorf("stopped at key %s to %s: %w", resp.Key, resp.TargetLanguage, resp.Error)
+				close(budgetSpent)
+			}
+		} else if IsRejected(resp.Error) {
+			// Excluded on its own; the rest of the batch goes on.
+		} else if resp.Error != nil {
+			s.failures++
+			if firstErr == nil {
+				if !s.ContinueOnError {
+					firstErr = fmt.Errorf("translation failed for key %s to %s: %w", resp.Key, resp.TargetLanguage, resp.Error)
+					cancel()
+				} else if s.MaxErrors > 0 && s.failures > s.MaxErrors {
+					firstErr = fmt.Errorf("%w: %d failed, limit is %d", ErrTooManyFailures, s.failures, s.MaxErrors)
+					cancel()
+				}
+			}
+		}
 		completed++
-		if progress != nil {
-			progress(completed, len(requests), resp)
+		kind := EventSucceeded
+		if resp.Error != nil {
+			kind = EventFailed
+		}
+		s.Events.emit(Event{
+			Kind:     kind,
+			Key:      resp.Key,
+			Language: resp.TargetLanguage,
+			Path:     resp.Path,
+			Provider: resp.Provider,
+			Err:      resp.Error,
+			Response: &resp,
+			Done:     completed,
+			Total:    len(requests),
+		})
+	}
+	for resp := range respChan {
+		handle(resp)
+		for _, dup := range duplicates[unitID{resp.Key, resp.TargetLanguage, resp.Path}] {
+			fanned := resp
+			fanned.Key = dup.Key
+			fanned.Path = dup.Path
+			fanned.Usage = model.Usage{}
+			fanned.Latency = 0
+			handle(fanned)
 		}
 	}
 
@@ -100,22 +217,135 @@ func (s *TranslationService) TranslateBatch(ctx context.Context, requests []mode
 	return responses, nil
 }
 
-// worker processes translation requests from the channel
-func (s *TranslationService) worker(ctx context.Context, reqChan <-chan model.TranslationRequest, respChan chan<- model.TranslationResponse, workerID int) {
-	for req := range reqChan {
+// Duplicates returns how many requests reused the translation of an
+// identical one across every batch the service ran.
+func (s *TranslationService) Duplicates() int {
+	return s.duplicates
+}
+
+// SavedCalls returns how many provider calls deduplication has saved across
+// every batch the service ran. Several duplicates may share one saved call
+// when the provider takes batches.
+func (s *TranslationService) SavedCalls() int {
+	return s.savedCalls
+}
+
+// annotate attaches the glossary and protected terms each request uses.
+func (s *TranslationService) annotate(requests []model.TranslationRequest) []model.TranslationRequest {
+	return s.Protected.annotate(s.Glossary.annotate(requests))
+}
+
+// duplicateKey is what makes two requests interchangeable: the same text in
+// the same context, for the same plural form and device, and either both or
+// neither translated again.
+type duplicateKey struct {
+	text, source, target, comment, plural, device string
+	retranslate                                   bool
+}
+
+// groupDuplicates keeps the first request of every group of interchangeable
+// requests, and maps each kept request to the others in its group.
+func groupDuplicates(requests []model.TranslationRequest) ([]model.TranslationRequest, map[unitID][]model.TranslationRequest) {
+	first := make(map[duplicateKey]unitID, len(requests))
+	var unique []model.TranslationRequest
+	duplicates := map[unitID][]model.TranslationRequest{}
+	for _, req := range requests {
+		key := duplicateKey{req.Text, req.SourceLanguage, req.TargetLanguage, req.Comment, PluralCategoryOf(req.Path), DeviceOf(req.Path), req.Current != ""}
+		if id, ok := first[key]; ok {
+			duplicates[id] = append(duplicates[id], req)
+			continue
+		}
+		first[key] = unitID{req.Key, req.TargetLanguage, req.Path}
+		unique = append(unique, req)
+	}
+	return unique, duplicates
+}
+
+// worker processes batches of translation requests from the channel
+func (s *TranslationService) worker(ctx context.Context, reqChan <-chan []model.TranslationRequest, respChan chan<- model.TranslationResponse, workerID int) {
+	for job := range reqChan {
 		select {
 		case <-ctx.Done():
 			return
 		default:
-			resp, err := s.Provider.Translate(ctx, req)
-			if err != nil {
-				resp.Error = err
+			for _, resp := range s.translateJob(ctx, job) {
+				respChan <- resp
 			}
-			respChan <- resp
 		}
 	}
 }
 
+// translate answers req from the translation memory when possible, and
+// otherwise asks the provider and remembers a good result.
+func (s *TranslationService) translate(ctx context.Context, req model.TranslationRequest) model.TranslationResponse {
+	if text, ok := s.lookup(req); ok {
+		return s.cached(req, text)
+	}
+
+	sent, mask := s.mask(req)
+	return s.finish(req, mask, s.call(ctx, []model.TranslationRequest{req}, []model.TranslationRequest{sent})[0])
+}
+
+// lookup returns the remembered translation of req. A unit being translated
+// again is never answered from the memory, which would likely hand back the
+// very translation it replaces.
+func (s *TranslationService) lookup(req model.TranslationRequest) (string, bool) {
+	if req.Current != "" {
+		return "", false
+	}
+	return s.Memory.Lookup(req)
+}
+
+// cached answers req with a translation from the memory, checked against
+// the current QA rules.
+func (s *TranslationService) cached(req model.TranslationRequest, text string) model.TranslationResponse {
+	return s.Validator.apply(req, model.TranslationResponse{
+		Key:            req.Key,
+		TargetLanguage: req.TargetLanguage,
+		Path:           req.Path,
+		Provider:       s.Name,
+		Model:          ModelName(s.Provider),
+		TranslatedText: text,
+		Cached:         true,
+	})
+}
+
+// mask protects the placeholders of req from the provider.
+func (s *TranslationService) mask(req model.TranslationRequest) (model.TranslationRequest, *placeholderMask) {
+	style := maskSentinel
+	if tagAware, ok := s.Provider.(TagAware); ok && tagAware.HandlesTags() {
+		style = maskXML
+	} else if braced, ok := s.Provider.(BraceMasked); ok && braced.MasksWithBraces() {
+		style = maskBraces
+	}
+	return maskPlaceholders(req, style)
+}
+
+// finish restores the placeholders in a provider response to req, checks it
+// and remembers it if it is good.
+func (s *TranslationService) finish(req model.TranslationRequest, mask *placeholderMask, resp model.TranslationResponse) model.TranslationResponse {
+	resp.Key = req.Key
+	resp.TargetLanguage = req.TargetLanguage
+	resp.Path = req.Path
+	resp.Provider = s.Name
+	if resp.Error == nil {
+		resp.TranslatedText, resp.Error = mask.restore(resp.TranslatedText)
+	}
+	if resp.Error == nil {
+		resp = s.Validator.apply(req, resp)
+	}
+	if resp.Error == nil {
+		if missing := missingSubstitutionTokens(req.Text, resp.TranslatedText); len(missing) > 0 {
+			resp.Error = fmt.Errorf("%w %s", ErrDroppedPlaceholders, strings.Join(missing, ", "))
+		}
+	}
+	// The translation being replaced is not remembered as a fresh one.
+	if resp.Error == nil && resp.TranslatedText != req.Current {
+		s.Memory.Store(req, resp.TranslatedText)
+	}
+	return resp
+}
+
 // bufferSize decides the channel buffer size to avoid allocating an excessively large queue.
 func (s *TranslationService) bufferSize(total int) int {
 	if total == 0 {
@@ -137,18 +367,22 @@ func (s *TranslationService) bufferSize(total int) int {
 }
 
 // CreateTranslationRequests creates translation requests from xcstrings data
-func CreateTranslationRequests(xcstrings *model.XCStrings, targetLanguages []string) []model.TranslationRequest {
+func CreateTranslationRequests(xcstrings *model.XCStrings, targetLanguages []string, policy RetranslatePolicy) []model.TranslationRequest {
 	var requests []model.TranslationRequest
 
 	for _, targetLang := range targetLanguages {
-		requests = append(requests, CreateTranslationRequestsForLanguage(xcstrings, targetLang)...)
+		requests = append(requests, CreateTranslationRequestsForLanguage(xcstrings, targetLang, policy)...)
 	}
 
 	return requests
 }
 
 // CreateTranslationRequestsForLanguage builds requests only for the given target language.
-func CreateTranslationRequestsForLanguage(xcstrings *model.XCStrings, targetLanguage string) []model.TranslationRequest {
+// Plural variations in the source produce one request per plural category the
+// target language needs, device variations one request per device variant, and
+// substitutions one request per plural form alongside the %#@name@ template.
+// Units the target already has are skipped unless the policy asks for them.
+func CreateTranslationRequestsForLanguage(xcstrings *model.XCStrings, targetLanguage string, policy RetranslatePolicy) []model.TranslationRequest {
 	var requests []model.TranslationRequest
 
 	for key, entry := range xcstrings.Strings {
@@ -156,59 +390,53 @@ func CreateTranslationRequestsForLanguage(xcstrings *model.XCStrings, targetLang
 			continue
 		}
 
-		sourceText := ""
-		if sourceLangEntry, ok := entry.Localizations[xcstrings.SourceLanguage]; ok {
-			sourceText = sourceLangEntry.StringUnit.Value
-		}
-
-		if key != "" && sourceText == "" {
-			sourceText = key
+		units := sourceUnitsFor(entry.Localizations[xcstrings.SourceLanguage], targetLanguage)
+		if len(units) == 0 && key != "" {
+			units = []sourceUnit{{Text: key}}
 		}
 
-		if sourceText == "" {
-			continue
-		}
-
-		// Skip if already has translation for this target
-		if _, ok := entry.Localizations[targetLanguage]; ok {
-			continue
+		target := entry.Localizations[targetLanguage]
+		for _, unit := range units {
+			current := target.Unit(unit.Path)
+			if !policy.NeedsTranslation(entry, current) {
+				continue
+			}
+			req := model.TranslationRequest{
+				Key:            key,
+				Text:           unit.Text,
+				SourceLanguage: xcstrings.SourceLanguage,
+				TargetLanguage: targetLanguage,
+				Path:           unit.Path,
+				Comment:        entry.Comment,
+			}
+			if current != nil {
+				req.Current = current.Value
+			}
+			requests = append(requests, req)
 		}
-
-		requests = append(requests, model.TranslationRequest{
-			Key:            key,
-			Text:           sourceText,
-			SourceLanguage: xcstrings.SourceLanguage,
-			TargetLanguage: targetLanguage,
-		})
 	}
 
 	return requests
 }
 
 // TranslatePerLanguage runs translations language by language to avoid building a massive request list.
-// The progressBuilder can be nil; when provided it produces a ProgressReporter for each language.
 func TranslatePerLanguage(
 	ctx context.Context,
 	xcstrings *model.XCStrings,
 	targetLanguages []string,
+	policy RetranslatePolicy,
 	service *TranslationService,
-	progressBuilder func(target string, total int) ProgressReporter,
 ) ([]model.TranslationResponse, error) {
 	var allResponses []model.TranslationResponse
 	var translateErr error
 
 	for _, target := range targetLanguages {
-		requests := CreateTranslationRequestsForLanguage(xcstrings, target)
+		requests := CreateTranslationRequestsForLanguage(xcstrings, target, policy)
 		if len(requests) == 0 {
 			continue
 		}
 
-		progress := ProgressReporter(nil)
-		if progressBuilder != nil {
-			progress = progressBuilder(target, len(requests))
-		}
-
-		responses, err := service.TranslateBatch(ctx, requests, progress)
+		responses, err := service.TranslateBatch(ctx, requests)
 		allResponses = append(allResponses, responses...)
 		if err != nil {
 			translateErr = fmt.Errorf("translation to %s failed: %w", target, err)
@@ -219,33 +447,13 @@ func TranslatePerLanguage(
 	return allResponses, translateErr
 }
 
-// NewVerboseProgressReporter prints coarse progress for a single language when verbose is true.
-func NewVerboseProgressReporter(target string, total int, verbose bool) ProgressReporter {
-	if !verbose || total == 0 {
-		return nil
-	}
-
-	// Print roughly every 10% (but at least once) to avoid noisy output.
-	step := total / 10
-	if step == 0 {
-		step = 1
+// ApplyTranslations applies translated responses to the xcstrings data, marking
+// each written unit with state (DefaultOutputState when empty).
+func ApplyTranslations(xcstrings *model.XCStrings, responses []model.TranslationResponse, state string) {
+	if state == "" {
+		state = DefaultOutputState
 	}
 
-	return func(done, total int, last model.TranslationResponse) {
-		if done != total && done%step != 0 {
-			return
-		}
-
-		status := "ok"
-		if last.Error != nil {
-			status = "err"
-		}
-		fmt.Printf("  [%s] %d/%d (%s)\n", target, done, total, status)
-	}
-}
-
-// ApplyTranslations applies translated responses to the xcstrings data
-func ApplyTranslations(xcstrings *model.XCStrings, responses []model.TranslationResponse) {
 	for _, resp := range responses {
 		if resp.Error != nil {
 			fmt.Printf("Error translating key %s: %v\n", resp.Key, resp.Error)
@@ -257,12 +465,13 @@ func ApplyTranslations(xcstrings *model.XCStrings, responses []model.Translation
 				entry.Localizations = make(map[string]model.Localization)
 			}
 
-			entry.Localizations[resp.TargetLanguage] = model.Localization{
-				StringUnit: model.StringUnit{
-					State: "translated",
-					Value: resp.TranslatedText,
-				},
-			}
+			loc := entry.Localizations[resp.TargetLanguage]
+			loc.SetUnit(resp.Path, model.StringUnit{
+				State: state,
+				Value: resp.TranslatedText,
+			})
+			copySubstitutionMetadata(&loc, entry.Localizations[xcstrings.SourceLanguage])
+			entry.Localizations[resp.TargetLanguage] = loc
 
 			xcstrings.Strings[resp.Key] = entry
 		}
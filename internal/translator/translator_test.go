@@ -0,0 +1,383 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// fakeProvider translates by prefixing the text with the target language,
+// unless translate says otherwise, and records the texts it was sent.
+type fakeProvider struct {
+	translate func(req model.TranslationRequest) (string, error)
+
+	mu    sync.Mutex
+	texts []string
+}
+
+func (p *fakeProvider) Translate(ctx context.Context, req model.TranslationRequest) (model.TranslationResponse, error) {
+	p.mu.Lock()
+	p.texts = append(p.texts, req.Text)
+	p.mu.Unlock()
+
+	text := "[" + req.TargetLanguage + "] " + req.Text
+	if p.translate != nil {
+		var err error
+		if text, err = p.translate(req); err != nil {
+			return model.TranslationResponse{}, err
+		}
+	}
+	return model.TranslationResponse{Key: req.Key, TargetLanguage: req.TargetLanguage, TranslatedText: text}, nil
+}
+
+// calls returns how many calls the provider received.
+func (p *fakeProvider) calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.texts)
+}
+
+// newTestService returns a sequential service without retries around provider.
+func newTestService(provider model.TranslationProvider) *TranslationService {
+	return &TranslationService{
+		Provider:    provider,
+		Name:        "fake",
+		Concurrency: 1,
+		Timeout:     time.Minute,
+		Retry:       RetryPolicy{MaxAttempts: 1},
+	}
+}
+
+// request returns a request translating text from English under key.
+func request(key, text, target string) model.TranslationRequest {
+	return model.TranslationRequest{Key: key, Text: text, SourceLanguage: "en", TargetLanguage: target}
+}
+
+// translations maps the keys of the successful responses to their text.
+func translations(responses []model.TranslationResponse) map[string]string {
+	texts := map[string]string{}
+	for _, resp := range responses {
+		if resp.Error == nil {
+			texts[resp.Key] = resp.TranslatedText
+		}
+	}
+	return texts
+}
+
+// failedKeys lists the keys of the failed responses, sorted.
+func failedKeys(responses []model.TranslationResponse) []string {
+	var keys []string
+	for _, resp := range responses {
+		if resp.Error != nil {
+			keys = append(keys, resp.Key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestTranslateWithRetry(t *testing.T) {
+	unavailable := &APIError{StatusCode: 503}
+	tests := []struct {
+		name     string
+		failures []error
+		attempts int
+		wantErr  bool
+	}{
+		{"succeeds at once", nil, 1, false},
+		{"retries a transient error", []error{unavailable, unavailable}, 3, false},
+		{"gives up after the last attempt", []error{unavailable, unavailable, unavailable}, 3, true},
+		{"does not retry a permanent error", []error{&APIError{StatusCode: 403}}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakeProvider{}
+			provider.translate = func(req model.TranslationRequest) (string, error) {
+				if n := provider.calls(); n <= len(tt.failures) {
+					return "", tt.failures[n-1]
+				}
+				return "Hallo", nil
+			}
+			service := newTestService(provider)
+			service.Retry = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+			resp := service.translateWithRetry(context.Background(), request("hello", "Hello", "de"))
+			if got := provider.calls(); got != tt.attempts {
+				t.Errorf("attempts = %d, want %d", got, tt.attempts)
+			}
+			if (resp.Error != nil) != tt.wantErr {
+				t.Errorf("error = %v, want error %v", resp.Error, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTranslateBatchFailures(t *testing.T) {
+	failing := func(req model.TranslationRequest) (string, error) {
+		if strings.HasPrefix(req.Key, "bad") {
+			return "", &APIError{StatusCode: 400, Message: "bad request"}
+		}
+		return "ok " + req.Text, nil
+	}
+	requests := []model.TranslationRequest{
+		request("good1", "One", "de"),
+		request("bad1", "Two", "de"),
+		request("good2", "Three", "de"),
+		request("bad2", "Four", "de"),
+	}
+
+	tests := []struct {
+		name           string
+		maxErrors      int
+		wantErr        error
+		wantFailed     []string
+		wantTranslated []string
+	}{
+		{"no limit", 0, nil, []string{"bad1", "bad2"}, []string{"good1", "good2"}},
+		{"within max errors", 2, nil, []string{"bad1", "bad2"}, []string{"good1", "good2"}},
+		{"over max errors", 1, ErrTooManyFailures, []string{"bad1", "bad2"}, []string{"good1", "good2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestService(&fakeProvider{translate: failing})
+			service.ContinueOnError = true
+			service.MaxErrors = tt.maxErrors
+
+			responses, err := service.TranslateBatch(context.Background(), requests)
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("TranslateBatch error = %v, want %v", err, tt.wantErr)
+			}
+			if got := failedKeys(responses); !slices.Equal(got, tt.wantFailed) {
+				t.Errorf("failed = %v, want %v", got, tt.wantFailed)
+			}
+			var translated []string
+			for key := range translations(responses) {
+				translated = append(translated, key)
+			}
+			sort.Strings(translated)
+			if !slices.Equal(translated, tt.wantTranslated) {
+				t.Errorf("translated = %v, want %v", translated, tt.wantTranslated)
+			}
+		})
+	}
+}
+
+func TestTranslateBatchStopsOnFirstError(t *testing.T) {
+	provider := &fakeProvider{translate: func(req model.TranslationRequest) (string, error) {
+		if req.Key == "k0" {
+			return "", &APIError{StatusCode: 403, Message: "forbidden"}
+		}
+		return "ok", nil
+	}}
+	service := newTestService(provider)
+	var requests []model.TranslationRequest
+	for i := range 20 {
+		requests = append(requests, request(fmt.Sprintf("k%d", i), fmt.Sprintf("Text %d", i), "de"))
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := service.TranslateBatch(context.Background(), requests)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "k0") {
+			t.Errorf("TranslateBatch error = %v, want the failure of k0", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("TranslateBatch did not return after cancelling the batch")
+	}
+	if got := provider.calls(); got == len(requests) {
+		t.Errorf("all %d requests were sent after the first failure", got)
+	}
+}
+
+func TestTranslateBatchDuplicates(t *testing.T) {
+	provider := &fakeProvider{}
+	service := newTestService(provider)
+	button := request("save.button", "Save", "de")
+	button.Comment = "Button title"
+	requests := []model.TranslationRequest{
+		request("save", "Save", "de"),
+		request("save.again", "Save", "de"),
+		button,
+		request("cancel", "Cancel", "de"),
+		request("save.fr", "Save", "fr"),
+	}
+
+	responses, err := service.TranslateBatch(context.Background(), requests)
+	if err != nil {
+		t.Fatalf("TranslateBatch: %v", err)
+	}
+	if got := provider.calls(); got != 4 {
+		t.Errorf("provider calls = %d, want 4", got)
+	}
+	want := map[string]string{
+		"save":        "[de] Save",
+		"save.again":  "[de] Save",
+		"save.button": "[de] Save",
+		"cancel":      "[de] Cancel",
+		"save.fr":     "[fr] Save",
+	}
+	if got := translations(responses); !maps.Equal(got, want) {
+		t.Errorf("translations = %v, want %v", got, want)
+	}
+	if got := service.Duplicates(); got != 1 {
+		t.Errorf("Duplicates = %d, want 1", got)
+	}
+	if got := service.SavedCalls(); got != 1 {
+		t.Errorf("SavedCalls = %d, want 1", got)
+	}
+}
+
+func TestTranslateBatchSavedCalls(t *testing.T) {
+	requests := []model.TranslationRequest{
+		request("save", "Save", "de"),
+		request("save.again", "Save", "de"),
+		request("save.toolbar", "Save", "de"),
+		request("cancel", "Cancel", "de"),
+		request("open", "Open", "de"),
+	}
+	tests := []struct {
+		name      string
+		provider  model.TranslationProvider
+		wantCalls int
+		wantSaved int
+	}{
+		{"one call per text", &fakeProvider{}, 3, 2},
+		{"duplicates in the same batch", &fakeBatchProvider{limits: model.BatchLimits{MaxItems: 10}}, 1, 0},
+		{"duplicates filling a batch", &fakeBatchProvider{limits: model.BatchLimits{MaxItems: 2}}, 2, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestService(tt.provider)
+			if _, err := service.TranslateBatch(context.Background(), requests); err != nil {
+				t.Fatalf("TranslateBatch: %v", err)
+			}
+			calls := 0
+			switch provider := tt.provider.(type) {
+			case *fakeProvider:
+				calls = provider.calls()
+			case *fakeBatchProvider:
+				calls = len(provider.batches) + provider.calls()
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("provider calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if got := service.Duplicates(); got != 2 {
+				t.Errorf("Duplicates = %d, want 2", got)
+			}
+			if got := service.SavedCalls(); got != tt.wantSaved {
+				t.Errorf("SavedCalls = %d, want %d", got, tt.wantSaved)
+			}
+		})
+	}
+}
+
+func TestTranslateBatchQARejection(t *testing.T) {
+	provider := &fakeProvider{translate: func(req model.TranslationRequest) (string, error) {
+		if req.Key == "same" {
+			return req.Text, nil
+		}
+		return "Übersetzt", nil
+	}}
+	service := newTestService(provider)
+	service.Validator = &Validator{Severities: map[string]string{RuleUntranslated: SeverityError}}
+	requests := []model.TranslationRequest{
+		request("same", "Untouched", "de"),
+		request("other", "Translated", "de"),
+		request("third", "Also translated", "de"),
+	}
+
+	responses, err := service.TranslateBatch(context.Background(), requests)
+	if err != nil {
+		t.Fatalf("TranslateBatch = %v; a rejected translation must not cancel the run", err)
+	}
+	if got := failedKeys(responses); !slices.Equal(got, []string{"same"}) {
+		t.Fatalf("failed = %v, want [same]", got)
+	}
+	for _, resp := range responses {
+		if resp.Key == "same" && !IsQAError(resp.Error) {
+			t.Errorf("same: error = %v, want a QA error", resp.Error)
+		}
+	}
+	if got := len(translations(responses)); got != 2 {
+		t.Errorf("%d translations, want 2", got)
+	}
+	if provider.calls() != 3 {
+		t.Errorf("provider calls = %d, want 3", provider.calls())
+	}
+}
+
+func TestTranslateBatchPlaceholderRejection(t *testing.T) {
+	provider := &fakeProvider{translate: func(req model.TranslationRequest) (string, error) {
+		if req.Key == "dropped" {
+			return "Hallo", nil
+		}
+		return "Hallo " + req.Text, nil
+	}}
+	service := newTestService(provider)
+	requests := []model.TranslationRequest{
+		request("dropped", "Hello %@", "de"),
+		request("kept", "Hello %@", "de"),
+		request("plain", "Hello", "de"),
+	}
+	requests[1].Comment = "Greeting"
+
+	responses, err := service.TranslateBatch(context.Background(), requests)
+	if err != nil {
+		t.Fatalf("TranslateBatch = %v; a rejected translation must not cancel the run", err)
+	}
+	if got := failedKeys(responses); !slices.Equal(got, []string{"dropped"}) {
+		t.Fatalf("failed = %v, want [dropped]", got)
+	}
+	for _, resp := range responses {
+		if resp.Key == "dropped" && !errors.Is(resp.Error, ErrDroppedPlaceholders) {
+			t.Errorf("dropped: error = %v, want ErrDroppedPlaceholders", resp.Error)
+		}
+	}
+	if got := translations(responses)["kept"]; got != "Hallo Hello %@" {
+		t.Errorf("kept = %q", got)
+	}
+}
+
+func TestTranslateBatchRetranslateSkipsMemory(t *testing.T) {
+	memory, err := OpenTranslationMemory(filepath.Join(t.TempDir(), "memory.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &fakeProvider{}
+	service := newTestService(provider)
+	service.Memory = memory.Scope("fake", provider)
+	service.Memory.Store(request("", "Save", "de"), "Sichern")
+
+	missing := request("missing", "Save", "de")
+	again := request("again", "Save", "de")
+	again.Current = "Sichern"
+	responses, err := service.TranslateBatch(context.Background(), []model.TranslationRequest{missing, again})
+	if err != nil {
+		t.Fatalf("TranslateBatch: %v", err)
+	}
+
+	want := map[string]string{"missing": "Sichern", "again": "[de] Save"}
+	if got := translations(responses); !maps.Equal(got, want) {
+		t.Errorf("translations = %v, want %v", got, want)
+	}
+	if !slices.Equal(provider.texts, []string{"Save"}) {
+		t.Errorf("provider was sent %q, want only the unit translated again", provider.texts)
+	}
+	if got, _ := service.Memory.Lookup(missing); got != "[de] Save" {
+		t.Errorf("memory = %q, want the new translation", got)
+	}
+}
@@ -0,0 +1,169 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// UsageReport sums what a run sent to its providers, per language and per
+// provider. It is printed at the end of a run and can be saved as JSON.
+type UsageReport struct {
+	GeneratedAt time.Time      `json:"generatedAt"`
+	InputFile   string         `json:"inputFile,omitempty"`
+	Total       UsageSummary   `json:"total"`
+	Languages   []UsageSummary `json:"languages"`
+	Providers   []UsageSummary `json:"providers"`
+}
+
+// UsageSummary is the usage of one language, one provider or the whole run.
+type UsageSummary struct {
+	// Name is the language or the provider; the provider of a model is
+	// written as "provider/model".
+	Name string `json:"name"`
+	// Translated counts the successful translations, of which Cached came
+	// from the translation memory; Failed counts the rest.
+	Translated       int `json:"translated"`
+	Cached           int `json:"cached"`
+	Failed           int `json:"failed"`
+	Characters       int `json:"characters"`
+	PromptTokens     int `json:"promptTokens"`
+	CompletionTokens int `json:"completionTokens"`
+	// LatencyMS is the time spent in provider calls.
+	LatencyMS int64 `json:"latencyMs"`
+	// Cost is priced from the usage; nil when a provider used has no price.
+	Cost *float64 `json:"cost,omitempty"`
+
+	latency  time.Duration
+	unpriced bool
+}
+
+// NewUsageReport sums the usage of responses, pricing it with prices.
+func NewUsageReport(responses []model.TranslationResponse, prices PriceTable) UsageReport {
+	report := UsageReport{GeneratedAt: time.Now().UTC()}
+	languages := map[string]*UsageSummary{}
+	providers := map[string]*UsageSummary{}
+	for _, resp := range responses {
+		provider := resp.Provider
+		if resp.Model != "" {
+			provider += "/" + resp.Model
+		}
+		var cost *float64
+		if resp.Usage != (model.Usage{}) {
+			if price, ok := prices.Lookup(resp.Provider, resp.Model); ok {
+				c := price.Cost(resp.Usage)
+				cost = &c
+			}
+		} else {
+			// Nothing billed, so nothing to price.
+			cost = new(float64)
+		}
+
+		report.Total.add(resp, cost)
+		summaryFor(languages, resp.TargetLanguage).add(resp, cost)
+		if resp.Provider != "" {
+			summaryFor(providers, provider).add(resp, cost)
+		}
+	}
+	report.Total.Name = "Total"
+	report.Languages = sortedSummaries(languages)
+	report.Providers = sortedSummaries(providers)
+	return report
+}
+
+func summaryFor(summaries map[string]*UsageSummary, name string) *UsageSummary {
+	summary, ok := summaries[name]
+	if !ok {
+		summary = &UsageSummary{Name: name}
+		summaries[name] = summary
+	}
+	return summary
+}
+
+func sortedSummaries(summaries map[string]*UsageSummary) []UsageSummary {
+	sorted := []UsageSummary{}
+	for _, summary := range summaries {
+		sorted = append(sorted, *summary)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// add counts resp, billed at cost; a nil cost leaves the summary unpriced.
+func (u *UsageSummary) add(resp model.TranslationResponse, cost *float64) {
+	switch {
+	case resp.Error != nil:
+		u.Failed++
+	case resp.Cached:
+		u.Translated++
+		u.Cached++
+	default:
+		u.Translated++
+	}
+	u.Characters += resp.Usage.Characters
+	u.PromptTokens += resp.Usage.PromptTokens
+	u.CompletionTokens += resp.Usage.CompletionTokens
+	u.latency += resp.Latency
+	u.LatencyMS = u.latency.Milliseconds()
+
+	if cost == nil {
+		u.unpriced = true
+		u.Cost = nil
+		return
+	}
+	if u.unpriced {
+		return
+	}
+	if u.Cost == nil {
+		u.Cost = new(float64)
+	}
+	*u.Cost += *cost
+}
+
+// Billed reports whether the run sent anything to a provider.
+func (r UsageReport) Billed() bool {
+	return r.Total.Characters > 0 || r.Total.PromptTokens > 0 || r.Total.CompletionTokens > 0
+}
+
+// WriteUsageSummary writes report as a table of languages, then of providers
+// when there are several, each followed by the total.
+func WriteUsageSummary(w io.Writer, report UsageReport) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Language\tTranslated\tFrom memory\tFailed\tCharacters\tPrompt tokens\tCompletion tokens\tProvider time\tCost\t")
+	for _, summary := range report.Languages {
+		writeUsageRow(tw, summary)
+	}
+	if len(report.Providers) > 1 {
+		fmt.Fprintln(tw, "Provider\t\t\t\t\t\t\t\t\t")
+		for _, summary := range report.Providers {
+			writeUsageRow(tw, summary)
+		}
+	}
+	if len(report.Languages) > 1 || len(report.Providers) > 1 {
+		writeUsageRow(tw, report.Total)
+	}
+	return tw.Flush()
+}
+
+func writeUsageRow(w io.Writer, u UsageSummary) {
+	fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t%s\t\n", u.Name, u.Translated, u.Cached, u.Failed,
+		u.Characters, u.PromptTokens, u.CompletionTokens, u.latency.Round(time.Millisecond), formatCost(u.Cost))
+}
+
+// WriteUsageReport saves report as indented JSON.
+func WriteUsageReport(path string, report UsageReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write usage report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// meteredProvider is a fakeProvider billed per token that reports no usage.
+type meteredProvider struct {
+	fakeProvider
+}
+
+func (p *meteredProvider) MetersTokens() bool { return true }
+
+func TestNewUsageReport(t *testing.T) {
+	responses := []model.TranslationResponse{
+		{Key: "a", TargetLanguage: "de", Provider: "deepl", Usage: model.Usage{Characters: 10}, Latency: 100 * time.Millisecond},
+		{Key: "b", TargetLanguage: "de", Provider: "deepl", Cached: true},
+		{Key: "c", TargetLanguage: "de", Provider: "deepl", Error: errors.New("failed")},
+		{Key: "a", TargetLanguage: "ja", Provider: "openai", Model: "gpt-4o", Usage: model.Usage{Characters: 10, PromptTokens: 1000, CompletionTokens: 500}, Latency: 200 * time.Millisecond},
+		{Key: "b", TargetLanguage: "ja", Provider: "google", Usage: model.Usage{Characters: 5}},
+	}
+	prices := PriceTable{
+		"deepl":         {PerMillionCharacters: 20_000},
+		"openai/gpt-4o": {PerMillionInputTokens: 2000, PerMillionOutputTokens: 4000},
+	}
+	report := NewUsageReport(responses, prices)
+
+	type row struct {
+		name                           string
+		translated, cached, failed     int
+		characters, prompt, completion int
+		latencyMS                      int64
+		cost                           string
+	}
+	describe := func(u UsageSummary) row {
+		cost := "unpriced"
+		if u.Cost != nil {
+			cost = formatCost(u.Cost)
+		}
+		return row{u.Name, u.Translated, u.Cached, u.Failed, u.Characters, u.PromptTokens, u.CompletionTokens, u.LatencyMS, cost}
+	}
+	deeplCost, openaiCost := 0.2, 4.0
+	tests := []struct {
+		name string
+		got  []UsageSummary
+		want []row
+	}{
+		{"languages", report.Languages, []row{
+			{"de", 2, 1, 1, 10, 0, 0, 100, formatCost(&deeplCost)},
+			// google has no price, so neither has Japanese.
+			{"ja", 2, 0, 0, 15, 1000, 500, 200, "unpriced"},
+		}},
+		{"providers", report.Providers, []row{
+			{"deepl", 2, 1, 1, 10, 0, 0, 100, formatCost(&deeplCost)},
+			{"google", 1, 0, 0, 5, 0, 0, 0, "unpriced"},
+			{"openai/gpt-4o", 1, 0, 0, 10, 1000, 500, 200, formatCost(&openaiCost)},
+		}},
+		{"total", []UsageSummary{report.Total}, []row{
+			{"Total", 4, 1, 1, 25, 1000, 500, 300, "unpriced"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.got) != len(tt.want) {
+				t.Fatalf("%d summaries, want %d", len(tt.got), len(tt.want))
+			}
+			for i, summary := range tt.got {
+				if got := describe(summary); got != tt.want[i] {
+					t.Errorf("summary %d = %+v, want %+v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+	if !report.Billed() {
+		t.Error("Billed = false")
+	}
+}
+
+func TestUsageReportNothingBilled(t *testing.T) {
+	report := NewUsageReport([]model.TranslationResponse{
+		{Key: "a", TargetLanguage: "de", Provider: "google", Cached: true},
+	}, nil)
+	if report.Billed() {
+		t.Error("Billed = true for a run answered from memory")
+	}
+	// Nothing was sent, so the run is priced at zero even without prices.
+	if report.Total.Cost == nil || *report.Total.Cost != 0 {
+		t.Errorf("Cost = %v, want 0", report.Total.Cost)
+	}
+}
+
+func TestWriteUsage(t *testing.T) {
+	report := NewUsageReport([]model.TranslationResponse{
+		{Key: "a", TargetLanguage: "de", Provider: "deepl", Usage: model.Usage{Characters: 10}},
+		{Key: "a", TargetLanguage: "ja", Provider: "google", Usage: model.Usage{Characters: 5}},
+	}, nil)
+
+	var out bytes.Buffer
+	if err := WriteUsageSummary(&out, report); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"de", "ja", "Provider", "deepl", "google", "Total"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("summary lacks %q:\n%s", want, out.String())
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "usage.json")
+	if err := WriteUsageReport(path, report); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTranslateBatchUsage(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider model.TranslationProvider
+		text     string
+		want     model.Usage
+	}{
+		{"characters of the text", &fakeProvider{}, "Save all", model.Usage{Characters: 8}},
+		{"characters before masking", &fakeProvider{}, "%@ & %lld", model.Usage{Characters: 9}},
+		{"characters before escaping into tags", &tagAwareBatchProvider{}, "%@ & %lld", model.Usage{Characters: 9}},
+		{"tokens estimated when unreported", &meteredProvider{}, "Save all", model.Usage{Characters: 8, PromptTokens: promptOverheadTokens + 2, CompletionTokens: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestService(tt.provider)
+			requests := []model.TranslationRequest{request("a", tt.text, "de"), request("b", tt.text, "de")}
+			responses, err := service.TranslateBatch(context.Background(), requests)
+			if err != nil {
+				t.Fatalf("TranslateBatch: %v", err)
+			}
+			for _, resp := range responses {
+				want := tt.want
+				// The duplicate is fanned out without being billed again.
+				if resp.Key == "b" {
+					want = model.Usage{}
+				}
+				if resp.Usage != want {
+					t.Errorf("%s: Usage = %+v, want %+v", resp.Key, resp.Usage, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package translator
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// sourceUnit is a single piece of source text and the unit path it is written back to.
+type sourceUnit struct {
+	Path string
+	Text string
+}
+
+// sourceUnitsFor expands a source localization into the units a target language
+// needs: the template text, its variations and every substitution's plural forms.
+func sourceUnitsFor(loc model.Localization, targetLanguage string) []sourceUnit {
+	var units []sourceUnit
+	if text := loc.Value(); text != "" {
+		units = append(units, sourceUnit{Text: text})
+	}
+	units = append(units, variationUnits(loc.Variations, "", targetLanguage)...)
+
+	names := make([]string, 0, len(loc.Substitutions))
+	for name := range loc.Substitutions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prefix := model.JoinPath("", "substitutions", name)
+		units = append(units, variationUnits(loc.Substitutions[name].Variations, prefix, targetLanguage)...)
+	}
+	return units
+}
+
+// variationUnits expands device and plural variations. Device variants keep the
+// source's device keys (iphone, ipad, mac, ...) and may nest plural variations.
+func variationUnits(variations *model.Variations, prefix, targetLanguage string) []sourceUnit {
+	if variations == nil {
+		return nil
+	}
+
+	var units []sourceUnit
+	devices := make([]string, 0, len(variations.Device))
+	for device := range variations.Device {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+	for _, device := range devices {
+		variation := variations.Device[device]
+		path := model.JoinPath(prefix, "device", device)
+		if variation.StringUnit != nil && variation.StringUnit.Value != "" {
+			units = append(units, sourceUnit{Path: path, Text: variation.StringUnit.Value})
+		}
+		units = append(units, variationUnits(variation.Variations, path, targetLanguage)...)
+	}
+
+	return append(units, pluralUnits(variations, prefix, targetLanguage)...)
+}
+
+// pluralUnits maps the source plural forms onto the target language's CLDR categories.
+func pluralUnits(variations *model.Variations, prefix, targetLanguage string) []sourceUnit {
+	if len(variations.Plural) == 0 {
+		return nil
+	}
+
+	forms := make(map[string]string, len(variations.Plural))
+	for category, variation := range variations.Plural {
+		if variation.StringUnit != nil && variation.StringUnit.Value != "" {
+			forms[category] = variation.StringUnit.Value
+		}
+	}
+
+	var units []sourceUnit
+	for _, category := range PluralCategories(targetLanguage) {
+		text := pluralSourceFor(forms, category)
+		if text == "" {
+			continue
+		}
+		units = append(units, sourceUnit{Path: model.JoinPath(prefix, "plural", category), Text: text})
+	}
+	return units
+}
+
+// PluralCategoryOf returns the plural category addressed by the last "plural"
+// segment of a unit path, or "" when the path is not a plural form.
+func PluralCategoryOf(path string) string {
+	i := strings.LastIndex(path, "plural.")
+	if i < 0 || (i > 0 && path[i-1] != '.') {
+		return ""
+	}
+	rest := path[i+len("plural."):]
+	if j := strings.IndexByte(rest, '.'); j >= 0 {
+		return ""
+	}
+	return rest
+}
+
+// DeviceOf returns the device class addressed by a unit path such as
+// "device.ipad.plural.one", or "" when the path has no device segment.
+func DeviceOf(path string) string {
+	segs := strings.Split(path, ".")
+	for i := 0; i+1 < len(segs); i += 2 {
+		if segs[i] == "device" {
+			return segs[i+1]
+		}
+	}
+	return ""
+}
+
+// substitutionToken matches %#@name@ substitution references (optionally
+// positional, e.g. %1$#@name@) and the %arg placeholder used inside substitution forms.
+var substitutionToken = regexp.MustCompile(`%(?:\d+\$)?#@[A-Za-z0-9_]+@|%arg`)
+
+// missingSubstitutionTokens lists substitution tokens present in source but absent from translated.
+func missingSubstitutionTokens(source, translated string) []string {
+	var missing []string
+	seen := map[string]bool{}
+	for _, token := range substitutionToken.FindAllString(source, -1) {
+		if seen[token] {
+			continue
+		}
+		seen[token] = true
+		if !strings.Contains(translated, token) {
+			missing = append(missing, token)
+		}
+	}
+	return missing
+}
+
+// copySubstitutionMetadata gives target the argNum and formatSpecifier of the
+// source substitutions it uses, so the emitted block is valid for Xcode.
+func copySubstitutionMetadata(target *model.Localization, source model.Localization) {
+	for name, sub := range target.Substitutions {
+		src, ok := source.Substitutions[name]
+		if !ok {
+			continue
+		}
+		if sub.ArgNum == 0 {
+			sub.ArgNum = src.ArgNum
+		}
+		if sub.FormatSpecifier == "" {
+			sub.FormatSpecifier = src.FormatSpecifier
+		}
+		target.Substitutions[name] = sub
+	}
+}
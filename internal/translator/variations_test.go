@@ -0,0 +1,239 @@
+package translator
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/fdddf/xcstrings-translator/internal/model"
+)
+
+// parseCatalog parses an English catalog made of the given strings object.
+func parseCatalog(t *testing.T, strings string) *model.XCStrings {
+	t.Helper()
+	xcstrings, err := model.ParseXCStrings([]byte(`{"sourceLanguage": "en", "version": "1.0", "strings": ` + strings + `}`))
+	if err != nil {
+		t.Fatalf("ParseXCStrings: %v", err)
+	}
+	return xcstrings
+}
+
+// describeRequests lists the requests as "key|path|text", sorted.
+func describeRequests(requests []model.TranslationRequest) []string {
+	described := make([]string, len(requests))
+	for i, req := range requests {
+		described[i] = fmt.Sprintf("%s|%s|%s", req.Key, req.Path, req.Text)
+	}
+	sort.Strings(described)
+	return described
+}
+
+const pluralCatalog = `{
+  "%lld files": {"localizations": {"en": {"variations": {"plural": {
+    "one": {"stringUnit": {"state": "translated", "value": "%lld file"}},
+    "other": {"stringUnit": {"state": "translated", "value": "%lld files"}}
+  }}}}},
+  "Hello": {},
+  "Internal": {"shouldTranslate": false}
+}`
+
+const deviceCatalog = `{
+  "Tap to continue": {"localizations": {"en": {"variations": {"device": {
+    "iphone": {"stringUnit": {"state": "translated", "value": "Tap to continue"}},
+    "mac": {"stringUnit": {"state": "translated", "value": "Click to continue"}}
+  }}}}},
+  "%lld photos": {"localizations": {"en": {"variations": {"device": {
+    "ipad": {"variations": {"plural": {
+      "one": {"stringUnit": {"state": "translated", "value": "%lld photo on this iPad"}},
+      "other": {"stringUnit": {"state": "translated", "value": "%lld photos on this iPad"}}
+    }}},
+    "other": {"stringUnit": {"state": "translated", "value": "%lld photos"}}
+  }}}}}
+}`
+
+const substitutionCatalog = `{
+  "%lld photos in %lld albums": {"localizations": {"en": {
+    "stringUnit": {"state": "translated", "value": "%#@photos@ in %#@albums@"},
+    "substitutions": {
+      "photos": {"argNum": 1, "formatSpecifier": "lld", "variations": {"plural": {
+        "one": {"stringUnit": {"state": "translated", "value": "%arg photo"}},
+        "other": {"stringUnit": {"state": "translated", "value": "%arg photos"}}
+      }}},
+      "albums": {"argNum": 2, "formatSpecifier": "lld", "variations": {"plural": {
+        "one": {"stringUnit": {"state": "translated", "value": "%arg album"}},
+        "other": {"stringUnit": {"state": "translated", "value": "%arg albums"}}
+      }}}
+    }
+  }}}
+}`
+
+func TestCreateTranslationRequestsForLanguage(t *testing.T) {
+	tests := []struct {
+		name    string
+		catalog string
+		target  string
+		want    []string
+	}{
+		{"plural to one/other", pluralCatalog, "de", []string{
+			"%lld files|plural.one|%lld file",
+			"%lld files|plural.other|%lld files",
+			"Hello||Hello",
+		}},
+		{"plural to other only", pluralCatalog, "ja", []string{
+			"%lld files|plural.other|%lld files",
+			"Hello||Hello",
+		}},
+		{"plural to more categories", pluralCatalog, "ru", []string{
+			"%lld files|plural.few|%lld files",
+			"%lld files|plural.many|%lld files",
+			"%lld files|plural.one|%lld file",
+			"%lld files|plural.other|%lld files",
+			"Hello||Hello",
+		}},
+		{"device variations", deviceCatalog, "de", []string{
+			"%lld photos|device.ipad.plural.one|%lld photo on this iPad",
+			"%lld photos|device.ipad.plural.other|%lld photos on this iPad",
+			"%lld photos|device.other|%lld photos",
+			"Tap to continue|device.iphone|Tap to continue",
+			"Tap to continue|device.mac|Click to continue",
+		}},
+		{"device variations with plural", deviceCatalog, "ja", []string{
+			"%lld photos|device.ipad.plural.other|%lld photos on this iPad",
+			"%lld photos|device.other|%lld photos",
+			"Tap to continue|device.iphone|Tap to continue",
+			"Tap to continue|device.mac|Click to continue",
+		}},
+		{"substitutions", substitutionCatalog, "de", []string{
+			"%lld photos in %lld albums|substitutions.albums.plural.one|%arg album",
+			"%lld photos in %lld albums|substitutions.albums.plural.other|%arg albums",
+			"%lld photos in %lld albums|substitutions.photos.plural.one|%arg photo",
+			"%lld photos in %lld albums|substitutions.photos.plural.other|%arg photos",
+			"%lld photos in %lld albums||%#@photos@ in %#@albums@",
+		}},
+		{"substitutions to other only", substitutionCatalog, "zh-Hans", []string{
+			"%lld photos in %lld albums|substitutions.albums.plural.other|%arg albums",
+			"%lld photos in %lld albums|substitutions.photos.plural.other|%arg photos",
+			"%lld photos in %lld albums||%#@photos@ in %#@albums@",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := CreateTranslationRequestsForLanguage(parseCatalog(t, tt.catalog), tt.target, RetranslatePolicy{})
+			if got := describeRequests(requests); !slices.Equal(got, tt.want) {
+				t.Errorf("requests =\n%q\nwant\n%q", got, tt.want)
+			}
+			for _, req := range requests {
+				if req.SourceLanguage != "en" || req.TargetLanguage != tt.target {
+					t.Errorf("%s: languages %s to %s", req.Key, req.SourceLanguage, req.TargetLanguage)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyTranslations(t *testing.T) {
+	tests := []struct {
+		name      string
+		catalog   string
+		responses []model.TranslationResponse
+	}{
+		{"plain and plural", pluralCatalog, []model.TranslationResponse{
+			{Key: "Hello", TargetLanguage: "de", TranslatedText: "Hallo"},
+			{Key: "%lld files", TargetLanguage: "de", Path: "plural.one", TranslatedText: "%lld Datei"},
+			{Key: "%lld files", TargetLanguage: "de", Path: "plural.other", TranslatedText: "%lld Dateien"},
+		}},
+		{"device variations", deviceCatalog, []model.TranslationResponse{
+			{Key: "%lld photos", TargetLanguage: "de", Path: "device.ipad.plural.one", TranslatedText: "%lld Foto auf diesem iPad"},
+			{Key: "Tap to continue", TargetLanguage: "de", Path: "device.mac", TranslatedText: "Zum Fortfahren klicken"},
+		}},
+		{"substitutions", substitutionCatalog, []model.TranslationResponse{
+			{Key: "%lld photos in %lld albums", TargetLanguage: "de", TranslatedText: "%#@photos@ in %#@albums@"},
+			{Key: "%lld photos in %lld albums", TargetLanguage: "de", Path: "substitutions.photos.plural.other", TranslatedText: "%arg Fotos"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xcstrings := parseCatalog(t, tt.catalog)
+			ApplyTranslations(xcstrings, tt.responses, "")
+			for _, resp := range tt.responses {
+				unit := xcstrings.Strings[resp.Key].Localizations["de"].Unit(resp.Path)
+				if unit == nil || unit.Value != resp.TranslatedText || unit.State != StateNeedsReview {
+					t.Errorf("%s %s = %+v, want %q needing review", resp.Key, resp.Path, unit, resp.TranslatedText)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyTranslationsSkipsFailures(t *testing.T) {
+	xcstrings := parseCatalog(t, pluralCatalog)
+	ApplyTranslations(xcstrings, []model.TranslationResponse{
+		{Key: "Hello", TargetLanguage: "de", Error: fmt.Errorf("failed")},
+	}, "")
+	if _, ok := xcstrings.Strings["Hello"].Localizations["de"]; ok {
+		t.Error("a failed response was applied")
+	}
+}
+
+func TestApplyTranslationsCopiesSubstitutionMetadata(t *testing.T) {
+	xcstrings := parseCatalog(t, substitutionCatalog)
+	ApplyTranslations(xcstrings, []model.TranslationResponse{
+		{Key: "%lld photos in %lld albums", TargetLanguage: "de", Path: "substitutions.photos.plural.one", TranslatedText: "%arg Foto"},
+	}, "")
+	photos := xcstrings.Strings["%lld photos in %lld albums"].Localizations["de"].Substitutions["photos"]
+	if photos.ArgNum != 1 || photos.FormatSpecifier != "lld" {
+		t.Errorf("photos substitution = %+v, want the source's argNum and formatSpecifier", photos)
+	}
+}
+
+func TestPluralCategoryOf(t *testing.T) {
+	tests := []struct{ path, want string }{
+		{"", ""},
+		{"plural.one", "one"},
+		{"device.ipad.plural.few", "few"},
+		{"substitutions.count.plural.other", "other"},
+		{"device.iphone", ""},
+		{"plural.one.device.mac", ""},
+		{"substitutions.myplural.device.mac", ""},
+	}
+	for _, tt := range tests {
+		if got := PluralCategoryOf(tt.path); got != tt.want {
+			t.Errorf("PluralCategoryOf(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDeviceOf(t *testing.T) {
+	tests := []struct{ path, want string }{
+		{"", ""},
+		{"plural.one", ""},
+		{"device.mac", "mac"},
+		{"device.ipad.plural.one", "ipad"},
+		{"substitutions.device.plural.one", ""},
+	}
+	for _, tt := range tests {
+		if got := DeviceOf(tt.path); got != tt.want {
+			t.Errorf("DeviceOf(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMissingSubstitutionTokens(t *testing.T) {
+	tests := []struct {
+		source, translated string
+		want               []string
+	}{
+		{"%#@photos@ in %#@albums@", "%#@albums@ mit %#@photos@", nil},
+		{"%#@photos@ in %#@albums@", "%#@photos@", []string{"%#@albums@"}},
+		{"%1$#@photos@", "%#@photos@", []string{"%1$#@photos@"}},
+		{"%arg photos", "%arg Fotos", nil},
+		{"%arg photos", "Fotos", []string{"%arg"}},
+		{"No tokens", "Keine", nil},
+	}
+	for _, tt := range tests {
+		if got := missingSubstitutionTokens(tt.source, tt.translated); !slices.Equal(got, tt.want) {
+			t.Errorf("missingSubstitutionTokens(%q, %q) = %q, want %q", tt.source, tt.translated, got, tt.want)
+		}
+	}
+}